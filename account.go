@@ -0,0 +1,262 @@
+package bitstamp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Balance is a balance of a single currency.
+type Balance struct {
+	Available float64
+	Reserved  float64
+	Total     float64
+	// The raw fields are the original values sent by the api, if Api.RawNumbers is set.
+	AvailableRaw string
+	ReservedRaw  string
+	TotalRaw     string
+}
+
+// Balances are balances of an account.
+type Balances struct {
+	// Currencies maps lowercase currency names to their balances.
+	Currencies map[string]Balance
+	// Fees maps lowercase pair symbols to the trading fee in percents.
+	Fees map[string]float64
+	// FeesRaw maps lowercase pair symbols to the original fee values, if Api.RawNumbers is set.
+	FeesRaw map[string]string
+}
+
+// Get returns the balance of the given currency.
+// A zero balance is returned for unknown currencies.
+func (b *Balances) Get(currency string) Balance {
+	return b.Currencies[strings.ToLower(currency)]
+}
+
+// Fee returns the trading fee of the given symbol in percents, and whether it is known.
+func (b *Balances) Fee(symbol string) (float64, bool) {
+	fee, found := b.Fees[strings.ToLower(symbol)]
+	return fee, found
+}
+
+// GetAccountBalance returns balances of all currencies and fees of all pairs.
+func (api *Api) GetAccountBalance() (*Balances, error) {
+	return api.GetAccountBalanceCtx(context.Background())
+}
+
+// GetAccountBalanceCtx is like GetAccountBalance, but uses the given context.
+func (api *Api) GetAccountBalanceCtx(ctx context.Context) (*Balances, error) {
+	body, err := api.read(ctx, "/balance/", nil)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := parseBalances(body)
+	if err == nil && api.RawNumbers {
+		err = setBalancesRaw(balances, body)
+	}
+	return balances, err
+}
+
+// parseBalances decodes a flat balance object, like {"btc_available": "1.0", "btcusd_fee": "0.5"}.
+// Keys are matched by their suffixes, so that new currencies need no code changes.
+func parseBalances(data []byte) (*Balances, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &Balances{
+		Currencies: make(map[string]Balance),
+		Fees:       make(map[string]float64),
+	}
+	for key, value := range raw {
+		name, field, ok := splitBalanceKey(key)
+		if !ok || value == nil {
+			continue
+		}
+		var target *float64
+		b := result.Currencies[name]
+		switch field {
+		case "available":
+			target = &b.Available
+		case "balance":
+			target = &b.Total
+		case "reserved":
+			target = &b.Reserved
+		case "fee":
+			fee, err := parseNumber(key, value)
+			if err != nil {
+				return nil, err
+			}
+			result.Fees[name] = fee
+			continue
+		default:
+			continue
+		}
+		v, err := parseNumber(key, value)
+		if err != nil {
+			return nil, err
+		}
+		*target = v
+		result.Currencies[name] = b
+	}
+	return result, nil
+}
+
+// splitBalanceKey splits a balance key, like btc_available, into the currency or the symbol,
+// and the field name. Withdrawal fees are skipped.
+func splitBalanceKey(key string) (name, field string, ok bool) {
+	if strings.HasSuffix(key, "_withdrawal_fee") {
+		return "", "", false
+	}
+	idx := strings.LastIndexByte(key, '_')
+	if idx <= 0 {
+		return "", "", false
+	}
+	return key[:idx], key[idx+1:], true
+}
+
+// PairBalance is a balance of the currencies of a single pair.
+type PairBalance struct {
+	BaseAvailable  float64
+	QuoteAvailable float64
+	BaseReserved   float64
+	QuoteReserved  float64
+	// Fee is the trading fee for the pair in percents.
+	Fee float64
+}
+
+// GetPairBalance returns balances and the trading fee for the given symbol.
+func (api *Api) GetPairBalance(symbol string) (*PairBalance, error) {
+	return api.GetPairBalanceCtx(context.Background(), symbol)
+}
+
+// GetPairBalanceCtx is like GetPairBalance, but uses the given context.
+func (api *Api) GetPairBalanceCtx(ctx context.Context, symbol string) (*PairBalance, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	symbol = strings.ToLower(symbol)
+	body, err := api.read(ctx, "/balance/"+symbol+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parsePairBalance(symbol, body)
+}
+
+func parsePairBalance(symbol string, data []byte) (*PairBalance, error) {
+	balance, err := parseBalances(data)
+	if err != nil {
+		return nil, err
+	}
+	fee := struct {
+		Fee numberField `json:"fee"`
+	}{Fee: numberField{name: "fee"}}
+	if err := json.Unmarshal(data, &fee); err != nil {
+		return nil, err
+	}
+	result := &PairBalance{Fee: fee.Fee.value}
+	for name, b := range balance.Currencies {
+		rest := strings.TrimPrefix(symbol, name)
+		if _, found := balance.Currencies[rest]; found && rest != symbol {
+			result.BaseAvailable, result.BaseReserved = b.Available, b.Reserved
+			continue
+		}
+		rest = strings.TrimSuffix(symbol, name)
+		if _, found := balance.Currencies[rest]; found && rest != symbol {
+			result.QuoteAvailable, result.QuoteReserved = b.Available, b.Reserved
+			continue
+		}
+		return nil, errors.Errorf("unexpected currency %s for %s", name, symbol)
+	}
+	return result, nil
+}
+
+// TradingFee is a trading fee of a pair in percents.
+type TradingFee struct {
+	Maker float64
+	Taker float64
+}
+
+// GetTradingFees returns trading fees of all pairs keyed by lowercase symbols.
+func (api *Api) GetTradingFees() (map[string]TradingFee, error) {
+	return api.GetTradingFeesCtx(context.Background())
+}
+
+// GetTradingFeesCtx is like GetTradingFees, but uses the given context.
+func (api *Api) GetTradingFeesCtx(ctx context.Context) (map[string]TradingFee, error) {
+	body, err := api.read(ctx, "/fees/trading/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseTradingFees(body)
+}
+
+// GetTradingFeesForPair returns trading fees of the given symbol.
+func (api *Api) GetTradingFeesForPair(symbol string) (*TradingFee, error) {
+	return api.GetTradingFeesForPairCtx(context.Background(), symbol)
+}
+
+// GetTradingFeesForPairCtx is like GetTradingFeesForPair, but uses the given context.
+func (api *Api) GetTradingFeesForPairCtx(ctx context.Context, symbol string) (*TradingFee, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	symbol = strings.ToLower(symbol)
+	body, err := api.read(ctx, "/fees/trading/"+symbol+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	// a single pair may come either as an object, or as a list of one element.
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		body = append(append([]byte{'['}, trimmed...), ']')
+	}
+	fees, err := parseTradingFees(body)
+	if err != nil {
+		return nil, err
+	}
+	fee, found := fees[symbol]
+	if !found {
+		return nil, errors.Errorf("no fees for %s", symbol)
+	}
+	return &fee, nil
+}
+
+func parseTradingFees(data []byte) (map[string]TradingFee, error) {
+	var raw []struct {
+		CurrencyPair string `json:"currency_pair"`
+		Market       string `json:"market"`
+		Fees         struct {
+			Maker interface{} `json:"maker"`
+			Taker interface{} `json:"taker"`
+		} `json:"fees"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make(map[string]TradingFee, len(raw))
+	for i, r := range raw {
+		symbol := r.Market
+		if symbol == "" {
+			symbol = r.CurrencyPair
+		}
+		symbol = pairSymbol(symbol)
+		if symbol == "" {
+			return nil, errors.Errorf("fee %d: empty pair", i)
+		}
+		var (
+			fee TradingFee
+			err error
+		)
+		if fee.Maker, err = parseNumber("maker fee", r.Fees.Maker); err != nil {
+			return nil, errors.Wrap(err, symbol)
+		}
+		if fee.Taker, err = parseNumber("taker fee", r.Fees.Taker); err != nil {
+			return nil, errors.Wrap(err, symbol)
+		}
+		result[symbol] = fee
+	}
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"math"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseBalances(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/balance.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances, err := parseBalances(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	currencies := []struct {
+		name     string
+		expected Balance
+	}{
+		{"usd", Balance{Available: 954.32, Reserved: 100, Total: 1054.32}},
+		{"eur", Balance{}},
+		{"btc", Balance{Available: 0.50234567, Reserved: 0.01, Total: 0.51234567}},
+		{"eth", Balance{Available: 2, Total: 2}},
+		{"xyz", Balance{Available: 1, Reserved: 0.5, Total: 1.5}},
+	}
+	for _, c := range currencies {
+		got, found := balances.Currencies[c.name]
+		if !found {
+			t.Errorf("%s: not found", c.name)
+			continue
+		}
+		if got != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, got)
+		}
+	}
+	if len(balances.Currencies) != len(currencies) {
+		t.Errorf("expected %d currencies, got %d", len(currencies), len(balances.Currencies))
+	}
+	fees := map[string]float64{"btcusd": 0.5, "btceur": 0.5, "ethusd": 0.25, "xyzusd": 0.1}
+	for pair, fee := range fees {
+		if got := balances.Fees[pair]; got != fee {
+			t.Errorf("%s fee: expected %v, got %v", pair, fee, got)
+		}
+	}
+	if len(balances.Fees) != len(fees) {
+		t.Errorf("expected %d fees, got %d", len(fees), len(balances.Fees))
+	}
+}
+
+func TestParseBalancesFull(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/balance_full.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances, err := parseBalances(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances.Currencies) != 39 {
+		t.Errorf("expected 39 currencies, got %d", len(balances.Currencies))
+	}
+	if len(balances.Fees) != 74 {
+		t.Errorf("expected 74 fees, got %d", len(balances.Fees))
+	}
+	for name, b := range balances.Currencies {
+		if b.Total <= 0 || math.Abs(b.Available+b.Reserved-b.Total) > 1e-9 {
+			t.Errorf("%s: inconsistent balance %+v", name, b)
+		}
+	}
+	checks := map[string]Balance{
+		"usd":  {Available: 1.25, Total: 1.25},
+		"pepe": {Available: 40.75, Reserved: 0.5, Total: 41.25},
+		"ZRX":  {Available: 48.25, Reserved: 0.5, Total: 48.75},
+		"abc":  {},
+	}
+	for name, expected := range checks {
+		if got := balances.Get(name); got != expected {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, got)
+		}
+	}
+	if fee, found := balances.Fee("EURUSD"); !found || fee != 0.2 {
+		t.Errorf("eurusd: unexpected fee %v, %v", fee, found)
+	}
+	if _, found := balances.Fee("btc_withdrawal"); found {
+		t.Error("withdrawal fees must not be treated as trading fees")
+	}
+}
+
+func TestParseBalancesInvalid(t *testing.T) {
+	if _, err := parseBalances([]byte(`{"usd_balance": "abc"}`)); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := parseBalances([]byte(`[]`)); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestParsePairBalance(t *testing.T) {
+	data := []byte(`{
+		"btc_available": "0.50000000", "btc_balance": "0.60000000", "btc_reserved": "0.10000000",
+		"usd_available": "100.00", "usd_balance": "150.00", "usd_reserved": "50.00",
+		"fee": "0.400"
+	}`)
+	balance, err := parsePairBalance("btcusd", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PairBalance{
+		BaseAvailable:  0.5,
+		QuoteAvailable: 100,
+		BaseReserved:   0.1,
+		QuoteReserved:  50,
+		Fee:            0.4,
+	}
+	if *balance != expected {
+		t.Errorf("expected %+v, got %+v", expected, *balance)
+	}
+	balance, err = parsePairBalance("usdcusd", []byte(`{"usdc_available": "5.0", "usd_available": "7.0", "fee": "0.1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.BaseAvailable != 5 || balance.QuoteAvailable != 7 {
+		t.Errorf("usdcusd: wrong balance %+v", *balance)
+	}
+	if _, err := parsePairBalance("btcusd", []byte(`{"eth_available": "1.0"}`)); err == nil {
+		t.Error("error expected for unexpected currency")
+	}
+}
+
+func TestGetPairBalanceEmptySymbol(t *testing.T) {
+	if _, err := New("", "").GetPairBalance(""); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestParseTradingFees(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_fees.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees, err := parseTradingFees(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]TradingFee{
+		"btcusd":  {Maker: 0.3, Taker: 0.4},
+		"btceur":  {Maker: 0.3, Taker: 0.4},
+		"ethusd":  {Maker: 0.2, Taker: 0.3},
+		"xrpusd":  {Maker: 0.1, Taker: 0.2},
+		"usdcusd": {Maker: 0, Taker: 0.05},
+	}
+	if !reflect.DeepEqual(expected, fees) {
+		t.Errorf("expected %+v, got %+v", expected, fees)
+	}
+	if _, err := parseTradingFees([]byte(`[{"market": "btcusd", "fees": {"maker": "x", "taker": "0.1"}}]`)); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestGetTradingFeesForPair(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/fees/trading/btcusd/": func(url.Values) (int, string) {
+			return 200, `{"currency_pair": "btcusd", "market": "btcusd", "fees": {"maker": "0.3", "taker": "0.4"}}`
+		},
+	})
+	defer restore()
+	fee, err := NewWithKey("key", "secret").GetTradingFeesForPair("BTCUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *fee != (TradingFee{Maker: 0.3, Taker: 0.4}) {
+		t.Errorf("unexpected fee %+v", *fee)
+	}
+}
@@ -0,0 +1,165 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"testing"
+)
+
+// parserFixture is a captured response used by the parser benchmarks and allocation tests.
+type parserFixture struct {
+	name string
+	data []byte
+	// units is the number of levels, trades or tickers in the fixture.
+	units int
+}
+
+func loadFixtures(tb testing.TB, names map[string]int) []parserFixture {
+	tb.Helper()
+	var result []parserFixture
+	for _, name := range sortedKeys(names) {
+		units := names[name]
+		data, err := ioutil.ReadFile("testdata/" + name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		result = append(result, parserFixture{name: name, data: data, units: units})
+	}
+	return result
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orderBookFixtures returns books of different sizes. units is the total number of levels.
+func orderBookFixtures(tb testing.TB) []parserFixture {
+	fixtures := loadFixtures(tb, map[string]int{"order_book_deep.json": 10000, "order_book_group2.json": 6})
+	message, err := ioutil.ReadFile("testdata/ws_order_book.json")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var ev WsEvent
+	if err := json.Unmarshal(message, &ev); err != nil {
+		tb.Fatal(err)
+	}
+	return append(fixtures, parserFixture{name: "ws_order_book.json", data: ev.Data, units: 200})
+}
+
+func tradesFixtures(tb testing.TB) []parserFixture {
+	return loadFixtures(tb, map[string]int{"transactions.json": 3, "transactions_large.json": 1000})
+}
+
+func BenchmarkParseOrderBookSizes(b *testing.B) {
+	api := New("", "")
+	for _, f := range orderBookFixtures(b) {
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(f.data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := api.parseOrderBook(f.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFormatTrades(b *testing.B) {
+	for _, f := range tradesFixtures(b) {
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(f.data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := formatTrades(f.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseTicker(b *testing.B) {
+	f := loadFixtures(b, map[string]int{"ticker_hour.json": 1})[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseTicker(f.data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseAllTickers(b *testing.B) {
+	f := loadFixtures(b, map[string]int{"tickers.json": 23})[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseAllTickers(f.data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestParserAllocs guards the hot paths against regressions to decoding through interface{} values,
+// which costs about 10 allocations per order book level. The bounds are loose,
+// as allocations vary between Go versions, and with the race detector.
+func TestParserAllocs(t *testing.T) {
+	api := New("", "")
+	check := func(name string, units int, perUnit, extra float64, f func() error) {
+		t.Helper()
+		if err := f(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		max := perUnit*float64(units) + extra
+		if allocs := testing.AllocsPerRun(10, func() { f() }); allocs > max {
+			t.Errorf("%s: expected at most %v allocations, got %v", name, max, allocs)
+		}
+	}
+	for _, f := range orderBookFixtures(t) {
+		data := f.data
+		check("parseOrderBook "+f.name, f.units, 3, 50, func() error {
+			_, err := api.parseOrderBook(data)
+			return err
+		})
+		check("parseOrderBookDepth "+f.name, f.units, 3, 50, func() error {
+			_, err := parseOrderBookDepth(data, 0)
+			return err
+		})
+	}
+	deep := loadFixtures(t, map[string]int{"order_book_deep.json": 10000})[0]
+	check("parseOrderBookDepth 25", 50, 3, 150, func() error {
+		_, err := parseOrderBookDepth(deep.data, 25)
+		return err
+	})
+	for _, f := range tradesFixtures(t) {
+		data := f.data
+		check("formatTrades "+f.name, f.units, 6, 30, func() error {
+			_, err := formatTrades(data)
+			return err
+		})
+	}
+	for _, f := range loadFixtures(t, map[string]int{"ticker_hour.json": 1, "tickers.json": 23}) {
+		data, name := f.data, f.name
+		check("tickers "+f.name, f.units, 15, 20, func() error {
+			if name == "ticker_hour.json" {
+				_, err := parseTicker(data)
+				return err
+			}
+			_, err := parseAllTickers(data)
+			return err
+		})
+	}
+	message, err := ioutil.ReadFile("testdata/ws_order_book.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("websocket event", 1, 0, 10, func() error {
+		var ev WsEvent
+		return json.Unmarshal(message, &ev)
+	})
+}
@@ -0,0 +1,234 @@
+package bitstamp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	authVersion     = "v2"
+	authPrefix      = "BITSTAMP "
+	formContentType = "application/x-www-form-urlencoded"
+)
+
+// post sends a signed POST request to the given api path.
+func (api *Api) post(ctx context.Context, path string, values url.Values) ([]byte, error) {
+	return api.traceRequest(ctx, path, func(ctx context.Context) ([]byte, error) {
+		return api.signedRequest(ctx, http.MethodPost, path, values, false)
+	})
+}
+
+// read sends a signed POST request to an idempotent api endpoint.
+// If the request is rejected because of the clock skew, it is retried once.
+// Other failed requests are retried according to Api.RetryPolicy.
+func (api *Api) read(ctx context.Context, path string, values url.Values) ([]byte, error) {
+	return api.traceRequest(ctx, path, func(ctx context.Context) ([]byte, error) {
+		return api.withRetries(ctx, false, func() ([]byte, error) {
+			return api.signedRequest(ctx, http.MethodPost, path, values, true)
+		})
+	})
+}
+
+// ClockSkew returns the difference between the server and the local clocks,
+// detected from the last response rejected because of an invalid timestamp.
+// The skew is applied to the timestamps of the signed requests of the api object
+// and of its copies made by WithAccount. The api objects created without a constructor share the skew.
+func (api *Api) ClockSkew() time.Duration {
+	return api.authClock().skew()
+}
+
+// signedRequest sends a request signed with the api key and secret
+// and returns the response body.
+// If the api rejects the request timestamp, the clock skew is adjusted
+// using the Date header of the response, and, if retry is set, the request is sent again.
+func (api *Api) signedRequest(ctx context.Context, method, path string, values url.Values, retry bool) ([]byte, error) {
+	if api.err != nil {
+		return nil, api.err
+	}
+	if api.Key == "" || api.Secret == "" {
+		return nil, ErrNoCredentials
+	}
+	body, date, err := api.sendSigned(ctx, method, path, values)
+	if err == nil || !isTimestampError(err) || date.IsZero() {
+		return body, err
+	}
+	api.authClock().setSkew(date.Sub(time.Now()))
+	if !retry {
+		return nil, err
+	}
+	traceRetry(ctx)
+	body, _, err = api.sendSigned(ctx, method, path, values)
+	return body, err
+}
+
+// sendSigned sends a signed request and returns the response body and the Date header value.
+func (api *Api) sendSigned(ctx context.Context, method, path string, values url.Values) ([]byte, time.Time, error) {
+	if err := api.waitRateLimit(ctx); err != nil {
+		return nil, time.Time{}, err
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "nonce generation error")
+	}
+	req, err := newSignedRequest(method, api.baseURL()+path, values, api.Key, api.Secret, nonce, api.authClock().now())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, body, err := api.do(req.WithContext(ctx))
+	if resp == nil {
+		return nil, time.Time{}, err
+	}
+	date, _ := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return nil, date, err
+	}
+	if err := api.checkSignedResponse(req, resp, body); err != nil {
+		api.observeError(err)
+		return nil, date, err
+	}
+	return body, date, nil
+}
+
+// checkSignedResponse returns the error of a response to a signed request.
+func (api *Api) checkSignedResponse(req *http.Request, resp *http.Response, body []byte) error {
+	// error responses may be unsigned.
+	if !isSuccessStatus(resp.StatusCode) {
+		return rateLimitError(resp, responseError(resp.StatusCode, body))
+	}
+	if api.VerifyResponses {
+		if err := verifyResponse(api.Secret, req, resp, body); err != nil {
+			return err
+		}
+	}
+	return responseError(resp.StatusCode, body)
+}
+
+// verifyResponse checks the X-Server-Auth-Signature header of a response to a signed request.
+func verifyResponse(secret string, req *http.Request, resp *http.Response, body []byte) error {
+	message := req.Header.Get("X-Auth-Nonce") + req.Header.Get("X-Auth-Timestamp") + resp.Header.Get("Content-Type") + string(body)
+	expected := sign(secret, message)
+	received := resp.Header.Get("X-Server-Auth-Signature")
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(received))) {
+		return withSentinel(ErrBadResponseSignature, &ResponseSignatureError{Expected: expected, Received: received})
+	}
+	return nil
+}
+
+// newSignedRequest builds a request with the v2 authentication headers.
+// values are sent as a form-encoded body.
+func newSignedRequest(method, rawurl string, values url.Values, key, secret, nonce string, t time.Time) (*http.Request, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+	body := values.Encode()
+	var contentType string
+	if body != "" {
+		contentType = formContentType
+	}
+	timestamp := strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	var query string
+	if u.RawQuery != "" {
+		query = "?" + u.RawQuery
+	}
+	message := authPrefix + key + method + u.Host + u.Path + query + contentType + nonce + timestamp + authVersion + body
+
+	req, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Auth", authPrefix+key)
+	req.Header.Set("X-Auth-Signature", sign(secret, message))
+	req.Header.Set("X-Auth-Nonce", nonce)
+	req.Header.Set("X-Auth-Timestamp", timestamp)
+	req.Header.Set("X-Auth-Version", authVersion)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// sharedClock issues the timestamps of the api objects created without a constructor.
+var sharedClock = newMonotonicClock()
+
+// authClock returns the clock, which issues timestamps of the signed requests of the api object.
+// The api objects created without a constructor share a clock.
+func (api *Api) authClock() *monotonicClock {
+	if api.clock == nil {
+		return sharedClock
+	}
+	return api.clock
+}
+
+func newMonotonicClock() *monotonicClock {
+	return &monotonicClock{wall: time.Now}
+}
+
+// monotonicClock returns millisecond timestamps, which never go backwards,
+// even if the wall clock does. It is safe for concurrent use.
+type monotonicClock struct {
+	wall func() time.Time
+	// last is the last issued timestamp in milliseconds.
+	last int64
+	// offset is added to the wall clock, in nanoseconds.
+	offset int64
+}
+
+// now returns the current time with the offset applied, truncated to milliseconds.
+// If it is not after the last issued timestamp,
+// the last timestamp plus one millisecond is returned.
+func (c *monotonicClock) now() time.Time {
+	ms := (c.wall().UnixNano() + atomic.LoadInt64(&c.offset)) / int64(time.Millisecond)
+	for {
+		last := atomic.LoadInt64(&c.last)
+		next := ms
+		if next <= last {
+			next = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&c.last, last, next) {
+			return time.Unix(0, next*int64(time.Millisecond))
+		}
+	}
+}
+
+// skew returns the offset added to the wall clock.
+func (c *monotonicClock) skew() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.offset))
+}
+
+// setSkew sets the offset added to the wall clock.
+// The last issued timestamp is kept, so after a negative correction
+// the timestamps advance by one millisecond until the corrected clock catches up.
+func (c *monotonicClock) setSkew(d time.Duration) {
+	atomic.StoreInt64(&c.offset, int64(d))
+}
+
+// sign returns a hex encoded HMAC-SHA256 of the message.
+func sign(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newNonce returns a random UUIDv4 string.
+func newNonce() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
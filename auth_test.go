@@ -0,0 +1,368 @@
+package bitstamp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestSignedRequest(t *testing.T) {
+	ts := time.Unix(1567755304, 968000000)
+	tests := []struct {
+		name        string
+		url         string
+		values      url.Values
+		message     string
+		body        string
+		contentType string
+	}{
+		{
+			name:        "with body",
+			url:         "https://www.bitstamp.net/api/v2/user_transactions/",
+			values:      url.Values{"offset": {"1"}},
+			message:     "BITSTAMP keyPOSTwww.bitstamp.net/api/v2/user_transactions/application/x-www-form-urlencodedf93c979d-b00d-43a9-9b9c-fd4cd9547fa61567755304968v2offset=1",
+			body:        "offset=1",
+			contentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:    "no body",
+			url:     "https://www.bitstamp.net/api/v2/balance/",
+			message: "BITSTAMP keyPOSTwww.bitstamp.net/api/v2/balance/f93c979d-b00d-43a9-9b9c-fd4cd9547fa61567755304968v2",
+		},
+		{
+			name:    "with query",
+			url:     "https://www.bitstamp.net/api/v2/balance/?a=b",
+			message: "BITSTAMP keyPOSTwww.bitstamp.net/api/v2/balance/?a=bf93c979d-b00d-43a9-9b9c-fd4cd9547fa61567755304968v2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := newSignedRequest("POST", test.url, test.values, "key", "secret", "f93c979d-b00d-43a9-9b9c-fd4cd9547fa6", ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]string{
+				"X-Auth":           "BITSTAMP key",
+				"X-Auth-Signature": sign("secret", test.message),
+				"X-Auth-Nonce":     "f93c979d-b00d-43a9-9b9c-fd4cd9547fa6",
+				"X-Auth-Timestamp": "1567755304968",
+				"X-Auth-Version":   "v2",
+				"Content-Type":     test.contentType,
+			}
+			for k, v := range expected {
+				if got := req.Header.Get(k); got != v {
+					t.Errorf("header %s: expected %q, got %q", k, v, got)
+				}
+			}
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != test.body {
+				t.Errorf("expected body %q, got %q", test.body, body)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n "message" | openssl dgst -sha256 -hmac "secret"
+	const expected = "8b5f48702995c1598c573db1e21866a9b825d4a794d169d7060a03605796360b"
+	if got := sign("secret", "message"); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestNewNonce(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	n1, err := newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n2, err := newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString(n1) {
+		t.Errorf("invalid nonce %s", n1)
+	}
+	if n1 == n2 {
+		t.Errorf("nonces must differ")
+	}
+}
+
+func TestVerifyResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature func(req *http.Request, body string) string
+		ok        bool
+	}{
+		{
+			name: "valid",
+			signature: func(req *http.Request, body string) string {
+				return sign("secret", req.Header.Get("X-Auth-Nonce")+req.Header.Get("X-Auth-Timestamp")+"application/json"+body)
+			},
+			ok: true,
+		},
+		{
+			name: "mismatch",
+			signature: func(req *http.Request, body string) string {
+				return sign("other", req.Header.Get("X-Auth-Nonce")+req.Header.Get("X-Auth-Timestamp")+"application/json"+body)
+			},
+		},
+		{
+			name: "absent",
+			signature: func(*http.Request, string) string {
+				return ""
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, restore := withFakeExchange(map[string]fakeHandler{
+				"/balance/": func(url.Values) (int, string) {
+					return 200, `{"usd_available": "1.00"}`
+				},
+			})
+			defer restore()
+			var expected, received string
+			fake.header = func(req *http.Request, body string) http.Header {
+				expected = sign("secret", req.Header.Get("X-Auth-Nonce")+req.Header.Get("X-Auth-Timestamp")+"application/json"+body)
+				if received = test.signature(req, body); received != "" {
+					return http.Header{"X-Server-Auth-Signature": {received}}
+				}
+				return nil
+			}
+			api := NewWithKey("key", "secret")
+			api.VerifyResponses = true
+			_, err := api.GetAccountBalance()
+			if test.ok {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrBadResponseSignature) {
+				t.Fatalf("ErrBadResponseSignature expected, got %v", err)
+			}
+			var sigErr *ResponseSignatureError
+			if !errors.As(err, &sigErr) {
+				t.Fatalf("*ResponseSignatureError expected, got %T", err)
+			}
+			if sigErr.Expected != expected {
+				t.Errorf("expected signature %s, got %s", expected, sigErr.Expected)
+			}
+			if sigErr.Received != received {
+				t.Errorf("expected received signature %q, got %q", received, sigErr.Received)
+			}
+		})
+	}
+}
+
+func TestMonotonicClock(t *testing.T) {
+	base := time.Unix(1567755304, 968000000)
+	wall := []time.Time{base, base, base.Add(-time.Second), base.Add(time.Millisecond), base.Add(time.Second)}
+	var i int
+	clock := &monotonicClock{wall: func() time.Time {
+		t := wall[i]
+		i++
+		return t
+	}}
+	expected := []time.Time{
+		base,
+		base.Add(time.Millisecond),
+		base.Add(2 * time.Millisecond),
+		base.Add(3 * time.Millisecond),
+		base.Add(time.Second),
+	}
+	for _, e := range expected {
+		if got := clock.now(); !got.Equal(e) {
+			t.Errorf("expected %v, got %v", e, got)
+		}
+	}
+	// a negative skew must not issue timestamps before the last one.
+	clock.setSkew(-time.Minute)
+	wall = []time.Time{base.Add(time.Second), base.Add(2 * time.Minute)}
+	i = 0
+	expected = []time.Time{base.Add(time.Second + time.Millisecond), base.Add(time.Minute)}
+	for _, e := range expected {
+		if got := clock.now(); !got.Equal(e) {
+			t.Errorf("expected %v after a negative skew, got %v", e, got)
+		}
+	}
+}
+
+func TestClockSkewPerApi(t *testing.T) {
+	exchange := &skewedExchange{skew: 10 * time.Minute}
+	defer withTransport(exchange)()
+	skewed, other := NewWithKey("key", "secret"), NewWithKey("key", "secret")
+	if _, err := skewed.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if skew := skewed.ClockSkew(); skew < 9*time.Minute {
+		t.Errorf("unexpected skew %v", skew)
+	}
+	if skew := other.ClockSkew(); skew != 0 {
+		t.Errorf("expected no skew for another api object, got %v", skew)
+	}
+	if skew := skewed.WithAccount("").ClockSkew(); skew != skewed.ClockSkew() {
+		t.Errorf("expected the skew of the copy to be %v, got %v", skewed.ClockSkew(), skew)
+	}
+}
+
+func TestConcurrentSignedRequests(t *testing.T) {
+	const n = 500
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(url.Values) (int, string) {
+			return 200, `{"usd_available": "1.00"}`
+		},
+	})
+	defer restore()
+	var (
+		mu         sync.Mutex
+		nonces     = make(map[string]bool)
+		timestamps = make(map[string]bool)
+	)
+	fake.header = func(req *http.Request, _ string) http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		nonce, ts := req.Header.Get("X-Auth-Nonce"), req.Header.Get("X-Auth-Timestamp")
+		if nonces[nonce] {
+			t.Errorf("duplicate nonce %s", nonce)
+		}
+		if timestamps[ts] {
+			t.Errorf("duplicate timestamp %s", ts)
+		}
+		nonces[nonce], timestamps[ts] = true, true
+		return nil
+	}
+	api := NewWithKey("key", "secret")
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.GetAccountBalance(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(nonces) != n {
+		t.Errorf("expected %d requests, got %d", n, len(nonces))
+	}
+}
+
+// skewedExchange rejects requests, which timestamps differ from its clock by more than a minute.
+type skewedExchange struct {
+	skew  time.Duration
+	calls int
+}
+
+func (e *skewedExchange) RoundTrip(req *http.Request) (*http.Response, error) {
+	e.calls++
+	serverTime := time.Now().Add(e.skew)
+	code, body := 200, `{"usd_available": "1.00"}`
+	ts, err := strconv.ParseInt(req.Header.Get("X-Auth-Timestamp"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if d := serverTime.Sub(time.Unix(0, ts*int64(time.Millisecond))); d > time.Minute || d < -time.Minute {
+		code, body = 403, `{"status": "error", "reason": "Timestamp is too far from server time", "code": "API0017"}`
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header: http.Header{
+			"Content-Type": {"application/json"},
+			"Date":         {serverTime.UTC().Format(http.TimeFormat)},
+		},
+		Body:    ioutil.NopCloser(strings.NewReader(body)),
+		Request: req,
+	}, nil
+}
+
+func TestClockSkewRetry(t *testing.T) {
+	exchange := &skewedExchange{skew: 10 * time.Minute}
+	defer withTransport(exchange)()
+	api := NewWithKey("key", "secret")
+	balances, err := api.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Get("usd").Available != 1 {
+		t.Errorf("unexpected balances %+v", balances)
+	}
+	if exchange.calls != 2 {
+		t.Errorf("expected 2 calls, got %d", exchange.calls)
+	}
+	if skew := api.ClockSkew(); skew < 9*time.Minute || skew > 11*time.Minute {
+		t.Errorf("unexpected skew %v", skew)
+	}
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if exchange.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", exchange.calls)
+	}
+}
+
+func TestClockSkewNoRetryForOrders(t *testing.T) {
+	exchange := &skewedExchange{skew: -10 * time.Minute}
+	defer withTransport(exchange)()
+	api := NewWithKey("key", "secret")
+	if _, err := api.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{}); err == nil {
+		t.Fatal("error expected")
+	}
+	if exchange.calls != 1 {
+		t.Errorf("expected 1 call, got %d", exchange.calls)
+	}
+	if skew := api.ClockSkew(); skew > -9*time.Minute || skew < -11*time.Minute {
+		t.Errorf("unexpected skew %v", skew)
+	}
+}
+
+func TestSignedRequestCancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	defer withTransport(hangingTransport(started))()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := NewWithKey("key", "secret").GetAccountBalanceCtx(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := NewWithKey("key", "secret").GetOpenOrdersCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v for a canceled context, got %v", context.Canceled, err)
+	}
+}
+
+func TestNoCredentials(t *testing.T) {
+	var sent int32
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		return nil, errors.New("unexpected request")
+	}))()
+	for _, api := range []*Api{NewPublic(), New("user", "password"), {Key: "key"}} {
+		if _, err := api.GetAccountBalance(); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("expected %v, got %v", ErrNoCredentials, err)
+		}
+		if _, err := api.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{}); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("expected %v for an order, got %v", ErrNoCredentials, err)
+		}
+	}
+	if n := atomic.LoadInt32(&sent); n != 0 {
+		t.Errorf("%d requests were sent", n)
+	}
+}
@@ -0,0 +1,213 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BankWithdrawalType is a type of a bank withdrawal.
+type BankWithdrawalType string
+
+const (
+	// BankWithdrawalSEPA is a SEPA withdrawal.
+	BankWithdrawalSEPA BankWithdrawalType = "sepa"
+	// BankWithdrawalInternational is an international wire withdrawal.
+	BankWithdrawalInternational BankWithdrawalType = "international"
+)
+
+// BankWithdrawalParams are parameters of a bank withdrawal.
+// Bank* fields and Currency are required for international withdrawals only.
+type BankWithdrawalParams struct {
+	Type   BankWithdrawalType
+	Amount float64
+	// AccountCurrency is the currency of the account to withdraw from.
+	AccountCurrency string
+	Name            string
+	IBAN            string
+	BIC             string
+	Address         string
+	PostalCode      string
+	City            string
+	// Country is an ISO 3166-1 alpha-2 code of the recipient country.
+	Country string
+
+	BankName       string
+	BankAddress    string
+	BankPostalCode string
+	BankCity       string
+	BankCountry    string
+	// Currency is the currency of the receiving bank account.
+	Currency string
+
+	// Comment is an optional comment to the withdrawal.
+	Comment string
+}
+
+func (p BankWithdrawalParams) values() (url.Values, error) {
+	if p.Type != BankWithdrawalSEPA && p.Type != BankWithdrawalInternational {
+		return nil, errors.Errorf("invalid withdrawal type %q", p.Type)
+	}
+	if p.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	fields := []struct {
+		name  string
+		value string
+		intl  bool
+	}{
+		{"account_currency", strings.ToLower(p.AccountCurrency), false},
+		{"name", p.Name, false},
+		{"iban", p.IBAN, false},
+		{"bic", p.BIC, false},
+		{"address", p.Address, false},
+		{"postal_code", p.PostalCode, false},
+		{"city", p.City, false},
+		{"country", p.Country, false},
+		{"bank_name", p.BankName, true},
+		{"bank_address", p.BankAddress, true},
+		{"bank_postal_code", p.BankPostalCode, true},
+		{"bank_city", p.BankCity, true},
+		{"bank_country", p.BankCountry, true},
+		{"currency", strings.ToLower(p.Currency), true},
+	}
+	values := url.Values{}
+	values.Set("type", string(p.Type))
+	values.Set("amount", formatDecimals(p.Amount, 2))
+	for _, f := range fields {
+		if f.intl && p.Type != BankWithdrawalInternational {
+			continue
+		}
+		if strings.TrimSpace(f.value) == "" {
+			return nil, errors.Errorf("%s is required for %s withdrawals", f.name, p.Type)
+		}
+		values.Set(f.name, f.value)
+	}
+	if p.Comment != "" {
+		values.Set("comment", p.Comment)
+	}
+	return values, nil
+}
+
+// OpenBankWithdrawal opens a bank withdrawal request and returns its id.
+func (api *Api) OpenBankWithdrawal(params BankWithdrawalParams) (int64, error) {
+	return api.OpenBankWithdrawalCtx(context.Background(), params)
+}
+
+// OpenBankWithdrawalCtx is like OpenBankWithdrawal, but uses the given context.
+func (api *Api) OpenBankWithdrawalCtx(ctx context.Context, params BankWithdrawalParams) (int64, error) {
+	values, err := params.values()
+	if err != nil {
+		return 0, err
+	}
+	body, err := api.post(ctx, "/withdrawal/open/", values)
+	if err != nil {
+		return 0, withdrawalError(err)
+	}
+	var resp struct {
+		WithdrawalID interface{} `json:"withdrawal_id"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	id, err := parseIntValue(resp.WithdrawalID)
+	if err != nil {
+		return 0, errors.Wrap(err, "withdrawal id parsing error")
+	}
+	return id, nil
+}
+
+// BankWithdrawalStatus is a status of a bank withdrawal.
+type BankWithdrawalStatus struct {
+	Status WithdrawalStatus
+	// Reason is set for failed and canceled withdrawals.
+	Reason string
+}
+
+// GetBankWithdrawalStatus returns the status of a bank withdrawal.
+func (api *Api) GetBankWithdrawalStatus(id int64) (*BankWithdrawalStatus, error) {
+	return api.GetBankWithdrawalStatusCtx(context.Background(), id)
+}
+
+// GetBankWithdrawalStatusCtx is like GetBankWithdrawalStatus, but uses the given context.
+func (api *Api) GetBankWithdrawalStatusCtx(ctx context.Context, id int64) (*BankWithdrawalStatus, error) {
+	body, err := api.read(ctx, "/withdrawal/status/", idValues(id))
+	if err != nil {
+		return nil, err
+	}
+	return parseBankWithdrawalStatus(body)
+}
+
+func parseBankWithdrawalStatus(data []byte) (*BankWithdrawalStatus, error) {
+	var raw struct {
+		Status interface{} `json:"status"`
+		Reason string      `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	status, err := parseIntValue(raw.Status)
+	if err != nil {
+		return nil, errors.Wrap(err, "status parsing error")
+	}
+	return &BankWithdrawalStatus{Status: WithdrawalStatus(status), Reason: raw.Reason}, nil
+}
+
+// CanceledBankWithdrawal is a canceled bank withdrawal.
+type CanceledBankWithdrawal struct {
+	ID              int64
+	Amount          float64
+	Currency        string
+	AccountCurrency string
+	Type            BankWithdrawalType
+}
+
+// CancelBankWithdrawal cancels a bank withdrawal.
+func (api *Api) CancelBankWithdrawal(id int64) (*CanceledBankWithdrawal, error) {
+	return api.CancelBankWithdrawalCtx(context.Background(), id)
+}
+
+// CancelBankWithdrawalCtx is like CancelBankWithdrawal, but uses the given context.
+func (api *Api) CancelBankWithdrawalCtx(ctx context.Context, id int64) (*CanceledBankWithdrawal, error) {
+	body, err := api.post(ctx, "/withdrawal/cancel/", idValues(id))
+	if err != nil {
+		return nil, err
+	}
+	return parseCanceledBankWithdrawal(body)
+}
+
+func parseCanceledBankWithdrawal(data []byte) (*CanceledBankWithdrawal, error) {
+	var raw struct {
+		ID              interface{} `json:"id"`
+		Amount          interface{} `json:"amount"`
+		Currency        string      `json:"currency"`
+		AccountCurrency string      `json:"account_currency"`
+		Type            string      `json:"type"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &CanceledBankWithdrawal{
+		Currency:        raw.Currency,
+		AccountCurrency: raw.AccountCurrency,
+		Type:            BankWithdrawalType(raw.Type),
+	}
+	var err error
+	if result.ID, err = parseIntValue(raw.ID); err != nil {
+		return nil, errors.Wrap(err, "id parsing error")
+	}
+	if result.Amount, err = parseNumber("amount", raw.Amount); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func idValues(id int64) url.Values {
+	values := url.Values{}
+	values.Set("id", strconv.FormatInt(id, 10))
+	return values
+}
@@ -0,0 +1,82 @@
+package bitstamp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBankWithdrawalParams(t *testing.T) {
+	sepa := BankWithdrawalParams{
+		Type:            BankWithdrawalSEPA,
+		Amount:          100.5,
+		AccountCurrency: "EUR",
+		Name:            "John Doe",
+		IBAN:            "DE89370400440532013000",
+		BIC:             "COBADEFFXXX",
+		Address:         "Main st. 1",
+		PostalCode:      "10115",
+		City:            "Berlin",
+		Country:         "DE",
+	}
+	values, err := sepa.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "account_currency=eur&address=Main+st.+1&amount=100.5&bic=COBADEFFXXX&city=Berlin&country=DE&iban=DE89370400440532013000&name=John+Doe&postal_code=10115&type=sepa"
+	if values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+
+	intl := sepa
+	intl.Type = BankWithdrawalInternational
+	_, err = intl.values()
+	if err == nil || !strings.Contains(err.Error(), "bank_name") {
+		t.Errorf("expected missing bank_name error, got %v", err)
+	}
+	intl.BankName, intl.BankAddress, intl.BankPostalCode, intl.BankCity = "Bank", "Bank st. 2", "10117", "Berlin"
+	_, err = intl.values()
+	if err == nil || !strings.Contains(err.Error(), "bank_country") {
+		t.Errorf("expected missing bank_country error, got %v", err)
+	}
+	intl.BankCountry, intl.Currency = "DE", "USD"
+	values, err = intl.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Get("currency") != "usd" || values.Get("bank_city") != "Berlin" {
+		t.Errorf("unexpected values %q", values.Encode())
+	}
+
+	missingIBAN := sepa
+	missingIBAN.IBAN = " "
+	if _, err := missingIBAN.values(); err == nil || !strings.Contains(err.Error(), "iban") {
+		t.Errorf("expected missing iban error, got %v", err)
+	}
+	invalidType := sepa
+	invalidType.Type = "swift"
+	if _, err := invalidType.values(); err == nil {
+		t.Error("error expected for invalid type")
+	}
+}
+
+func TestParseBankWithdrawalStatus(t *testing.T) {
+	status, err := parseBankWithdrawalStatus([]byte(`{"status": 4, "reason": "Invalid IBAN"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != WithdrawalFailed || status.Reason != "Invalid IBAN" {
+		t.Errorf("unexpected status %+v", *status)
+	}
+}
+
+func TestParseCanceledBankWithdrawal(t *testing.T) {
+	data := []byte(`{"id": 123, "amount": "100.50", "currency": "EUR", "account_currency": "EUR", "type": "sepa"}`)
+	result, err := parseCanceledBankWithdrawal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := CanceledBankWithdrawal{ID: 123, Amount: 100.5, Currency: "EUR", AccountCurrency: "EUR", Type: BankWithdrawalSEPA}
+	if *result != expected {
+		t.Errorf("expected %+v, got %+v", expected, *result)
+	}
+}
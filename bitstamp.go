@@ -1,14 +1,15 @@
 package bitstamp
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 )
 
@@ -24,10 +25,84 @@ type Ticker struct {
 	Low  float64 `json:",string"`
 	Ask  float64 `json:",string"`
 	Bid  float64 `json:",string"`
+	// Open is the first price of the day for daily tickers, and of the hour for hourly ones.
+	Open float64 `json:",string"`
+	// Volume is the traded amount in the base currency.
+	Volume float64 `json:",string"`
+	// VWAP is the volume weighted average price.
+	VWAP float64 `json:"vwap,string"`
+	// Open24 is the price 24 hours ago.
+	Open24 float64 `json:"open_24,string"`
+	// PercentChange24 is the price change in percents since Open24.
+	PercentChange24 float64 `json:"percent_change_24,string"`
+	// Side is the type of the last trade.
+	Side OrderType `json:",string"`
+	// Timestamp is the ticker time in unix seconds.
+	Timestamp int64 `json:",string"`
+	// Time is the ticker time. It is zero if the api did not return it.
+	Time time.Time `json:"-"`
+
+	// The raw fields are the original values sent by the api, if Api.RawNumbers is set.
+	LastRaw            string `json:"-"`
+	HighRaw            string `json:"-"`
+	LowRaw             string `json:"-"`
+	AskRaw             string `json:"-"`
+	BidRaw             string `json:"-"`
+	OpenRaw            string `json:"-"`
+	VolumeRaw          string `json:"-"`
+	VWAPRaw            string `json:"-"`
+	Open24Raw          string `json:"-"`
+	PercentChange24Raw string `json:"-"`
+}
+
+// UnmarshalJSON decodes a ticker, where the timestamp may be either a string or a number.
+func (t *Ticker) UnmarshalJSON(data []byte) error {
+	type ticker Ticker
+	// the fields shadow the ones of ticker, as the api may send both strings and numbers.
+	raw := struct {
+		ticker
+		Timestamp       *flexibleInt64
+		Last            numberField
+		High            numberField
+		Low             numberField
+		Ask             numberField
+		Bid             numberField
+		Open            numberField
+		Volume          numberField
+		VWAP            numberField `json:"vwap"`
+		Open24          numberField `json:"open_24"`
+		PercentChange24 numberField `json:"percent_change_24"`
+	}{
+		Last:            numberField{name: "last"},
+		High:            numberField{name: "high"},
+		Low:             numberField{name: "low"},
+		Ask:             numberField{name: "ask"},
+		Bid:             numberField{name: "bid"},
+		Open:            numberField{name: "open"},
+		Volume:          numberField{name: "volume"},
+		VWAP:            numberField{name: "vwap"},
+		Open24:          numberField{name: "open_24"},
+		PercentChange24: numberField{name: "percent_change_24"},
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	result := Ticker(raw.ticker)
+	result.Last, result.High, result.Low = raw.Last.value, raw.High.value, raw.Low.value
+	result.Ask, result.Bid, result.Open = raw.Ask.value, raw.Bid.value, raw.Open.value
+	result.Volume, result.VWAP = raw.Volume.value, raw.VWAP.value
+	result.Open24, result.PercentChange24 = raw.Open24.value, raw.PercentChange24.value
+	if raw.Timestamp != nil {
+		ts := int64(*raw.Timestamp)
+		result.Timestamp, result.Time = ts, time.Unix(ts, 0)
+	}
+	*t = result
+	return nil
 }
 
 // OrderBook is a standart order book.
 type OrderBook struct {
+	// Time has microsecond precision, if the api sends microtimestamp.
 	Time time.Time
 	Asks []Order
 	Bids []Order
@@ -37,230 +112,689 @@ type OrderBook struct {
 type Order struct {
 	Price  float64
 	Amount float64
+	// PriceRaw and AmountRaw are the original values sent by the api, if Api.RawNumbers is set.
+	PriceRaw  string
+	AmountRaw string
 }
 
 // Trade is a trade representation.
 type Trade struct {
-	Time   time.Time
+	Time time.Time
+	// ID is the trade id as a string.
+	//
+	// Deprecated: use TID.
 	ID     string
 	Price  float64
 	Amount float64
+	TID    int64
+	// Type is the side of the taker.
+	Type TradeType
+	// PriceRaw and AmountRaw are the original values sent by the api, if Api.RawNumbers is set.
+	PriceRaw  string
+	AmountRaw string
+}
+
+// TradeType is a type of a trade.
+type TradeType int
+
+const (
+	// TradeBuy is a trade, where a buy order was the taker.
+	TradeBuy TradeType = 0
+	// TradeSell is a trade, where a sell order was the taker.
+	TradeSell TradeType = 1
+)
+
+func (t TradeType) String() string {
+	switch t {
+	case TradeBuy:
+		return "buy"
+	case TradeSell:
+		return "sell"
+	default:
+		return "unknown(" + strconv.Itoa(int(t)) + ")"
+	}
 }
 
 // Api is a Bitstamp client.
 type Api struct {
 	User     string
 	Password string
-}
+	// Key and Secret are used to sign requests to the private endpoints.
+	Key    string
+	Secret string
+	// RawNumbers enables keeping the original values of the numeric fields of tickers,
+	// order books, trades and balances in their raw fields, like Order.PriceRaw.
+	RawNumbers bool
+	// BookValidation is the checking of the order books. By default, books are not checked.
+	BookValidation BookValidation
+	// VerifyResponses enables checking of the X-Server-Auth-Signature header
+	// of the responses to signed requests.
+	VerifyResponses bool
+	// SkipMinimumOrderCheck disables the client side check of the minimum order value,
+	// which is done if the trading pairs info is cached. See EnableSymbolValidation and EnableAutoRounding.
+	SkipMinimumOrderCheck bool
+	// Accounts maps account names to their credentials. See WithAccount.
+	Accounts map[string]Credentials
+	// RateLimitRetryBudget is the total time public requests may wait for retries
+	// after being rate limited. Zero disables retries. Private requests are never retried.
+	RateLimitRetryBudget time.Duration
+	// RetryPolicy, if set, is used to retry failed public and private read-only requests.
+	RetryPolicy RetryPolicy `json:"-"`
+	// RequestHooks are called before sending each rest request. See RequestHook.
+	RequestHooks []RequestHook `json:"-"`
+	// ResponseHooks are called after each rest request. See ResponseHook.
+	ResponseHooks []ResponseHook `json:"-"`
+	// WebsocketDialer is used to connect to the websocket api. If nil, websocket.DefaultDialer is used.
+	WebsocketDialer *websocket.Dialer `json:"-"`
+	// UserAgent, if set, is the User-Agent header of rest requests and websocket handshakes.
+	UserAgent string
+	// Headers are added to rest requests and websocket handshakes.
+	// Authentication headers and Content-Type can't be changed.
+	Headers http.Header `json:"-"`
+	// Logger receives internal log messages. If nil, they are discarded.
+	Logger Logger `json:"-"`
+	// Metrics, if set, receives measurements of rest requests and websocket messages.
+	Metrics Metrics `json:"-"`
+	// Tracer, if set, starts spans around rest requests and websocket connections.
+	Tracer Tracer `json:"-"`
+	// HTTPClient is used to send rest requests. If nil, a default client is used.
+	HTTPClient *http.Client `json:"-"`
+	// Timeout limits the time of rest requests without a context deadline,
+	// and the time of websocket handshakes. If zero, 10 seconds is used.
+	// If negative, there is no timeout.
+	Timeout time.Duration
+	// BaseURL is the url of the rest api. If empty, API_URL is used.
+	BaseURL string
+	// WebsocketURL is the url of the websocket api used by subscriptions.
+	// If empty, the default Bitstamp url is used.
+	WebsocketURL string
 
-// NewFromConfig creates a new api object given a config file. The config file must
-// be json formated to inlude User and Password
-func NewFromConfig(cfgfile string) (api *Api, err error) {
-	file, err := ioutil.ReadFile(cfgfile)
-	if err != nil {
-		return nil, err
-	}
+	// err is returned by signed requests without sending them.
+	err error
+	// pairs is a cache of the trading pairs info, used if validateSymbols or rounding is set.
+	pairs           *pairCache
+	validateSymbols bool
+	// rounding, if set, is the rounding mode of order prices and amounts.
+	rounding *RoundingMode
+	// limiter, if set, limits the rate of rest requests.
+	limiter *rateLimiter
+	// semaphore, if set, limits the number of concurrent rest requests.
+	semaphore *requestSemaphore
+	// conn are the connection settings used by NewClient.
+	conn *connOptions
+	// debug, if set, dumps the traffic. See WithDebug.
+	debug *debugDumper
+	// clock issues the timestamps of signed requests. It is set by the constructors, see authClock.
+	clock *monotonicClock
+}
 
-	api = new(Api)
-	err = json.Unmarshal(file, api)
-	if err != nil {
-		return nil, err
-	}
-	return api, nil
+// NewPublic creates a new api object for public requests.
+// Private requests of the object fail with ErrNoCredentials.
+func NewPublic() *Api {
+	return &Api{clock: newMonotonicClock()}
 }
 
 // New creates a new api object given a user and a password.
+// The user and the password are not used to sign requests,
+// so private requests fail with ErrNoCredentials. Use NewWithKey instead.
+// See NewClient for more settings.
 func New(user, password string) *Api {
 	api := &Api{
 		User:     user,
 		Password: password,
+		clock:    newMonotonicClock(),
 	}
 	return api
 }
 
-func (api *Api) get(url string) (body []byte, err error) {
-	resp, err := http.Get(fmt.Sprint(API_URL, url))
-	if err != nil {
-		return
+// NewWithKey creates a new api object given an api key and a secret.
+func NewWithKey(key, secret string) *Api {
+	return &Api{
+		Key:    key,
+		Secret: secret,
+		clock:  newMonotonicClock(),
 	}
-	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
-	return
 }
 
-// GetTicker returns a ticker for the goven symbol.
-func (api *Api) GetTicker(symbol string) (ticker *Ticker, err error) {
-	body, err := api.get("/ticker/" + symbol)
+// defaultTimeout is the timeout of requests if Api.Timeout is not set.
+const defaultTimeout = 10 * time.Second
+
+// defaultClient is used if Api.HTTPClient is not set.
+// The timeouts are set by the request contexts.
+var defaultClient = &http.Client{}
+
+func (api *Api) httpClient() *http.Client {
+	if api.HTTPClient != nil {
+		return api.HTTPClient
+	}
+	return defaultClient
+}
+
+func (api *Api) baseURL() string {
+	if api.BaseURL != "" {
+		return strings.TrimSuffix(api.BaseURL, "/")
+	}
+	return API_URL
+}
+
+func (api *Api) websocketURL() string {
+	if api.WebsocketURL != "" {
+		return api.WebsocketURL
+	}
+	return bitstampWsUrl
+}
+
+// get sends a GET request to the given public api path and returns the response body.
+// Failed requests are retried according to Api.RateLimitRetryBudget and Api.RetryPolicy.
+func (api *Api) get(ctx context.Context, path string) ([]byte, error) {
+	return api.traceRequest(ctx, path, func(ctx context.Context) ([]byte, error) {
+		return api.withRetries(ctx, true, func() ([]byte, error) {
+			return api.sendGet(ctx, path)
+		})
+	})
+}
+
+func (api *Api) sendGet(ctx context.Context, path string) ([]byte, error) {
+	if err := api.waitRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api.baseURL()+path, nil)
 	if err != nil {
-		return
+		return nil, err
 	}
-	ticker = new(Ticker)
-	err = json.Unmarshal(body, ticker)
+	resp, body, err := api.do(req)
 	if err != nil {
-		return
+		return nil, err
 	}
-	return
+	if err := responseError(resp.StatusCode, body); err != nil {
+		err = rateLimitError(resp, err)
+		api.observeError(err)
+		return nil, err
+	}
+	return body, nil
 }
 
-// GetOrderBook returns order book for the given symbol.
-func (api *Api) GetOrderBook(symbol string) (orderbook *OrderBook, err error) {
-	body, err := api.get("/order_book/" + symbol)
+// requestError returns the error of a failed request.
+// If ctx is done, the error wraps ctx.Err(), so it can be checked with errors.Is.
+func requestError(ctx context.Context, err error) error {
+	switch ctxErr := ctx.Err(); ctxErr {
+	case nil:
+		return err
+	case context.DeadlineExceeded:
+		return errors.Wrap(ctxErr, "request timed out")
+	default:
+		return errors.Wrap(ctxErr, "request aborted")
+	}
+}
+
+func (api *Api) timeout() time.Duration {
+	switch {
+	case api.Timeout == 0:
+		return defaultTimeout
+	case api.Timeout < 0:
+		return 0
+	default:
+		return api.Timeout
+	}
+}
+
+// GetTicker returns a ticker for the goven symbol.
+func (api *Api) GetTicker(symbol string) (ticker *Ticker, err error) {
+	return api.GetTickerCtx(context.Background(), symbol)
+}
+
+// GetTickerCtx is like GetTicker, but uses the given context.
+func (api *Api) GetTickerCtx(ctx context.Context, symbol string) (ticker *Ticker, err error) {
+	return api.getTicker(ctx, "/ticker/", symbol)
+}
+
+// GetTickerHour returns a ticker for the given symbol, calculated for the last hour.
+func (api *Api) GetTickerHour(symbol string) (*Ticker, error) {
+	return api.GetTickerHourCtx(context.Background(), symbol)
+}
+
+// GetTickerHourCtx is like GetTickerHour, but uses the given context.
+func (api *Api) GetTickerHourCtx(ctx context.Context, symbol string) (*Ticker, error) {
+	return api.getTicker(ctx, "/ticker_hour/", symbol)
+}
+
+// GetAllTickers returns tickers of all pairs keyed by lowercase symbols, like btcusd.
+func (api *Api) GetAllTickers() (map[string]Ticker, error) {
+	return api.GetAllTickersCtx(context.Background())
+}
+
+// GetAllTickersCtx is like GetAllTickers, but uses the given context.
+func (api *Api) GetAllTickersCtx(ctx context.Context) (map[string]Ticker, error) {
+	body, err := api.get(ctx, "/ticker/")
 	if err != nil {
-		return
+		return nil, err
+	}
+	tickers, err := parseAllTickers(body)
+	if err == nil && api.RawNumbers {
+		err = setAllTickersRaw(tickers, body)
 	}
-	return api.parseOrderBook(body)
+	return tickers, err
 }
 
-func (api *Api) parseOrderBook(data []byte) (*OrderBook, error) {
-	defaultstruct := make(map[string]interface{})
-	err := json.Unmarshal(data, &defaultstruct)
+func (api *Api) getTicker(ctx context.Context, path, symbol string) (*Ticker, error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, path+symbol)
 	if err != nil {
 		return nil, err
 	}
+	ticker, err := parseTicker(body)
+	if err == nil && api.RawNumbers {
+		err = setTickerRaw(ticker, body)
+	}
+	return ticker, err
+}
 
-	timestamp := time.Now().Unix()
+func parseTicker(data []byte) (*Ticker, error) {
+	ticker := new(Ticker)
+	if err := json.Unmarshal(data, ticker); err != nil {
+		return nil, err
+	}
+	return ticker, nil
+}
 
-	if timestampObj, found := defaultstruct["timestamp"]; found {
-		timestampStr, ok := timestampObj.(string)
-		if !ok {
-			return nil, errors.New("invalid timestamp")
+func parseAllTickers(data []byte) (map[string]Ticker, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make(map[string]Ticker, len(raw))
+	for i, r := range raw {
+		var pair struct {
+			Pair string `json:"pair"`
 		}
-		timestamp, err = strconv.ParseInt(timestampStr, 10, 64)
-		if err != nil {
-			return nil, err
+		if err := json.Unmarshal(r, &pair); err != nil {
+			return nil, errors.Wrapf(err, "ticker %d", i)
+		}
+		symbol := pairSymbol(pair.Pair)
+		if symbol == "" {
+			return nil, errors.Errorf("ticker %d: empty pair", i)
 		}
+		var ticker Ticker
+		if err := json.Unmarshal(r, &ticker); err != nil {
+			return nil, errors.Wrapf(err, "%s ticker", symbol)
+		}
+		result[symbol] = ticker
 	}
+	return result, nil
+}
 
-	result := &OrderBook{Time: time.Unix(timestamp, 0)}
+// ConversionRate is a currency conversion rate.
+type ConversionRate struct {
+	Buy  float64 `json:",string"`
+	Sell float64 `json:",string"`
+}
+
+// GetEurUsd returns the EUR/USD conversion rate.
+func (api *Api) GetEurUsd() (*ConversionRate, error) {
+	return api.GetEurUsdCtx(context.Background())
+}
 
-	bids, ok := defaultstruct["bids"].([]interface{})
-	if !ok {
-		return nil, errors.Wrap(err, "bids interface convert error")
+// GetEurUsdCtx is like GetEurUsd, but uses the given context.
+func (api *Api) GetEurUsdCtx(ctx context.Context) (*ConversionRate, error) {
+	body, err := api.get(ctx, "/eur_usd/")
+	if err != nil {
+		return nil, err
+	}
+	raw := struct {
+		Buy  numberField
+		Sell numberField
+	}{
+		Buy:  numberField{name: "buy"},
+		Sell: numberField{name: "sell"},
 	}
-	asks, ok := defaultstruct["asks"].([]interface{})
-	if !ok {
-		return nil, errors.Wrap(err, "asks interface convert error")
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
 	}
+	return &ConversionRate{Buy: raw.Buy.value, Sell: raw.Sell.value}, nil
+}
 
-	parse := func(arr []interface{}) ([]Order, error) {
-		var result []Order
-		for _, elem := range arr {
-			ord := elem.([]interface{})
-			price, err := strconv.ParseFloat(ord[0].(string), 64)
-			if err != nil {
-				return nil, err
-			}
-			amount, err := strconv.ParseFloat(ord[1].(string), 64)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, Order{Price: price, Amount: amount})
-		}
-		return result, nil
+// GetOrderBook returns order book for the given symbol.
+func (api *Api) GetOrderBook(symbol string) (orderbook *OrderBook, err error) {
+	return api.GetOrderBookCtx(context.Background(), symbol)
+}
+
+// GetOrderBookCtx is like GetOrderBook, but uses the given context.
+func (api *Api) GetOrderBookCtx(ctx context.Context, symbol string) (orderbook *OrderBook, err error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/order_book/"+symbol)
+	if err != nil {
+		return nil, err
+	}
+	book, err := api.parseOrderBook(body)
+	if err != nil {
+		return nil, err
 	}
+	if err := api.checkBook(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (api *Api) parseOrderBook(data []byte) (*OrderBook, error) {
+	return decodeOrderBook(data, api.RawNumbers)
+}
 
-	if parsedBids, err := parse(bids); err == nil {
-		result.Bids = parsedBids
-	} else {
+func decodeOrderBook(data []byte, withRaw bool) (*OrderBook, error) {
+	var raw struct {
+		Timestamp      *flexibleInt64 `json:"timestamp"`
+		Microtimestamp *flexibleInt64 `json:"microtimestamp"`
+		Bids           *levelBuffer   `json:"bids"`
+		Asks           *levelBuffer   `json:"asks"`
+	}
+	bids, asks := getLevelBuffer("bids"), getLevelBuffer("asks")
+	defer putLevelBuffer(bids)
+	defer putLevelBuffer(asks)
+	raw.Bids, raw.Asks = bids, asks
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &OrderBook{Time: bookTime(raw.Timestamp, raw.Microtimestamp)}
+	if !bids.found {
+		return nil, errors.New("bids are missing")
+	}
+	if !asks.found {
+		return nil, errors.New("asks are missing")
+	}
+	var err error
+	if result.Bids, err = parseBookLevels(bids.levels, withRaw); err != nil {
 		return nil, errors.Wrap(err, "bids parsing error")
 	}
-
-	if parsedAsks, err := parse(asks); err == nil {
-		result.Asks = parsedAsks
-	} else {
+	if result.Asks, err = parseBookLevels(asks.levels, withRaw); err != nil {
 		return nil, errors.Wrap(err, "asks parsing error")
 	}
+	return result, nil
+}
+
+// bookTime returns the time of an order book, preferring microtimestamp.
+// If the api sent neither, the local time is returned.
+func bookTime(timestamp, microtimestamp *flexibleInt64) time.Time {
+	switch {
+	case microtimestamp != nil:
+		return unixMicro(int64(*microtimestamp))
+	case timestamp != nil:
+		return time.Unix(int64(*timestamp), 0)
+	default:
+		return time.Now()
+	}
+}
 
+// parseBookLevels converts price and amount pairs to orders. Elements after the amount are ignored.
+// If withRaw is set, the raw fields of the orders are set too.
+func parseBookLevels(levels [][2]json.RawMessage, withRaw bool) ([]Order, error) {
+	if len(levels) == 0 {
+		return nil, nil
+	}
+	result := make([]Order, len(levels))
+	for i, level := range levels {
+		order, err := parseBookLevel(level, withRaw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "level %d", i)
+		}
+		result[i] = order
+	}
 	return result, nil
 }
 
+// parseBookLevel converts an undecoded price and amount pair to an order.
+// Missing and null values are errors.
+func parseBookLevel(level [2]json.RawMessage, withRaw bool) (Order, error) {
+	price, err := parseRawNumber("price", level[0])
+	if err != nil {
+		return Order{}, err
+	}
+	amount, err := parseRawNumber("amount", level[1])
+	if err != nil {
+		return Order{}, err
+	}
+	order := Order{Price: price, Amount: amount}
+	if withRaw {
+		order.PriceRaw, order.AmountRaw = rawNumberText(level[0]), rawNumberText(level[1])
+	}
+	return order, nil
+}
+
 // GetTrades returns the list of last trades with default parameters.
 func (api *Api) GetTrades(symbol string) (trades []Trade, err error) {
-	body, err := api.get("/transactions/" + symbol)
+	return api.GetTradesCtx(context.Background(), symbol)
+}
+
+// GetTradesCtx is like GetTrades, but uses the given context.
+func (api *Api) GetTradesCtx(ctx context.Context, symbol string) (trades []Trade, err error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/transactions/"+symbol)
 	if err != nil {
 		return nil, errors.Wrap(err, "get transactions error")
 	}
-	return formatTrades(body)
+	return api.parseTrades(body)
 }
 
-// GetTradesParams returns the list of last trades.
-//	interval - The time interval from which we want the transactions to be returned.
-//		Possible values are minute, hour (default) or day.
-func (api *Api) GetTradesParams(symbol string, interval string) (trades []Trade, err error) {
+// Interval is a time interval of the trades.
+type Interval string
+
+const (
+	// IntervalMinute requests the trades of the last minute.
+	IntervalMinute Interval = "minute"
+	// IntervalHour requests the trades of the last hour.
+	IntervalHour Interval = "hour"
+	// IntervalDay requests the trades of the last day.
+	IntervalDay Interval = "day"
+)
+
+func (i Interval) validate() error {
+	switch i {
+	case IntervalMinute, IntervalHour, IntervalDay:
+		return nil
+	default:
+		return errors.Errorf("invalid interval %q", string(i))
+	}
+}
+
+// GetTradesParams returns the list of last trades for the given interval.
+// If interval is empty, the api default, which is an hour, is used.
+func (api *Api) GetTradesParams(symbol string, interval Interval) (trades []Trade, err error) {
+	return api.GetTradesParamsCtx(context.Background(), symbol, interval)
+}
+
+// GetTradesParamsCtx is like GetTradesParams, but uses the given context.
+func (api *Api) GetTradesParamsCtx(ctx context.Context, symbol string, interval Interval) (trades []Trade, err error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
 	values := url.Values{}
-	values.Add("time", interval)
-	body, err := api.get("/transactions/" + symbol + "/?" + values.Encode())
+	if interval != "" {
+		if err := interval.validate(); err != nil {
+			return nil, err
+		}
+		values.Set("time", string(interval))
+	}
+	path := "/transactions/" + symbol + "/"
+	if len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+	body, err := api.get(ctx, path)
 	if err != nil {
 		return
 	}
-	return formatTrades(body)
+	return api.parseTrades(body)
 }
 
 // SubscribeOrderBook subscribes for websocket events and sends order book updates
 // into dataChan. To stop processing, sent to, or close stopChan.
 func (api *Api) SubscribeOrderBook(symb string, dataChan chan<- OrderBook, stopChan <-chan struct{}) error {
-	c, err := NewWsClient()
-	if err != nil {
-		return errors.Wrap(err, "error initializing client")
+	return api.SubscribeOrderBookCtx(context.Background(), symb, dataChan, stopChan)
+}
+
+// SubscribeOrderBookCtx is like SubscribeOrderBook, but uses the given context.
+// The connection span is a child of the span in ctx, and the subscription ends, when ctx is done.
+func (api *Api) SubscribeOrderBookCtx(ctx context.Context, symb string, dataChan chan<- OrderBook, stopChan <-chan struct{}) error {
+	return api.SubscribeOrderBookDepthCtx(ctx, symb, 0, dataChan, stopChan)
+}
+
+// SubscribeOrderBookDepth is like SubscribeOrderBook, but the books have at most depth levels
+// on each side. If depth is not positive, whole books are sent.
+func (api *Api) SubscribeOrderBookDepth(symb string, depth int, dataChan chan<- OrderBook, stopChan <-chan struct{}) error {
+	return api.SubscribeOrderBookDepthCtx(context.Background(), symb, depth, dataChan, stopChan)
+}
+
+// SubscribeOrderBookDepthCtx is like SubscribeOrderBookDepth, but uses the given context.
+func (api *Api) SubscribeOrderBookDepthCtx(ctx context.Context, symb string, depth int, dataChan chan<- OrderBook, stopChan <-chan struct{}) error {
+	parse := api.parseOrderBook
+	if depth > 0 {
+		parse = func(data []byte) (*OrderBook, error) {
+			return api.parseOrderBookDepth(data, depth)
+		}
 	}
+	return api.subscribeOrderBookChannel(ctx, ChannelOrderBook(symb), symb, func(data []byte) error {
+		ob, err := parse(data)
+		if err == nil {
+			err = api.checkBook(ob)
+		}
+		if err != nil {
+			return err
+		}
+		dataChan <- *ob
+		return nil
+	}, stopChan)
+}
+
+// SubscribeDetailOrderBook is like SubscribeOrderBook, but the books are sent from the detail channel,
+// where each level is an individual order with its id.
+func (api *Api) SubscribeDetailOrderBook(symb string, dataChan chan<- GroupedOrderBook, stopChan <-chan struct{}) error {
+	return api.SubscribeDetailOrderBookCtx(context.Background(), symb, dataChan, stopChan)
+}
+
+// SubscribeDetailOrderBookCtx is like SubscribeDetailOrderBook, but uses the given context.
+func (api *Api) SubscribeDetailOrderBookCtx(ctx context.Context, symb string, dataChan chan<- GroupedOrderBook, stopChan <-chan struct{}) error {
+	return api.subscribeOrderBookChannel(ctx, ChannelDetailOrderBook(symb), symb, func(data []byte) error {
+		ob, err := parseGroupedOrderBook(data, OrderBookUngrouped)
+		if err != nil {
+			return err
+		}
+		dataChan <- *ob
+		return nil
+	}, stopChan)
+}
 
-	err = c.Subscribe(fmt.Sprintf("order_book_%s", symb))
+// subscribeOrderBookChannel subscribes to an order book channel and calls onData for each data event.
+// Errors returned by onData are logged, and the event is skipped.
+func (api *Api) subscribeOrderBookChannel(ctx context.Context, channel, symb string, onData func(data []byte) error, stopChan <-chan struct{}) error {
+	log := api.logger()
+	var c *WsClient
+	err := api.traceWebsocketConnect(ctx, api.websocketURL(), symb, func() (err error) {
+		c, err = newWsClient(api.websocketURL(), api.wsConfig())
+		if err != nil {
+			return errors.Wrap(err, "error initializing client")
+		}
+		return c.Subscribe(channel)
+	})
 	if err != nil {
 		return err
 	}
+	unsubscribe := func() {
+		if err := c.Unsubscribe(channel); err != nil {
+			log.Errorf("order book %s: unsubscribe error: %v", symb, err)
+		}
+		c.Close()
+	}
 
 	for {
 		select {
 		case ev := <-c.Stream:
 			if ev.Event == "data" {
-				if ob, err := api.parseOrderBook(ev.Data); err == nil {
-					dataChan <- *ob
+				if err := onData(ev.Data); err != nil {
+					log.Errorf("order book %s: %v", symb, err)
 				}
 			} else {
-				fmt.Println(ev.Event)
+				log.Debugf("order book %s: %s event", symb, ev.Event)
 			}
 		case <-stopChan:
+			unsubscribe()
+			return nil
+		case <-ctx.Done():
+			unsubscribe()
+			return ctx.Err()
 		case <-c.Errors:
-			err = c.Unsubscribe(fmt.Sprintf("order_book_%s", symb))
-			if err != nil {
-				fmt.Printf("usubscribe err : %s", err)
-			}
-			c.Close()
+			unsubscribe()
 			return nil
 		}
 	}
 }
 
-func formatTrades(body []byte) (trades []Trade, err error) {
-	var defaultstruct []interface{}
-	err = json.Unmarshal(body, &defaultstruct)
-	if err != nil {
-		return
+// parseTrades decodes trades, keeping the raw values if RawNumbers is set.
+func (api *Api) parseTrades(body []byte) ([]Trade, error) {
+	trades, err := formatTrades(body)
+	if err == nil && api.RawNumbers {
+		err = setTradesRaw(trades, body)
 	}
-	trades = make([]Trade, len(defaultstruct))
-	for i, _trade := range defaultstruct {
-		_t := _trade.(map[string]interface{})
-		price, err := strconv.ParseFloat(_t["price"].(string), 64)
-		if err != nil {
-			return trades, err
-		}
-		amount, err := strconv.ParseFloat(_t["amount"].(string), 64)
-		if err != nil {
-			return trades, err
-		}
-		timestampStr := _t["date"].(string)
-		timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	return trades, err
+}
+
+// rawTrade is a trade as sent by the api. The values are either strings or numbers.
+type rawTrade struct {
+	Date   json.Number     `json:"date"`
+	TID    json.Number     `json:"tid"`
+	Price  json.RawMessage `json:"price"`
+	Amount json.RawMessage `json:"amount"`
+	Type   json.Number     `json:"type"`
+}
+
+func formatTrades(body []byte) ([]Trade, error) {
+	var raw []rawTrade
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, errors.Wrap(err, "trades decoding error")
+	}
+	trades := make([]Trade, len(raw))
+	for i, r := range raw {
+		trade, err := r.trade()
 		if err != nil {
-			return trades, err
-		}
-		time := time.Unix(timestamp, 0)
-		trade := Trade{
-			Time:   time,
-			ID:     _t["tid"].(string),
-			Price:  price,
-			Amount: amount,
+			return nil, errors.Wrapf(err, "trade %d", i)
 		}
 		trades[i] = trade
 	}
-	return
+	return trades, nil
+}
+
+func (r rawTrade) trade() (Trade, error) {
+	price, err := parseRawNumber("price", r.Price)
+	if err != nil {
+		return Trade{}, err
+	}
+	amount, err := parseRawNumber("amount", r.Amount)
+	if err != nil {
+		return Trade{}, err
+	}
+	timestamp, err := strconv.ParseInt(string(r.Date), 10, 64)
+	if err != nil {
+		return Trade{}, errors.Wrap(err, "date parsing error")
+	}
+	tid, err := strconv.ParseInt(string(r.TID), 10, 64)
+	if err != nil {
+		return Trade{}, errors.Wrap(err, "tid parsing error")
+	}
+	tradeType, err := strconv.ParseInt(string(r.Type), 10, 64)
+	if err != nil {
+		return Trade{}, errors.Wrap(err, "type parsing error")
+	}
+	if tradeType != int64(TradeBuy) && tradeType != int64(TradeSell) {
+		return Trade{}, errors.Errorf("unknown trade type %d", tradeType)
+	}
+	return Trade{
+		Time:   time.Unix(timestamp, 0),
+		ID:     string(r.TID),
+		Price:  price,
+		Amount: amount,
+		TID:    tid,
+		Type:   TradeType(tradeType),
+	}, nil
 }
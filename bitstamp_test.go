@@ -1,17 +1,25 @@
 package bitstamp
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
-	"syscall"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
-func Init() (api *Api) {
+func Init(t *testing.T) (api *Api) {
 	filename := os.ExpandEnv("$BITSTAMP_CONFIG")
 	if filename == "" {
-		fmt.Println("Please set $BITSTAMP_CONFIG to a proper configuration file")
-		syscall.Exit(0)
+		t.Skip("Please set $BITSTAMP_CONFIG to a proper configuration file")
 	}
 	api, err := NewFromConfig(filename)
 	if err != nil {
@@ -21,7 +29,7 @@ func Init() (api *Api) {
 }
 
 func TestTicker(t *testing.T) {
-	api := Init()
+	api := Init(t)
 	ticker, err := api.GetTicker("btcusd")
 	if err != nil {
 		t.Errorf("Could not fetch ticker : %s", err)
@@ -32,7 +40,7 @@ func TestTicker(t *testing.T) {
 }
 
 func TestOrderBook(t *testing.T) {
-	api := Init()
+	api := Init(t)
 	orderbook, err := api.GetOrderBook("btcusd")
 	if err != nil {
 		t.Errorf("Could not fetch orderbook : %s", err)
@@ -43,7 +51,7 @@ func TestOrderBook(t *testing.T) {
 }
 
 func TestTrades(t *testing.T) {
-	api := Init()
+	api := Init(t)
 	trades, err := api.GetTrades("btcusd")
 	if err != nil {
 		t.Errorf("Could not fetch trades : %s", err)
@@ -54,7 +62,7 @@ func TestTrades(t *testing.T) {
 }
 
 func TestTradesParams(t *testing.T) {
-	api := Init()
+	api := Init(t)
 	trades, err := api.GetTradesParams("btcusd", "")
 	if err != nil {
 		t.Errorf("Could not fetch trades with params: %s", err)
@@ -63,3 +71,324 @@ func TestTradesParams(t *testing.T) {
 		t.Errorf("trades with params probably wrongly filled")
 	}
 }
+
+func TestGetTickerHour(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ticker_hour.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker_hour/btcusd": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+	})
+	defer restore()
+	ticker, err := New("", "").GetTickerHour("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Ticker{
+		Last:      10455.51,
+		High:      10480,
+		Low:       10431.12,
+		Ask:       10455.51,
+		Bid:       10453,
+		Open:      10441.29,
+		Volume:    142.71522468,
+		VWAP:      10462.33,
+		Timestamp: 1567755304,
+		Time:      time.Unix(1567755304, 0),
+	}
+	if *ticker != expected {
+		t.Errorf("expected %+v, got %+v", expected, *ticker)
+	}
+}
+
+func TestParseAllTickers(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/tickers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickers, err := parseAllTickers(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 23 {
+		t.Errorf("expected 23 tickers, got %d", len(tickers))
+	}
+	expected := map[string]Ticker{
+		"btcusd": {
+			Last: 1010, High: 1100, Low: 900, Ask: 1015, Bid: 1005,
+			Open: 1000, Volume: 0, VWAP: 1000, Open24: 1000, PercentChange24: 1,
+			Timestamp: 1567755304, Time: time.Unix(1567755304, 0),
+		},
+		"ethbtc": {
+			Last: 112.22, High: 122.22, Low: 100, Ask: 112.78, Bid: 111.67,
+			Open: 111.11, Volume: 84, VWAP: 111.11, Open24: 111.11, PercentChange24: 1,
+			Timestamp: 1567755312, Time: time.Unix(1567755312, 0),
+		},
+		"pepeusd": {
+			Last: 45.91, High: 50, Low: 40.91, Ask: 46.14, Bid: 45.68,
+			Open: 45.45, Volume: 220.5, VWAP: 45.45, Open24: 45.45, PercentChange24: 1,
+			Timestamp: 1567755325, Time: time.Unix(1567755325, 0),
+		},
+		"1incheur": {
+			Last: 43.91, High: 47.83, Low: 39.13, Ask: 44.13, Bid: 43.7,
+			Open: 43.48, Volume: 231, VWAP: 43.48, Open24: 43.48, PercentChange24: 1,
+			Timestamp: 1567755326, Time: time.Unix(1567755326, 0),
+		},
+	}
+	for symbol, e := range expected {
+		got, found := tickers[symbol]
+		if !found {
+			t.Errorf("%s: not found", symbol)
+			continue
+		}
+		if got != e {
+			t.Errorf("%s: expected %+v, got %+v", symbol, e, got)
+		}
+	}
+	if side := tickers["btceur"].Side; side != OrderSell {
+		t.Errorf("btceur: expected side %v, got %v", OrderSell, side)
+	}
+	if change := tickers["btcgbp"].PercentChange24; change != 0 {
+		t.Errorf("btcgbp: expected no change for null, got %v", change)
+	}
+	if _, err := parseAllTickers([]byte(`[{"last": "1.0"}]`)); err == nil {
+		t.Error("error expected for an empty pair")
+	}
+}
+
+func TestGetEurUsd(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/eur_usd/": func(url.Values) (int, string) {
+			return 200, `{"sell": "1.0963", "buy": "1.1047"}`
+		},
+	})
+	defer restore()
+	rate, err := New("", "").GetEurUsd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (ConversionRate{Buy: 1.1047, Sell: 1.0963}); *rate != expected {
+		t.Errorf("expected %+v, got %+v", expected, *rate)
+	}
+}
+
+func TestTickerRoundTrip(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ticker_hour.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticker, err := parseTicker(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(ticker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := parseTicker(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != *ticker {
+		t.Errorf("expected %+v, got %+v", *ticker, *decoded)
+	}
+}
+
+func TestTickerTime(t *testing.T) {
+	tests := map[string]Ticker{
+		`{"last": "1.5", "timestamp": "1567755304"}`: {Last: 1.5, Timestamp: 1567755304, Time: time.Unix(1567755304, 0)},
+		`{"last": "1.5", "timestamp": 1567755304}`:   {Last: 1.5, Timestamp: 1567755304, Time: time.Unix(1567755304, 0)},
+		`{"last": "1.5"}`:                    {Last: 1.5},
+		`{"last": "1.5", "timestamp": null}`: {Last: 1.5},
+	}
+	for data, expected := range tests {
+		ticker, err := parseTicker([]byte(data))
+		if err != nil {
+			t.Errorf("%s: %v", data, err)
+			continue
+		}
+		if *ticker != expected {
+			t.Errorf("%s: expected %+v, got %+v", data, expected, *ticker)
+		}
+	}
+	if _, err := parseTicker([]byte(`{"timestamp": "now"}`)); err == nil {
+		t.Error("error expected for an invalid timestamp")
+	}
+}
+
+func TestFormatTrades(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/transactions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trades, err := formatTrades(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Trade{
+		{Time: time.Unix(1567755304, 0), ID: "98765432", TID: 98765432, Price: 10455.51, Amount: 0.02, Type: TradeBuy},
+		{Time: time.Unix(1567755302, 0), ID: "98765431", TID: 98765431, Price: 10453, Amount: 1.5, Type: TradeSell},
+		{Time: time.Unix(1567755300, 0), ID: "98765430", TID: 98765430, Price: 10452.5, Amount: 0.001, Type: TradeSell},
+	}
+	if !reflect.DeepEqual(expected, trades) {
+		t.Errorf("expected %+v, got %+v", expected, trades)
+	}
+	if _, err := formatTrades([]byte(`[{"date": "1", "tid": "1", "amount": "1", "type": "2", "price": "1"}]`)); err == nil {
+		t.Error("error expected for an unknown type")
+	}
+}
+
+func TestFormatTradesNumbers(t *testing.T) {
+	trades, err := formatTrades([]byte(`[{"date": 1567755304, "tid": 98765432, "amount": 0.02, "type": 0, "price": 10455.51}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Trade{{Time: time.Unix(1567755304, 0), ID: "98765432", TID: 98765432, Price: 10455.51, Amount: 0.02, Type: TradeBuy}}
+	if !reflect.DeepEqual(expected, trades) {
+		t.Errorf("expected %+v, got %+v", expected, trades)
+	}
+}
+
+func TestFormatTradesMalformed(t *testing.T) {
+	tests := []struct {
+		data string
+		// err is a part of the expected error message.
+		err string
+	}{
+		{data: `{"status": "error", "reason": "Invalid currency pair", "code": "API0011"}`, err: "trades decoding error"},
+		{data: `[null]`, err: "trade 0: price parsing error"},
+		{data: `["x"]`, err: "trades decoding error"},
+		{data: `[{"date": "1", "tid": {}, "amount": "1", "type": "0", "price": "1"}]`, err: "trades decoding error"},
+		{data: `[{"date": "1", "tid": "1", "amount": "1", "type": "0", "price": "1"}, {"date": "1", "tid": "2", "type": "0", "price": "1"}]`, err: "trade 1: amount parsing error"},
+		{data: `[{"date": "1", "amount": "1", "type": "0", "price": "1"}]`, err: "trade 0: tid parsing error"},
+		{data: `[{"date": "1.5", "tid": "1", "amount": "1", "type": "0", "price": "1"}]`, err: "trade 0: date parsing error"},
+	}
+	for _, test := range tests {
+		trades, err := formatTrades([]byte(test.data))
+		if err == nil || trades != nil {
+			t.Errorf("%s: expected an error, got %+v", test.data, trades)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected %q in %q", test.data, test.err, err.Error())
+		}
+	}
+}
+
+func TestGetTradesParamsURL(t *testing.T) {
+	var urls []string
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		urls = append(urls, req.URL.String())
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`[]`)),
+			Request:    req,
+		}, nil
+	}))()
+	api := New("", "")
+	for _, interval := range []Interval{IntervalMinute, IntervalDay, ""} {
+		if _, err := api.GetTradesParams("btcusd", interval); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{
+		"https://www.bitstamp.net/api/v2/transactions/btcusd/?time=minute",
+		"https://www.bitstamp.net/api/v2/transactions/btcusd/?time=day",
+		"https://www.bitstamp.net/api/v2/transactions/btcusd/",
+	}
+	if !reflect.DeepEqual(expected, urls) {
+		t.Errorf("expected %q, got %q", expected, urls)
+	}
+	if _, err := api.GetTradesParams("btcusd", "minutes"); err == nil {
+		t.Error("error expected for an invalid interval")
+	}
+	if len(urls) != len(expected) {
+		t.Error("invalid interval must not be sent")
+	}
+}
+
+func TestPublicRequestCancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	defer withTransport(hangingTransport(started))()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err := New("", "").GetTickerCtx(ctx, "btcusd"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() { <-started }()
+	if _, err := New("", "").GetTradesCtx(ctx, "btcusd"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	if timeout := New("", "").timeout(); timeout != 10*time.Second {
+		t.Errorf("unexpected default timeout %v", timeout)
+	}
+	var paths []string
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"last": "1.5"}`)),
+			Request:    req,
+		}, nil
+	})}
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request to the default client: %s", req.URL)
+		return nil, errors.New("unexpected request")
+	}))()
+	api := NewWithKey("key", "secret")
+	api.HTTPClient = client
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/api/v2/ticker/btcusd", "/api/v2/balance/"}
+	if !reflect.DeepEqual(expected, paths) {
+		t.Errorf("expected %q, got %q", expected, paths)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/ticker/btcusd", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"last": "10455.51", "timestamp": "1567755304"}`))
+	})
+	mux.HandleFunc("/api/v2/balance/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Auth") != "BITSTAMP key" {
+			w.Write([]byte(`{"status": "error", "reason": "unauthorized"}`))
+			return
+		}
+		w.Write([]byte(`{"btc_available": "1.5", "btc_reserved": "0.5", "btc_balance": "2.0"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	api := NewWithKey("key", "secret")
+	api.BaseURL = srv.URL + "/api/v2/"
+	api.HTTPClient = srv.Client()
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last != 10455.51 {
+		t.Errorf("unexpected ticker %+v", *ticker)
+	}
+	balances, err := api.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Balance{Available: 1.5, Reserved: 0.5, Total: 2}); balances.Get("btc") != expected {
+		t.Errorf("expected %+v, got %+v", expected, balances.Get("btc"))
+	}
+}
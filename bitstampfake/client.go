@@ -0,0 +1,887 @@
+package bitstampfake
+
+import (
+	"context"
+	"time"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+)
+
+// Client is an in-memory bitstamp.Client.
+// Responses are scripted by setting the function fields, like GetTickerFunc.
+// Calls of methods without a function return ErrNotScripted.
+// Both the plain and the Ctx variants of a method call the same function,
+// and are recorded under the plain name.
+type Client struct {
+	// MarketDataClient methods.
+	GetTickerFunc           func(ctx context.Context, symbol string) (*bitstamp.Ticker, error)
+	GetTickerHourFunc       func(ctx context.Context, symbol string) (*bitstamp.Ticker, error)
+	GetAllTickersFunc       func(ctx context.Context) (map[string]bitstamp.Ticker, error)
+	GetEurUsdFunc           func(ctx context.Context) (*bitstamp.ConversionRate, error)
+	GetOrderBookFunc        func(ctx context.Context, symbol string) (*bitstamp.OrderBook, error)
+	GetOrderBookDepthFunc   func(ctx context.Context, symbol string, depth int) (*bitstamp.OrderBook, error)
+	GetOrderBookGroupedFunc func(ctx context.Context, symbol string, group int) (*bitstamp.GroupedOrderBook, error)
+	GetTradesFunc           func(ctx context.Context, symbol string) ([]bitstamp.Trade, error)
+	GetTradesParamsFunc     func(ctx context.Context, symbol string, interval bitstamp.Interval) ([]bitstamp.Trade, error)
+	GetOHLCFunc             func(ctx context.Context, symbol string, opts bitstamp.OHLCOpts) ([]bitstamp.Candle, error)
+	GetCurrenciesFunc       func(ctx context.Context) ([]bitstamp.Currency, error)
+	GetTradingPairsInfoFunc func(ctx context.Context) ([]bitstamp.PairInfo, error)
+	GetTickerDecimalFunc    func(ctx context.Context, symbol string) (*bitstamp.DecimalTicker, error)
+	GetOrderBookDecimalFunc func(ctx context.Context, symbol string) (*bitstamp.DecimalOrderBook, error)
+	GetTradesDecimalFunc    func(ctx context.Context, symbol string) ([]bitstamp.DecimalTrade, error)
+	GetMarketSnapshotFunc   func(ctx context.Context, symbol string) (*bitstamp.Snapshot, error)
+
+	// TradingClient methods.
+	BuyLimitOrderFunc          func(ctx context.Context, symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error)
+	SellLimitOrderFunc         func(ctx context.Context, symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error)
+	BuyMarketOrderFunc         func(ctx context.Context, symbol string, amount float64, opts bitstamp.MarketOrderOpts) (*bitstamp.OrderResult, error)
+	SellMarketOrderFunc        func(ctx context.Context, symbol string, amount float64, opts bitstamp.MarketOrderOpts) (*bitstamp.OrderResult, error)
+	BuyInstantOrderFunc        func(ctx context.Context, symbol string, amount float64, opts bitstamp.InstantOrderOpts) (*bitstamp.OrderResult, error)
+	SellInstantOrderFunc       func(ctx context.Context, symbol string, amount float64, opts bitstamp.InstantOrderOpts) (*bitstamp.OrderResult, error)
+	BuyLimitOrderDecimalFunc   func(ctx context.Context, symbol string, price, amount bitstamp.Decimal, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error)
+	SellLimitOrderDecimalFunc  func(ctx context.Context, symbol string, price, amount bitstamp.Decimal, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error)
+	ReplaceOrderFunc           func(ctx context.Context, orderID int64, newPrice, newAmount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.ReplaceResult, error)
+	CancelOrderFunc            func(ctx context.Context, id int64) (*bitstamp.CanceledOrder, error)
+	CancelAllOrdersFunc        func(ctx context.Context) (*bitstamp.CancelAllResult, error)
+	CancelAllOrdersForPairFunc func(ctx context.Context, symbol string) (*bitstamp.CancelAllResult, error)
+	GetOpenOrdersFunc          func(ctx context.Context) ([]bitstamp.OpenOrder, error)
+	GetOpenOrdersForPairFunc   func(ctx context.Context, symbol string) ([]bitstamp.OpenOrder, error)
+	GetOrderStatusFunc         func(ctx context.Context, id int64) (*bitstamp.OrderStatusResult, error)
+	GetOrderStatusParamsFunc   func(ctx context.Context, params bitstamp.OrderStatusParams) (*bitstamp.OrderStatusResult, error)
+
+	// AccountClient methods.
+	GetAccountBalanceFunc        func(ctx context.Context) (*bitstamp.Balances, error)
+	GetAccountBalanceDecimalFunc func(ctx context.Context) (*bitstamp.DecimalBalances, error)
+	GetPairBalanceFunc           func(ctx context.Context, symbol string) (*bitstamp.PairBalance, error)
+	GetTradingFeesFunc           func(ctx context.Context) (map[string]bitstamp.TradingFee, error)
+	GetTradingFeesForPairFunc    func(ctx context.Context, symbol string) (*bitstamp.TradingFee, error)
+	GetUserTransactionsFunc      func(ctx context.Context, params bitstamp.UserTransactionsParams) ([]bitstamp.UserTransaction, error)
+	GetCryptoTransactionsFunc    func(ctx context.Context, params bitstamp.CryptoTransactionsParams) (*bitstamp.CryptoTransactions, error)
+	GetWebsocketsTokenFunc       func(ctx context.Context) (*bitstamp.WebsocketToken, error)
+
+	// FundingClient methods.
+	GetDepositAddressFunc         func(ctx context.Context, currency string) (*bitstamp.DepositAddress, error)
+	CryptoWithdrawFunc            func(ctx context.Context, currency, address string, amount float64, opts bitstamp.WithdrawOpts) (int64, error)
+	WithdrawBTCFunc               func(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawETHFunc               func(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawUSDCFunc              func(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawUSDTFunc              func(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawLINKFunc              func(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawPAXFunc               func(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawXRPFunc               func(ctx context.Context, address string, amount float64, destinationTag *int64) (int64, error)
+	WithdrawXLMFunc               func(ctx context.Context, address string, amount float64, memoID *string) (int64, error)
+	GetWithdrawalRequestsFunc     func(ctx context.Context, since time.Time, limit int) ([]bitstamp.WithdrawalRequest, error)
+	OpenBankWithdrawalFunc        func(ctx context.Context, params bitstamp.BankWithdrawalParams) (int64, error)
+	GetBankWithdrawalStatusFunc   func(ctx context.Context, id int64) (*bitstamp.BankWithdrawalStatus, error)
+	CancelBankWithdrawalFunc      func(ctx context.Context, id int64) (*bitstamp.CanceledBankWithdrawal, error)
+	NewLiquidationAddressFunc     func(ctx context.Context, liquidationCurrency string) (string, error)
+	GetLiquidationAddressInfoFunc func(ctx context.Context, address string, since time.Time) ([]bitstamp.LiquidationAddressInfo, error)
+	TransferSubToMainFunc         func(ctx context.Context, amount float64, currency, subAccount string) (*bitstamp.TransferResult, error)
+	TransferMainToSubFunc         func(ctx context.Context, amount float64, currency, subAccount string) (*bitstamp.TransferResult, error)
+
+	recorder
+}
+
+var _ bitstamp.Client = (*Client)(nil)
+
+// GetTicker calls GetTickerFunc.
+func (c *Client) GetTicker(symbol string) (*bitstamp.Ticker, error) {
+	return c.GetTickerCtx(context.Background(), symbol)
+}
+
+// GetTickerCtx calls GetTickerFunc.
+func (c *Client) GetTickerCtx(ctx context.Context, symbol string) (*bitstamp.Ticker, error) {
+	c.record("GetTicker", symbol)
+	if c.GetTickerFunc == nil {
+		return nil, notScripted("GetTicker")
+	}
+	return c.GetTickerFunc(ctx, symbol)
+}
+
+// GetTickerHour calls GetTickerHourFunc.
+func (c *Client) GetTickerHour(symbol string) (*bitstamp.Ticker, error) {
+	return c.GetTickerHourCtx(context.Background(), symbol)
+}
+
+// GetTickerHourCtx calls GetTickerHourFunc.
+func (c *Client) GetTickerHourCtx(ctx context.Context, symbol string) (*bitstamp.Ticker, error) {
+	c.record("GetTickerHour", symbol)
+	if c.GetTickerHourFunc == nil {
+		return nil, notScripted("GetTickerHour")
+	}
+	return c.GetTickerHourFunc(ctx, symbol)
+}
+
+// GetAllTickers calls GetAllTickersFunc.
+func (c *Client) GetAllTickers() (map[string]bitstamp.Ticker, error) {
+	return c.GetAllTickersCtx(context.Background())
+}
+
+// GetAllTickersCtx calls GetAllTickersFunc.
+func (c *Client) GetAllTickersCtx(ctx context.Context) (map[string]bitstamp.Ticker, error) {
+	c.record("GetAllTickers")
+	if c.GetAllTickersFunc == nil {
+		return nil, notScripted("GetAllTickers")
+	}
+	return c.GetAllTickersFunc(ctx)
+}
+
+// GetEurUsd calls GetEurUsdFunc.
+func (c *Client) GetEurUsd() (*bitstamp.ConversionRate, error) {
+	return c.GetEurUsdCtx(context.Background())
+}
+
+// GetEurUsdCtx calls GetEurUsdFunc.
+func (c *Client) GetEurUsdCtx(ctx context.Context) (*bitstamp.ConversionRate, error) {
+	c.record("GetEurUsd")
+	if c.GetEurUsdFunc == nil {
+		return nil, notScripted("GetEurUsd")
+	}
+	return c.GetEurUsdFunc(ctx)
+}
+
+// GetOrderBook calls GetOrderBookFunc.
+func (c *Client) GetOrderBook(symbol string) (*bitstamp.OrderBook, error) {
+	return c.GetOrderBookCtx(context.Background(), symbol)
+}
+
+// GetOrderBookCtx calls GetOrderBookFunc.
+func (c *Client) GetOrderBookCtx(ctx context.Context, symbol string) (*bitstamp.OrderBook, error) {
+	c.record("GetOrderBook", symbol)
+	if c.GetOrderBookFunc == nil {
+		return nil, notScripted("GetOrderBook")
+	}
+	return c.GetOrderBookFunc(ctx, symbol)
+}
+
+// GetOrderBookDepth calls GetOrderBookDepthFunc.
+func (c *Client) GetOrderBookDepth(symbol string, depth int) (*bitstamp.OrderBook, error) {
+	return c.GetOrderBookDepthCtx(context.Background(), symbol, depth)
+}
+
+// GetOrderBookDepthCtx calls GetOrderBookDepthFunc.
+func (c *Client) GetOrderBookDepthCtx(ctx context.Context, symbol string, depth int) (*bitstamp.OrderBook, error) {
+	c.record("GetOrderBookDepth", symbol, depth)
+	if c.GetOrderBookDepthFunc == nil {
+		return nil, notScripted("GetOrderBookDepth")
+	}
+	return c.GetOrderBookDepthFunc(ctx, symbol, depth)
+}
+
+// GetOrderBookGrouped calls GetOrderBookGroupedFunc.
+func (c *Client) GetOrderBookGrouped(symbol string, group int) (*bitstamp.GroupedOrderBook, error) {
+	return c.GetOrderBookGroupedCtx(context.Background(), symbol, group)
+}
+
+// GetOrderBookGroupedCtx calls GetOrderBookGroupedFunc.
+func (c *Client) GetOrderBookGroupedCtx(ctx context.Context, symbol string, group int) (*bitstamp.GroupedOrderBook, error) {
+	c.record("GetOrderBookGrouped", symbol, group)
+	if c.GetOrderBookGroupedFunc == nil {
+		return nil, notScripted("GetOrderBookGrouped")
+	}
+	return c.GetOrderBookGroupedFunc(ctx, symbol, group)
+}
+
+// GetTrades calls GetTradesFunc.
+func (c *Client) GetTrades(symbol string) ([]bitstamp.Trade, error) {
+	return c.GetTradesCtx(context.Background(), symbol)
+}
+
+// GetTradesCtx calls GetTradesFunc.
+func (c *Client) GetTradesCtx(ctx context.Context, symbol string) ([]bitstamp.Trade, error) {
+	c.record("GetTrades", symbol)
+	if c.GetTradesFunc == nil {
+		return nil, notScripted("GetTrades")
+	}
+	return c.GetTradesFunc(ctx, symbol)
+}
+
+// GetTradesParams calls GetTradesParamsFunc.
+func (c *Client) GetTradesParams(symbol string, interval bitstamp.Interval) ([]bitstamp.Trade, error) {
+	return c.GetTradesParamsCtx(context.Background(), symbol, interval)
+}
+
+// GetTradesParamsCtx calls GetTradesParamsFunc.
+func (c *Client) GetTradesParamsCtx(ctx context.Context, symbol string, interval bitstamp.Interval) ([]bitstamp.Trade, error) {
+	c.record("GetTradesParams", symbol, interval)
+	if c.GetTradesParamsFunc == nil {
+		return nil, notScripted("GetTradesParams")
+	}
+	return c.GetTradesParamsFunc(ctx, symbol, interval)
+}
+
+// GetOHLC calls GetOHLCFunc.
+func (c *Client) GetOHLC(symbol string, opts bitstamp.OHLCOpts) ([]bitstamp.Candle, error) {
+	return c.GetOHLCCtx(context.Background(), symbol, opts)
+}
+
+// GetOHLCCtx calls GetOHLCFunc.
+func (c *Client) GetOHLCCtx(ctx context.Context, symbol string, opts bitstamp.OHLCOpts) ([]bitstamp.Candle, error) {
+	c.record("GetOHLC", symbol, opts)
+	if c.GetOHLCFunc == nil {
+		return nil, notScripted("GetOHLC")
+	}
+	return c.GetOHLCFunc(ctx, symbol, opts)
+}
+
+// GetCurrencies calls GetCurrenciesFunc.
+func (c *Client) GetCurrencies() ([]bitstamp.Currency, error) {
+	return c.GetCurrenciesCtx(context.Background())
+}
+
+// GetCurrenciesCtx calls GetCurrenciesFunc.
+func (c *Client) GetCurrenciesCtx(ctx context.Context) ([]bitstamp.Currency, error) {
+	c.record("GetCurrencies")
+	if c.GetCurrenciesFunc == nil {
+		return nil, notScripted("GetCurrencies")
+	}
+	return c.GetCurrenciesFunc(ctx)
+}
+
+// GetTradingPairsInfo calls GetTradingPairsInfoFunc.
+func (c *Client) GetTradingPairsInfo() ([]bitstamp.PairInfo, error) {
+	return c.GetTradingPairsInfoCtx(context.Background())
+}
+
+// GetTradingPairsInfoCtx calls GetTradingPairsInfoFunc.
+func (c *Client) GetTradingPairsInfoCtx(ctx context.Context) ([]bitstamp.PairInfo, error) {
+	c.record("GetTradingPairsInfo")
+	if c.GetTradingPairsInfoFunc == nil {
+		return nil, notScripted("GetTradingPairsInfo")
+	}
+	return c.GetTradingPairsInfoFunc(ctx)
+}
+
+// GetTickerDecimal calls GetTickerDecimalFunc.
+func (c *Client) GetTickerDecimal(symbol string) (*bitstamp.DecimalTicker, error) {
+	return c.GetTickerDecimalCtx(context.Background(), symbol)
+}
+
+// GetTickerDecimalCtx calls GetTickerDecimalFunc.
+func (c *Client) GetTickerDecimalCtx(ctx context.Context, symbol string) (*bitstamp.DecimalTicker, error) {
+	c.record("GetTickerDecimal", symbol)
+	if c.GetTickerDecimalFunc == nil {
+		return nil, notScripted("GetTickerDecimal")
+	}
+	return c.GetTickerDecimalFunc(ctx, symbol)
+}
+
+// GetOrderBookDecimal calls GetOrderBookDecimalFunc.
+func (c *Client) GetOrderBookDecimal(symbol string) (*bitstamp.DecimalOrderBook, error) {
+	return c.GetOrderBookDecimalCtx(context.Background(), symbol)
+}
+
+// GetOrderBookDecimalCtx calls GetOrderBookDecimalFunc.
+func (c *Client) GetOrderBookDecimalCtx(ctx context.Context, symbol string) (*bitstamp.DecimalOrderBook, error) {
+	c.record("GetOrderBookDecimal", symbol)
+	if c.GetOrderBookDecimalFunc == nil {
+		return nil, notScripted("GetOrderBookDecimal")
+	}
+	return c.GetOrderBookDecimalFunc(ctx, symbol)
+}
+
+// GetTradesDecimal calls GetTradesDecimalFunc.
+func (c *Client) GetTradesDecimal(symbol string) ([]bitstamp.DecimalTrade, error) {
+	return c.GetTradesDecimalCtx(context.Background(), symbol)
+}
+
+// GetTradesDecimalCtx calls GetTradesDecimalFunc.
+func (c *Client) GetTradesDecimalCtx(ctx context.Context, symbol string) ([]bitstamp.DecimalTrade, error) {
+	c.record("GetTradesDecimal", symbol)
+	if c.GetTradesDecimalFunc == nil {
+		return nil, notScripted("GetTradesDecimal")
+	}
+	return c.GetTradesDecimalFunc(ctx, symbol)
+}
+
+// GetMarketSnapshot calls GetMarketSnapshotFunc.
+func (c *Client) GetMarketSnapshot(ctx context.Context, symbol string) (*bitstamp.Snapshot, error) {
+	c.record("GetMarketSnapshot", symbol)
+	if c.GetMarketSnapshotFunc == nil {
+		return nil, notScripted("GetMarketSnapshot")
+	}
+	return c.GetMarketSnapshotFunc(ctx, symbol)
+}
+
+// BuyLimitOrder calls BuyLimitOrderFunc.
+func (c *Client) BuyLimitOrder(symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.BuyLimitOrderCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// BuyLimitOrderCtx calls BuyLimitOrderFunc.
+func (c *Client) BuyLimitOrderCtx(ctx context.Context, symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("BuyLimitOrder", symbol, price, amount, opts)
+	if c.BuyLimitOrderFunc == nil {
+		return nil, notScripted("BuyLimitOrder")
+	}
+	return c.BuyLimitOrderFunc(ctx, symbol, price, amount, opts)
+}
+
+// SellLimitOrder calls SellLimitOrderFunc.
+func (c *Client) SellLimitOrder(symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.SellLimitOrderCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// SellLimitOrderCtx calls SellLimitOrderFunc.
+func (c *Client) SellLimitOrderCtx(ctx context.Context, symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("SellLimitOrder", symbol, price, amount, opts)
+	if c.SellLimitOrderFunc == nil {
+		return nil, notScripted("SellLimitOrder")
+	}
+	return c.SellLimitOrderFunc(ctx, symbol, price, amount, opts)
+}
+
+// BuyMarketOrder calls BuyMarketOrderFunc.
+func (c *Client) BuyMarketOrder(symbol string, amount float64, opts bitstamp.MarketOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.BuyMarketOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// BuyMarketOrderCtx calls BuyMarketOrderFunc.
+func (c *Client) BuyMarketOrderCtx(ctx context.Context, symbol string, amount float64, opts bitstamp.MarketOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("BuyMarketOrder", symbol, amount, opts)
+	if c.BuyMarketOrderFunc == nil {
+		return nil, notScripted("BuyMarketOrder")
+	}
+	return c.BuyMarketOrderFunc(ctx, symbol, amount, opts)
+}
+
+// SellMarketOrder calls SellMarketOrderFunc.
+func (c *Client) SellMarketOrder(symbol string, amount float64, opts bitstamp.MarketOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.SellMarketOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// SellMarketOrderCtx calls SellMarketOrderFunc.
+func (c *Client) SellMarketOrderCtx(ctx context.Context, symbol string, amount float64, opts bitstamp.MarketOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("SellMarketOrder", symbol, amount, opts)
+	if c.SellMarketOrderFunc == nil {
+		return nil, notScripted("SellMarketOrder")
+	}
+	return c.SellMarketOrderFunc(ctx, symbol, amount, opts)
+}
+
+// BuyInstantOrder calls BuyInstantOrderFunc.
+func (c *Client) BuyInstantOrder(symbol string, amount float64, opts bitstamp.InstantOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.BuyInstantOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// BuyInstantOrderCtx calls BuyInstantOrderFunc.
+func (c *Client) BuyInstantOrderCtx(ctx context.Context, symbol string, amount float64, opts bitstamp.InstantOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("BuyInstantOrder", symbol, amount, opts)
+	if c.BuyInstantOrderFunc == nil {
+		return nil, notScripted("BuyInstantOrder")
+	}
+	return c.BuyInstantOrderFunc(ctx, symbol, amount, opts)
+}
+
+// SellInstantOrder calls SellInstantOrderFunc.
+func (c *Client) SellInstantOrder(symbol string, amount float64, opts bitstamp.InstantOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.SellInstantOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// SellInstantOrderCtx calls SellInstantOrderFunc.
+func (c *Client) SellInstantOrderCtx(ctx context.Context, symbol string, amount float64, opts bitstamp.InstantOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("SellInstantOrder", symbol, amount, opts)
+	if c.SellInstantOrderFunc == nil {
+		return nil, notScripted("SellInstantOrder")
+	}
+	return c.SellInstantOrderFunc(ctx, symbol, amount, opts)
+}
+
+// BuyLimitOrderDecimal calls BuyLimitOrderDecimalFunc.
+func (c *Client) BuyLimitOrderDecimal(symbol string, price, amount bitstamp.Decimal, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.BuyLimitOrderDecimalCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// BuyLimitOrderDecimalCtx calls BuyLimitOrderDecimalFunc.
+func (c *Client) BuyLimitOrderDecimalCtx(ctx context.Context, symbol string, price, amount bitstamp.Decimal, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("BuyLimitOrderDecimal", symbol, price, amount, opts)
+	if c.BuyLimitOrderDecimalFunc == nil {
+		return nil, notScripted("BuyLimitOrderDecimal")
+	}
+	return c.BuyLimitOrderDecimalFunc(ctx, symbol, price, amount, opts)
+}
+
+// SellLimitOrderDecimal calls SellLimitOrderDecimalFunc.
+func (c *Client) SellLimitOrderDecimal(symbol string, price, amount bitstamp.Decimal, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	return c.SellLimitOrderDecimalCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// SellLimitOrderDecimalCtx calls SellLimitOrderDecimalFunc.
+func (c *Client) SellLimitOrderDecimalCtx(ctx context.Context, symbol string, price, amount bitstamp.Decimal, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+	c.record("SellLimitOrderDecimal", symbol, price, amount, opts)
+	if c.SellLimitOrderDecimalFunc == nil {
+		return nil, notScripted("SellLimitOrderDecimal")
+	}
+	return c.SellLimitOrderDecimalFunc(ctx, symbol, price, amount, opts)
+}
+
+// ReplaceOrder calls ReplaceOrderFunc.
+func (c *Client) ReplaceOrder(ctx context.Context, orderID int64, newPrice, newAmount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.ReplaceResult, error) {
+	c.record("ReplaceOrder", orderID, newPrice, newAmount, opts)
+	if c.ReplaceOrderFunc == nil {
+		return nil, notScripted("ReplaceOrder")
+	}
+	return c.ReplaceOrderFunc(ctx, orderID, newPrice, newAmount, opts)
+}
+
+// CancelOrder calls CancelOrderFunc.
+func (c *Client) CancelOrder(id int64) (*bitstamp.CanceledOrder, error) {
+	return c.CancelOrderCtx(context.Background(), id)
+}
+
+// CancelOrderCtx calls CancelOrderFunc.
+func (c *Client) CancelOrderCtx(ctx context.Context, id int64) (*bitstamp.CanceledOrder, error) {
+	c.record("CancelOrder", id)
+	if c.CancelOrderFunc == nil {
+		return nil, notScripted("CancelOrder")
+	}
+	return c.CancelOrderFunc(ctx, id)
+}
+
+// CancelAllOrders calls CancelAllOrdersFunc.
+func (c *Client) CancelAllOrders() (*bitstamp.CancelAllResult, error) {
+	return c.CancelAllOrdersCtx(context.Background())
+}
+
+// CancelAllOrdersCtx calls CancelAllOrdersFunc.
+func (c *Client) CancelAllOrdersCtx(ctx context.Context) (*bitstamp.CancelAllResult, error) {
+	c.record("CancelAllOrders")
+	if c.CancelAllOrdersFunc == nil {
+		return nil, notScripted("CancelAllOrders")
+	}
+	return c.CancelAllOrdersFunc(ctx)
+}
+
+// CancelAllOrdersForPair calls CancelAllOrdersForPairFunc.
+func (c *Client) CancelAllOrdersForPair(symbol string) (*bitstamp.CancelAllResult, error) {
+	return c.CancelAllOrdersForPairCtx(context.Background(), symbol)
+}
+
+// CancelAllOrdersForPairCtx calls CancelAllOrdersForPairFunc.
+func (c *Client) CancelAllOrdersForPairCtx(ctx context.Context, symbol string) (*bitstamp.CancelAllResult, error) {
+	c.record("CancelAllOrdersForPair", symbol)
+	if c.CancelAllOrdersForPairFunc == nil {
+		return nil, notScripted("CancelAllOrdersForPair")
+	}
+	return c.CancelAllOrdersForPairFunc(ctx, symbol)
+}
+
+// GetOpenOrders calls GetOpenOrdersFunc.
+func (c *Client) GetOpenOrders() ([]bitstamp.OpenOrder, error) {
+	return c.GetOpenOrdersCtx(context.Background())
+}
+
+// GetOpenOrdersCtx calls GetOpenOrdersFunc.
+func (c *Client) GetOpenOrdersCtx(ctx context.Context) ([]bitstamp.OpenOrder, error) {
+	c.record("GetOpenOrders")
+	if c.GetOpenOrdersFunc == nil {
+		return nil, notScripted("GetOpenOrders")
+	}
+	return c.GetOpenOrdersFunc(ctx)
+}
+
+// GetOpenOrdersForPair calls GetOpenOrdersForPairFunc.
+func (c *Client) GetOpenOrdersForPair(symbol string) ([]bitstamp.OpenOrder, error) {
+	return c.GetOpenOrdersForPairCtx(context.Background(), symbol)
+}
+
+// GetOpenOrdersForPairCtx calls GetOpenOrdersForPairFunc.
+func (c *Client) GetOpenOrdersForPairCtx(ctx context.Context, symbol string) ([]bitstamp.OpenOrder, error) {
+	c.record("GetOpenOrdersForPair", symbol)
+	if c.GetOpenOrdersForPairFunc == nil {
+		return nil, notScripted("GetOpenOrdersForPair")
+	}
+	return c.GetOpenOrdersForPairFunc(ctx, symbol)
+}
+
+// GetOrderStatus calls GetOrderStatusFunc.
+func (c *Client) GetOrderStatus(id int64) (*bitstamp.OrderStatusResult, error) {
+	return c.GetOrderStatusCtx(context.Background(), id)
+}
+
+// GetOrderStatusCtx calls GetOrderStatusFunc.
+func (c *Client) GetOrderStatusCtx(ctx context.Context, id int64) (*bitstamp.OrderStatusResult, error) {
+	c.record("GetOrderStatus", id)
+	if c.GetOrderStatusFunc == nil {
+		return nil, notScripted("GetOrderStatus")
+	}
+	return c.GetOrderStatusFunc(ctx, id)
+}
+
+// GetOrderStatusParams calls GetOrderStatusParamsFunc.
+func (c *Client) GetOrderStatusParams(params bitstamp.OrderStatusParams) (*bitstamp.OrderStatusResult, error) {
+	return c.GetOrderStatusParamsCtx(context.Background(), params)
+}
+
+// GetOrderStatusParamsCtx calls GetOrderStatusParamsFunc.
+func (c *Client) GetOrderStatusParamsCtx(ctx context.Context, params bitstamp.OrderStatusParams) (*bitstamp.OrderStatusResult, error) {
+	c.record("GetOrderStatusParams", params)
+	if c.GetOrderStatusParamsFunc == nil {
+		return nil, notScripted("GetOrderStatusParams")
+	}
+	return c.GetOrderStatusParamsFunc(ctx, params)
+}
+
+// GetAccountBalance calls GetAccountBalanceFunc.
+func (c *Client) GetAccountBalance() (*bitstamp.Balances, error) {
+	return c.GetAccountBalanceCtx(context.Background())
+}
+
+// GetAccountBalanceCtx calls GetAccountBalanceFunc.
+func (c *Client) GetAccountBalanceCtx(ctx context.Context) (*bitstamp.Balances, error) {
+	c.record("GetAccountBalance")
+	if c.GetAccountBalanceFunc == nil {
+		return nil, notScripted("GetAccountBalance")
+	}
+	return c.GetAccountBalanceFunc(ctx)
+}
+
+// GetAccountBalanceDecimal calls GetAccountBalanceDecimalFunc.
+func (c *Client) GetAccountBalanceDecimal() (*bitstamp.DecimalBalances, error) {
+	return c.GetAccountBalanceDecimalCtx(context.Background())
+}
+
+// GetAccountBalanceDecimalCtx calls GetAccountBalanceDecimalFunc.
+func (c *Client) GetAccountBalanceDecimalCtx(ctx context.Context) (*bitstamp.DecimalBalances, error) {
+	c.record("GetAccountBalanceDecimal")
+	if c.GetAccountBalanceDecimalFunc == nil {
+		return nil, notScripted("GetAccountBalanceDecimal")
+	}
+	return c.GetAccountBalanceDecimalFunc(ctx)
+}
+
+// GetPairBalance calls GetPairBalanceFunc.
+func (c *Client) GetPairBalance(symbol string) (*bitstamp.PairBalance, error) {
+	return c.GetPairBalanceCtx(context.Background(), symbol)
+}
+
+// GetPairBalanceCtx calls GetPairBalanceFunc.
+func (c *Client) GetPairBalanceCtx(ctx context.Context, symbol string) (*bitstamp.PairBalance, error) {
+	c.record("GetPairBalance", symbol)
+	if c.GetPairBalanceFunc == nil {
+		return nil, notScripted("GetPairBalance")
+	}
+	return c.GetPairBalanceFunc(ctx, symbol)
+}
+
+// GetTradingFees calls GetTradingFeesFunc.
+func (c *Client) GetTradingFees() (map[string]bitstamp.TradingFee, error) {
+	return c.GetTradingFeesCtx(context.Background())
+}
+
+// GetTradingFeesCtx calls GetTradingFeesFunc.
+func (c *Client) GetTradingFeesCtx(ctx context.Context) (map[string]bitstamp.TradingFee, error) {
+	c.record("GetTradingFees")
+	if c.GetTradingFeesFunc == nil {
+		return nil, notScripted("GetTradingFees")
+	}
+	return c.GetTradingFeesFunc(ctx)
+}
+
+// GetTradingFeesForPair calls GetTradingFeesForPairFunc.
+func (c *Client) GetTradingFeesForPair(symbol string) (*bitstamp.TradingFee, error) {
+	return c.GetTradingFeesForPairCtx(context.Background(), symbol)
+}
+
+// GetTradingFeesForPairCtx calls GetTradingFeesForPairFunc.
+func (c *Client) GetTradingFeesForPairCtx(ctx context.Context, symbol string) (*bitstamp.TradingFee, error) {
+	c.record("GetTradingFeesForPair", symbol)
+	if c.GetTradingFeesForPairFunc == nil {
+		return nil, notScripted("GetTradingFeesForPair")
+	}
+	return c.GetTradingFeesForPairFunc(ctx, symbol)
+}
+
+// GetUserTransactions calls GetUserTransactionsFunc.
+func (c *Client) GetUserTransactions(params bitstamp.UserTransactionsParams) ([]bitstamp.UserTransaction, error) {
+	return c.GetUserTransactionsCtx(context.Background(), params)
+}
+
+// GetUserTransactionsCtx calls GetUserTransactionsFunc.
+func (c *Client) GetUserTransactionsCtx(ctx context.Context, params bitstamp.UserTransactionsParams) ([]bitstamp.UserTransaction, error) {
+	c.record("GetUserTransactions", params)
+	if c.GetUserTransactionsFunc == nil {
+		return nil, notScripted("GetUserTransactions")
+	}
+	return c.GetUserTransactionsFunc(ctx, params)
+}
+
+// GetCryptoTransactions calls GetCryptoTransactionsFunc.
+func (c *Client) GetCryptoTransactions(params bitstamp.CryptoTransactionsParams) (*bitstamp.CryptoTransactions, error) {
+	return c.GetCryptoTransactionsCtx(context.Background(), params)
+}
+
+// GetCryptoTransactionsCtx calls GetCryptoTransactionsFunc.
+func (c *Client) GetCryptoTransactionsCtx(ctx context.Context, params bitstamp.CryptoTransactionsParams) (*bitstamp.CryptoTransactions, error) {
+	c.record("GetCryptoTransactions", params)
+	if c.GetCryptoTransactionsFunc == nil {
+		return nil, notScripted("GetCryptoTransactions")
+	}
+	return c.GetCryptoTransactionsFunc(ctx, params)
+}
+
+// GetWebsocketsToken calls GetWebsocketsTokenFunc.
+func (c *Client) GetWebsocketsToken() (*bitstamp.WebsocketToken, error) {
+	return c.GetWebsocketsTokenCtx(context.Background())
+}
+
+// GetWebsocketsTokenCtx calls GetWebsocketsTokenFunc.
+func (c *Client) GetWebsocketsTokenCtx(ctx context.Context) (*bitstamp.WebsocketToken, error) {
+	c.record("GetWebsocketsToken")
+	if c.GetWebsocketsTokenFunc == nil {
+		return nil, notScripted("GetWebsocketsToken")
+	}
+	return c.GetWebsocketsTokenFunc(ctx)
+}
+
+// GetDepositAddress calls GetDepositAddressFunc.
+func (c *Client) GetDepositAddress(currency string) (*bitstamp.DepositAddress, error) {
+	return c.GetDepositAddressCtx(context.Background(), currency)
+}
+
+// GetDepositAddressCtx calls GetDepositAddressFunc.
+func (c *Client) GetDepositAddressCtx(ctx context.Context, currency string) (*bitstamp.DepositAddress, error) {
+	c.record("GetDepositAddress", currency)
+	if c.GetDepositAddressFunc == nil {
+		return nil, notScripted("GetDepositAddress")
+	}
+	return c.GetDepositAddressFunc(ctx, currency)
+}
+
+// CryptoWithdraw calls CryptoWithdrawFunc.
+func (c *Client) CryptoWithdraw(currency, address string, amount float64, opts bitstamp.WithdrawOpts) (int64, error) {
+	return c.CryptoWithdrawCtx(context.Background(), currency, address, amount, opts)
+}
+
+// CryptoWithdrawCtx calls CryptoWithdrawFunc.
+func (c *Client) CryptoWithdrawCtx(ctx context.Context, currency, address string, amount float64, opts bitstamp.WithdrawOpts) (int64, error) {
+	c.record("CryptoWithdraw", currency, address, amount, opts)
+	if c.CryptoWithdrawFunc == nil {
+		return 0, notScripted("CryptoWithdraw")
+	}
+	return c.CryptoWithdrawFunc(ctx, currency, address, amount, opts)
+}
+
+// WithdrawBTC calls WithdrawBTCFunc.
+func (c *Client) WithdrawBTC(address string, amount float64) (int64, error) {
+	return c.WithdrawBTCCtx(context.Background(), address, amount)
+}
+
+// WithdrawBTCCtx calls WithdrawBTCFunc.
+func (c *Client) WithdrawBTCCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	c.record("WithdrawBTC", address, amount)
+	if c.WithdrawBTCFunc == nil {
+		return 0, notScripted("WithdrawBTC")
+	}
+	return c.WithdrawBTCFunc(ctx, address, amount)
+}
+
+// WithdrawETH calls WithdrawETHFunc.
+func (c *Client) WithdrawETH(address string, amount float64) (int64, error) {
+	return c.WithdrawETHCtx(context.Background(), address, amount)
+}
+
+// WithdrawETHCtx calls WithdrawETHFunc.
+func (c *Client) WithdrawETHCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	c.record("WithdrawETH", address, amount)
+	if c.WithdrawETHFunc == nil {
+		return 0, notScripted("WithdrawETH")
+	}
+	return c.WithdrawETHFunc(ctx, address, amount)
+}
+
+// WithdrawUSDC calls WithdrawUSDCFunc.
+func (c *Client) WithdrawUSDC(address string, amount float64) (int64, error) {
+	return c.WithdrawUSDCCtx(context.Background(), address, amount)
+}
+
+// WithdrawUSDCCtx calls WithdrawUSDCFunc.
+func (c *Client) WithdrawUSDCCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	c.record("WithdrawUSDC", address, amount)
+	if c.WithdrawUSDCFunc == nil {
+		return 0, notScripted("WithdrawUSDC")
+	}
+	return c.WithdrawUSDCFunc(ctx, address, amount)
+}
+
+// WithdrawUSDT calls WithdrawUSDTFunc.
+func (c *Client) WithdrawUSDT(address string, amount float64) (int64, error) {
+	return c.WithdrawUSDTCtx(context.Background(), address, amount)
+}
+
+// WithdrawUSDTCtx calls WithdrawUSDTFunc.
+func (c *Client) WithdrawUSDTCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	c.record("WithdrawUSDT", address, amount)
+	if c.WithdrawUSDTFunc == nil {
+		return 0, notScripted("WithdrawUSDT")
+	}
+	return c.WithdrawUSDTFunc(ctx, address, amount)
+}
+
+// WithdrawLINK calls WithdrawLINKFunc.
+func (c *Client) WithdrawLINK(address string, amount float64) (int64, error) {
+	return c.WithdrawLINKCtx(context.Background(), address, amount)
+}
+
+// WithdrawLINKCtx calls WithdrawLINKFunc.
+func (c *Client) WithdrawLINKCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	c.record("WithdrawLINK", address, amount)
+	if c.WithdrawLINKFunc == nil {
+		return 0, notScripted("WithdrawLINK")
+	}
+	return c.WithdrawLINKFunc(ctx, address, amount)
+}
+
+// WithdrawPAX calls WithdrawPAXFunc.
+func (c *Client) WithdrawPAX(address string, amount float64) (int64, error) {
+	return c.WithdrawPAXCtx(context.Background(), address, amount)
+}
+
+// WithdrawPAXCtx calls WithdrawPAXFunc.
+func (c *Client) WithdrawPAXCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	c.record("WithdrawPAX", address, amount)
+	if c.WithdrawPAXFunc == nil {
+		return 0, notScripted("WithdrawPAX")
+	}
+	return c.WithdrawPAXFunc(ctx, address, amount)
+}
+
+// WithdrawXRP calls WithdrawXRPFunc.
+func (c *Client) WithdrawXRP(address string, amount float64, destinationTag *int64) (int64, error) {
+	return c.WithdrawXRPCtx(context.Background(), address, amount, destinationTag)
+}
+
+// WithdrawXRPCtx calls WithdrawXRPFunc.
+func (c *Client) WithdrawXRPCtx(ctx context.Context, address string, amount float64, destinationTag *int64) (int64, error) {
+	c.record("WithdrawXRP", address, amount, destinationTag)
+	if c.WithdrawXRPFunc == nil {
+		return 0, notScripted("WithdrawXRP")
+	}
+	return c.WithdrawXRPFunc(ctx, address, amount, destinationTag)
+}
+
+// WithdrawXLM calls WithdrawXLMFunc.
+func (c *Client) WithdrawXLM(address string, amount float64, memoID *string) (int64, error) {
+	return c.WithdrawXLMCtx(context.Background(), address, amount, memoID)
+}
+
+// WithdrawXLMCtx calls WithdrawXLMFunc.
+func (c *Client) WithdrawXLMCtx(ctx context.Context, address string, amount float64, memoID *string) (int64, error) {
+	c.record("WithdrawXLM", address, amount, memoID)
+	if c.WithdrawXLMFunc == nil {
+		return 0, notScripted("WithdrawXLM")
+	}
+	return c.WithdrawXLMFunc(ctx, address, amount, memoID)
+}
+
+// GetWithdrawalRequests calls GetWithdrawalRequestsFunc.
+func (c *Client) GetWithdrawalRequests(since time.Time, limit int) ([]bitstamp.WithdrawalRequest, error) {
+	return c.GetWithdrawalRequestsCtx(context.Background(), since, limit)
+}
+
+// GetWithdrawalRequestsCtx calls GetWithdrawalRequestsFunc.
+func (c *Client) GetWithdrawalRequestsCtx(ctx context.Context, since time.Time, limit int) ([]bitstamp.WithdrawalRequest, error) {
+	c.record("GetWithdrawalRequests", since, limit)
+	if c.GetWithdrawalRequestsFunc == nil {
+		return nil, notScripted("GetWithdrawalRequests")
+	}
+	return c.GetWithdrawalRequestsFunc(ctx, since, limit)
+}
+
+// OpenBankWithdrawal calls OpenBankWithdrawalFunc.
+func (c *Client) OpenBankWithdrawal(params bitstamp.BankWithdrawalParams) (int64, error) {
+	return c.OpenBankWithdrawalCtx(context.Background(), params)
+}
+
+// OpenBankWithdrawalCtx calls OpenBankWithdrawalFunc.
+func (c *Client) OpenBankWithdrawalCtx(ctx context.Context, params bitstamp.BankWithdrawalParams) (int64, error) {
+	c.record("OpenBankWithdrawal", params)
+	if c.OpenBankWithdrawalFunc == nil {
+		return 0, notScripted("OpenBankWithdrawal")
+	}
+	return c.OpenBankWithdrawalFunc(ctx, params)
+}
+
+// GetBankWithdrawalStatus calls GetBankWithdrawalStatusFunc.
+func (c *Client) GetBankWithdrawalStatus(id int64) (*bitstamp.BankWithdrawalStatus, error) {
+	return c.GetBankWithdrawalStatusCtx(context.Background(), id)
+}
+
+// GetBankWithdrawalStatusCtx calls GetBankWithdrawalStatusFunc.
+func (c *Client) GetBankWithdrawalStatusCtx(ctx context.Context, id int64) (*bitstamp.BankWithdrawalStatus, error) {
+	c.record("GetBankWithdrawalStatus", id)
+	if c.GetBankWithdrawalStatusFunc == nil {
+		return nil, notScripted("GetBankWithdrawalStatus")
+	}
+	return c.GetBankWithdrawalStatusFunc(ctx, id)
+}
+
+// CancelBankWithdrawal calls CancelBankWithdrawalFunc.
+func (c *Client) CancelBankWithdrawal(id int64) (*bitstamp.CanceledBankWithdrawal, error) {
+	return c.CancelBankWithdrawalCtx(context.Background(), id)
+}
+
+// CancelBankWithdrawalCtx calls CancelBankWithdrawalFunc.
+func (c *Client) CancelBankWithdrawalCtx(ctx context.Context, id int64) (*bitstamp.CanceledBankWithdrawal, error) {
+	c.record("CancelBankWithdrawal", id)
+	if c.CancelBankWithdrawalFunc == nil {
+		return nil, notScripted("CancelBankWithdrawal")
+	}
+	return c.CancelBankWithdrawalFunc(ctx, id)
+}
+
+// NewLiquidationAddress calls NewLiquidationAddressFunc.
+func (c *Client) NewLiquidationAddress(liquidationCurrency string) (string, error) {
+	return c.NewLiquidationAddressCtx(context.Background(), liquidationCurrency)
+}
+
+// NewLiquidationAddressCtx calls NewLiquidationAddressFunc.
+func (c *Client) NewLiquidationAddressCtx(ctx context.Context, liquidationCurrency string) (string, error) {
+	c.record("NewLiquidationAddress", liquidationCurrency)
+	if c.NewLiquidationAddressFunc == nil {
+		return "", notScripted("NewLiquidationAddress")
+	}
+	return c.NewLiquidationAddressFunc(ctx, liquidationCurrency)
+}
+
+// GetLiquidationAddressInfo calls GetLiquidationAddressInfoFunc.
+func (c *Client) GetLiquidationAddressInfo(address string, since time.Time) ([]bitstamp.LiquidationAddressInfo, error) {
+	return c.GetLiquidationAddressInfoCtx(context.Background(), address, since)
+}
+
+// GetLiquidationAddressInfoCtx calls GetLiquidationAddressInfoFunc.
+func (c *Client) GetLiquidationAddressInfoCtx(ctx context.Context, address string, since time.Time) ([]bitstamp.LiquidationAddressInfo, error) {
+	c.record("GetLiquidationAddressInfo", address, since)
+	if c.GetLiquidationAddressInfoFunc == nil {
+		return nil, notScripted("GetLiquidationAddressInfo")
+	}
+	return c.GetLiquidationAddressInfoFunc(ctx, address, since)
+}
+
+// TransferSubToMain calls TransferSubToMainFunc.
+func (c *Client) TransferSubToMain(amount float64, currency, subAccount string) (*bitstamp.TransferResult, error) {
+	return c.TransferSubToMainCtx(context.Background(), amount, currency, subAccount)
+}
+
+// TransferSubToMainCtx calls TransferSubToMainFunc.
+func (c *Client) TransferSubToMainCtx(ctx context.Context, amount float64, currency, subAccount string) (*bitstamp.TransferResult, error) {
+	c.record("TransferSubToMain", amount, currency, subAccount)
+	if c.TransferSubToMainFunc == nil {
+		return nil, notScripted("TransferSubToMain")
+	}
+	return c.TransferSubToMainFunc(ctx, amount, currency, subAccount)
+}
+
+// TransferMainToSub calls TransferMainToSubFunc.
+func (c *Client) TransferMainToSub(amount float64, currency, subAccount string) (*bitstamp.TransferResult, error) {
+	return c.TransferMainToSubCtx(context.Background(), amount, currency, subAccount)
+}
+
+// TransferMainToSubCtx calls TransferMainToSubFunc.
+func (c *Client) TransferMainToSubCtx(ctx context.Context, amount float64, currency, subAccount string) (*bitstamp.TransferResult, error) {
+	c.record("TransferMainToSub", amount, currency, subAccount)
+	if c.TransferMainToSubFunc == nil {
+		return nil, notScripted("TransferMainToSub")
+	}
+	return c.TransferMainToSubFunc(ctx, amount, currency, subAccount)
+}
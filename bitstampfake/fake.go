@@ -0,0 +1,73 @@
+// Package bitstampfake is an in-memory fake of the bitstamp client for tests of
+// code, which depends on bitstamp.Client or on its smaller interfaces.
+//
+//	fake := &bitstampfake.Client{
+//		GetTickerFunc: func(ctx context.Context, symbol string) (*bitstamp.Ticker, error) {
+//			return &bitstamp.Ticker{Last: 10000}, nil
+//		},
+//	}
+//	runStrategy(fake)
+//	if calls := fake.CallsTo("BuyLimitOrder"); len(calls) != 1 {
+//		...
+//	}
+package bitstampfake
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotScripted is returned by the methods without a scripted function.
+var ErrNotScripted = errors.New("not scripted")
+
+func notScripted(method string) error {
+	return errors.Wrapf(ErrNotScripted, "bitstampfake: %s", method)
+}
+
+// Call is a recorded method call.
+type Call struct {
+	// Method is the name of the method without the Ctx suffix, like GetTicker.
+	Method string
+	// Args are the arguments except the context.
+	Args []interface{}
+}
+
+// recorder records the calls. It is safe for concurrent use.
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns all recorded calls in order.
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls of the given method in order.
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []Call
+	for _, call := range r.calls {
+		if call.Method == method {
+			result = append(result, call)
+		}
+	}
+	return result
+}
+
+// Reset forgets the recorded calls.
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
@@ -0,0 +1,96 @@
+package bitstampfake
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+	"github.com/pkg/errors"
+)
+
+// buyBelowLast is strategy code depending on the interfaces.
+func buyBelowLast(market bitstamp.MarketDataClient, trading bitstamp.TradingClient, symbol string) (*bitstamp.OrderResult, error) {
+	ticker, err := market.GetTicker(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return trading.BuyLimitOrder(symbol, ticker.Last/2, 0.1, bitstamp.LimitOrderOpts{})
+}
+
+func TestClient(t *testing.T) {
+	fake := &Client{
+		GetTickerFunc: func(ctx context.Context, symbol string) (*bitstamp.Ticker, error) {
+			return &bitstamp.Ticker{Last: 10000}, nil
+		},
+		BuyLimitOrderFunc: func(ctx context.Context, symbol string, price, amount float64, opts bitstamp.LimitOrderOpts) (*bitstamp.OrderResult, error) {
+			return &bitstamp.OrderResult{ID: 1, Price: price, Amount: amount}, nil
+		},
+	}
+	result, err := buyBelowLast(fake, fake, "btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ID != 1 || result.Price != 5000 || result.Amount != 0.1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	expected := []Call{
+		{Method: "GetTicker", Args: []interface{}{"btcusd"}},
+		{Method: "BuyLimitOrder", Args: []interface{}{"btcusd", 5000.0, 0.1, bitstamp.LimitOrderOpts{}}},
+	}
+	if calls := fake.Calls(); !reflect.DeepEqual(expected, calls) {
+		t.Errorf("expected %+v, got %+v", expected, calls)
+	}
+	if calls := fake.CallsTo("BuyLimitOrder"); !reflect.DeepEqual(expected[1:], calls) {
+		t.Errorf("expected %+v, got %+v", expected[1:], calls)
+	}
+	fake.Reset()
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+}
+
+func TestClientNotScripted(t *testing.T) {
+	var fake Client
+	if _, err := fake.GetAccountBalanceCtx(context.Background()); !errors.Is(err, ErrNotScripted) {
+		t.Errorf("expected ErrNotScripted, got %v", err)
+	}
+	if id, err := fake.WithdrawBTC("address", 1); id != 0 || !errors.Is(err, ErrNotScripted) {
+		t.Errorf("unexpected result %d, %v", id, err)
+	}
+	if calls := fake.CallsTo("WithdrawBTC"); len(calls) != 1 {
+		t.Errorf("expected 1 call, got %+v", calls)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	fake := &Client{
+		CancelOrderFunc: func(ctx context.Context, id int64) (*bitstamp.CanceledOrder, error) {
+			return nil, &bitstamp.APIError{StatusCode: 400, Reason: "Order not found"}
+		},
+	}
+	if _, err := fake.CancelOrder(1); !errors.Is(err, bitstamp.ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestClientConcurrentCalls(t *testing.T) {
+	fake := &Client{
+		GetOrderBookFunc: func(ctx context.Context, symbol string) (*bitstamp.OrderBook, error) {
+			return &bitstamp.OrderBook{}, nil
+		},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fake.GetOrderBook("btcusd")
+		}()
+	}
+	wg.Wait()
+	if calls := fake.CallsTo("GetOrderBook"); len(calls) != 10 {
+		t.Errorf("expected 10 calls, got %d", len(calls))
+	}
+}
@@ -0,0 +1,377 @@
+package bitstamptest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	datetimeLayout = "2006-01-02 15:04:05.000000"
+	// lastPrice is the price of the default ticker, at which market orders are filled.
+	lastPrice = "10000.00"
+)
+
+// quoteCurrencies are used to split symbols into currencies. Longer names go first.
+var quoteCurrencies = []string{"usdt", "usdc", "usd", "eur", "gbp", "btc", "eth"}
+
+// balances are the default balances by currency.
+var balances = map[string]string{
+	"usd": "10000.00",
+	"eur": "5000.00",
+	"btc": "1.00000000",
+	"eth": "10.00000000",
+}
+
+// handler emulates an endpoint. path is the request path without the /api/v2 prefix.
+type handler func(b *book, path string, form url.Values) (int, string)
+
+// route returns the handler of the endpoint at path, and whether it is private.
+func route(path string) (h handler, private bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case path == "/ticker/":
+		return allTickers, false
+	case len(parts) == 2 && (parts[0] == "ticker" || parts[0] == "ticker_hour"):
+		return ticker, false
+	case len(parts) == 2 && parts[0] == "order_book":
+		return orderBook, false
+	case len(parts) == 2 && parts[0] == "transactions":
+		return transactions, false
+	case path == "/balance/":
+		return accountBalance, true
+	case len(parts) == 2 && parts[0] == "balance":
+		return pairBalance, true
+	case len(parts) == 2 && parts[0] == "open_orders":
+		return openOrders, true
+	case len(parts) == 2 && (parts[0] == "buy" || parts[0] == "sell"):
+		return limitOrder, true
+	case len(parts) == 3 && (parts[0] == "buy" || parts[0] == "sell") && parts[1] == "market":
+		return marketOrder, true
+	case path == "/cancel_order/":
+		return cancelOrder, true
+	case len(parts) <= 2 && parts[0] == "cancel_all_orders":
+		return cancelAllOrders, true
+	case path == "/order_status/":
+		return orderStatus, true
+	}
+	return nil, false
+}
+
+// pairName converts a symbol, like btcusd, to a pair name, like BTC/USD.
+// It returns an empty string for unknown quote currencies.
+func pairName(symbol string) string {
+	for _, quote := range quoteCurrencies {
+		if len(symbol) > len(quote) && strings.HasSuffix(symbol, quote) {
+			base := symbol[:len(symbol)-len(quote)]
+			return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+		}
+	}
+	return ""
+}
+
+// symbolOf returns the last path element, like btcusd for /ticker/btcusd.
+// It returns an empty string for invalid symbols.
+func symbolOf(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	symbol := parts[len(parts)-1]
+	if pairName(symbol) == "" {
+		return ""
+	}
+	return symbol
+}
+
+func invalidPair() (int, string) {
+	return http.StatusNotFound, `{"error": "Invalid currency pair"}`
+}
+
+func tickerData(now time.Time) map[string]string {
+	return map[string]string{
+		"last":              lastPrice,
+		"high":              "10100.00",
+		"low":               "9900.00",
+		"vwap":              "10000.00",
+		"volume":            "100.00000000",
+		"bid":               "9999.00",
+		"ask":               "10001.00",
+		"open":              "9950.00",
+		"open_24":           "9950.00",
+		"percent_change_24": "0.50",
+		"side":              "0",
+		"timestamp":         strconv.FormatInt(now.Unix(), 10),
+	}
+}
+
+func ticker(b *book, path string, form url.Values) (int, string) {
+	if symbolOf(path) == "" {
+		return invalidPair()
+	}
+	return jsonResponse(tickerData(time.Now()))
+}
+
+func allTickers(b *book, path string, form url.Values) (int, string) {
+	var result []map[string]string
+	for _, symbol := range []string{"btcusd", "btceur", "ethusd"} {
+		t := tickerData(time.Now())
+		t["pair"] = pairName(symbol)
+		result = append(result, t)
+	}
+	return jsonResponse(result)
+}
+
+func orderBook(b *book, path string, form url.Values) (int, string) {
+	if symbolOf(path) == "" {
+		return invalidPair()
+	}
+	now := time.Now()
+	return jsonResponse(map[string]interface{}{
+		"timestamp":      strconv.FormatInt(now.Unix(), 10),
+		"microtimestamp": strconv.FormatInt(now.UnixNano()/int64(time.Microsecond), 10),
+		"bids":           [][]string{{"9999.00", "0.50000000"}, {"9998.00", "1.00000000"}, {"9997.00", "2.00000000"}},
+		"asks":           [][]string{{"10001.00", "0.50000000"}, {"10002.00", "1.00000000"}, {"10003.00", "2.00000000"}},
+	})
+}
+
+func transactions(b *book, path string, form url.Values) (int, string) {
+	if symbolOf(path) == "" {
+		return invalidPair()
+	}
+	now := time.Now().Unix()
+	var result []map[string]string
+	for i, price := range []string{lastPrice, "9999.00", "10001.00"} {
+		result = append(result, map[string]string{
+			"date":   strconv.FormatInt(now-int64(i), 10),
+			"tid":    strconv.Itoa(1000 - i),
+			"amount": "0.01000000",
+			"type":   strconv.Itoa(i % 2),
+			"price":  price,
+		})
+	}
+	return jsonResponse(result)
+}
+
+func accountBalance(b *book, path string, form url.Values) (int, string) {
+	result := make(map[string]string)
+	for currency, balance := range balances {
+		setBalance(result, currency, balance)
+	}
+	for _, symbol := range []string{"btcusd", "btceur", "ethusd"} {
+		result[symbol+"_fee"] = "0.500"
+	}
+	return jsonResponse(result)
+}
+
+func pairBalance(b *book, path string, form url.Values) (int, string) {
+	symbol := symbolOf(path)
+	if symbol == "" {
+		return invalidPair()
+	}
+	result := map[string]string{"fee": "0.500"}
+	for _, currency := range strings.Split(strings.ToLower(pairName(symbol)), "/") {
+		setBalance(result, currency, balances[currency])
+	}
+	return jsonResponse(result)
+}
+
+// setBalance sets the balance fields of a currency. Balances are never reserved.
+func setBalance(result map[string]string, currency, balance string) {
+	if balance == "" {
+		balance = "0.00000000"
+	}
+	result[currency+"_balance"] = balance
+	result[currency+"_available"] = balance
+	result[currency+"_reserved"] = "0.00000000"
+}
+
+// book keeps the orders placed on the server.
+type book struct {
+	lastID int64
+	orders map[int64]*order
+}
+
+type order struct {
+	id            int64
+	time          time.Time
+	typ           int
+	symbol        string
+	price         string
+	amount        string
+	clientOrderID string
+	status        string
+}
+
+func newBook() *book {
+	return &book{orders: make(map[int64]*order)}
+}
+
+// place adds an order with the given status.
+func (b *book) place(path string, form url.Values, price, status string) (int, string) {
+	symbol := symbolOf(path)
+	if symbol == "" {
+		return invalidPair()
+	}
+	amount := form.Get("amount")
+	if v, err := strconv.ParseFloat(amount, 64); err != nil || v <= 0 {
+		return errorResponse(http.StatusBadRequest, "", "Invalid amount")
+	}
+	typ := 0
+	if strings.HasPrefix(path, "/sell/") {
+		typ = 1
+	}
+	b.lastID++
+	o := &order{
+		id:            b.lastID,
+		time:          time.Now().UTC(),
+		typ:           typ,
+		symbol:        symbol,
+		price:         price,
+		amount:        amount,
+		clientOrderID: form.Get("client_order_id"),
+		status:        status,
+	}
+	b.orders[o.id] = o
+	result := map[string]string{
+		"id":       strconv.FormatInt(o.id, 10),
+		"datetime": o.time.Format(datetimeLayout),
+		"type":     strconv.Itoa(o.typ),
+		"price":    o.price,
+		"amount":   o.amount,
+	}
+	if o.clientOrderID != "" {
+		result["client_order_id"] = o.clientOrderID
+	}
+	return jsonResponse(result)
+}
+
+// open returns the open orders of the symbol, or of all symbols, if it is empty, ordered by id.
+func (b *book) open(symbol string) []*order {
+	var result []*order
+	for _, o := range b.orders {
+		if o.status == "Open" && (symbol == "" || o.symbol == symbol) {
+			result = append(result, o)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result
+}
+
+func limitOrder(b *book, path string, form url.Values) (int, string) {
+	price := form.Get("price")
+	if v, err := strconv.ParseFloat(price, 64); err != nil || v <= 0 {
+		return errorResponse(http.StatusBadRequest, "", "Invalid price")
+	}
+	return b.place(path, form, price, "Open")
+}
+
+func marketOrder(b *book, path string, form url.Values) (int, string) {
+	return b.place(path, form, lastPrice, "Finished")
+}
+
+func openOrders(b *book, path string, form url.Values) (int, string) {
+	symbol := symbolOf(path)
+	if symbol == "" && !strings.HasSuffix(path, "/all/") {
+		return invalidPair()
+	}
+	result := []map[string]string{}
+	for _, o := range b.open(symbol) {
+		result = append(result, map[string]string{
+			"id":              strconv.FormatInt(o.id, 10),
+			"datetime":        o.time.Format(datetimeLayout),
+			"type":            strconv.Itoa(o.typ),
+			"price":           o.price,
+			"amount":          o.amount,
+			"currency_pair":   pairName(o.symbol),
+			"client_order_id": o.clientOrderID,
+		})
+	}
+	return jsonResponse(result)
+}
+
+func canceledOrder(o *order) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            o.id,
+		"type":          o.typ,
+		"price":         o.price,
+		"amount":        o.amount,
+		"currency_pair": pairName(o.symbol),
+	}
+}
+
+func orderNotFound() (int, string) {
+	return errorResponse(http.StatusBadRequest, "API0014", "Order not found")
+}
+
+func cancelOrder(b *book, path string, form url.Values) (int, string) {
+	id, _ := strconv.ParseInt(form.Get("id"), 10, 64)
+	o, found := b.orders[id]
+	if !found || o.status != "Open" {
+		return orderNotFound()
+	}
+	o.status = "Canceled"
+	return jsonResponse(canceledOrder(o))
+}
+
+func cancelAllOrders(b *book, path string, form url.Values) (int, string) {
+	var symbol string
+	if path != "/cancel_all_orders/" {
+		if symbol = symbolOf(path); symbol == "" {
+			return invalidPair()
+		}
+	}
+	canceled := []map[string]interface{}{}
+	for _, o := range b.open(symbol) {
+		o.status = "Canceled"
+		canceled = append(canceled, canceledOrder(o))
+	}
+	return jsonResponse(map[string]interface{}{
+		"success":      true,
+		"canceled":     canceled,
+		"not_canceled": []interface{}{},
+	})
+}
+
+func orderStatus(b *book, path string, form url.Values) (int, string) {
+	var o *order
+	if id := form.Get("id"); id != "" {
+		n, _ := strconv.ParseInt(id, 10, 64)
+		o = b.orders[n]
+	} else if clientOrderID := form.Get("client_order_id"); clientOrderID != "" {
+		for _, candidate := range b.orders {
+			if candidate.clientOrderID == clientOrderID {
+				o = candidate
+			}
+		}
+	}
+	if o == nil {
+		return orderNotFound()
+	}
+	remaining, fills := o.amount, []interface{}{}
+	if o.status == "Finished" {
+		remaining = "0.00000000"
+		currencies := strings.Split(strings.ToLower(pairName(o.symbol)), "/")
+		price, _ := strconv.ParseFloat(o.price, 64)
+		amount, _ := strconv.ParseFloat(o.amount, 64)
+		fills = append(fills, map[string]interface{}{
+			"tid":         o.id,
+			"price":       o.price,
+			"datetime":    o.time.Format(datetimeLayout),
+			"fee":         "0.00",
+			"type":        2,
+			currencies[0]: o.amount,
+			currencies[1]: strconv.FormatFloat(price*amount, 'f', -1, 64),
+		})
+	}
+	return jsonResponse(map[string]interface{}{
+		"id":               o.id,
+		"datetime":         o.time.Format(datetimeLayout),
+		"type":             strconv.Itoa(o.typ),
+		"status":           o.status,
+		"market":           pairName(o.symbol),
+		"amount_remaining": remaining,
+		"client_order_id":  o.clientOrderID,
+		"transactions":     fills,
+	})
+}
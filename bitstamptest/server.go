@@ -0,0 +1,343 @@
+// Package bitstamptest provides a fake Bitstamp rest api for integration tests.
+//
+// The server emulates the public ticker, order book and transactions endpoints,
+// and the signed balance and order endpoints. Signed requests are verified
+// against the key and the secret of the server, and the responses to them are signed,
+// so that clients may check response signatures too.
+//
+//	srv := bitstamptest.NewServer()
+//	defer srv.Close()
+//	api, err := bitstamp.NewClient(
+//		bitstamp.WithBaseURL(srv.BaseURL()),
+//		bitstamp.WithCredentials(srv.Key(), srv.Secret()),
+//	)
+package bitstamptest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKey and DefaultSecret are the credentials of a server created without WithCredentials.
+	DefaultKey    = "bitstamptestkey"
+	DefaultSecret = "bitstamptestsecret"
+
+	apiPrefix   = "/api/v2"
+	authPrefix  = "BITSTAMP "
+	authVersion = "v2"
+	contentType = "application/json"
+)
+
+// Server is a fake Bitstamp rest api. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	key, secret string
+
+	mu sync.Mutex
+	// responses are the canned responses by path.
+	responses map[string]response
+	faults    []*Fault
+	requests  []Request
+	book      *book
+}
+
+// response is a canned response.
+type response struct {
+	code int
+	body string
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	// Path is the path without the /api/v2 prefix, like /balance/.
+	Path string
+	// Form are the query and the body parameters.
+	Form url.Values
+	// Signed is set if the request had valid authentication headers.
+	Signed bool
+}
+
+// Option is an option of NewServer.
+type Option func(s *Server)
+
+// WithCredentials sets the api key and secret of the signed requests.
+func WithCredentials(key, secret string) Option {
+	return func(s *Server) {
+		s.key, s.secret = key, secret
+	}
+}
+
+// NewServer starts a server with the default data: a ticker and an order book for any symbol,
+// a few trades, balances of usd, btc and eth, and no open orders.
+// The server must be closed by the caller.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		key:       DefaultKey,
+		secret:    DefaultSecret,
+		responses: make(map[string]response),
+		book:      newBook(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// BaseURL returns the url of the rest api, which is passed to bitstamp.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + apiPrefix
+}
+
+// Key returns the api key of the server.
+func (s *Server) Key() string {
+	return s.key
+}
+
+// Secret returns the api secret of the server.
+func (s *Server) Secret() string {
+	return s.secret
+}
+
+// SetResponse seeds a canned response of the endpoint at path, like /ticker/btcusd.
+// It is returned instead of the emulated one. Signed requests are still verified.
+func (s *Server) SetResponse(path string, code int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[path] = response{code: code, body: body}
+}
+
+// ClearResponses removes the canned responses.
+func (s *Server) ClearResponses() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = make(map[string]response)
+}
+
+// Requests returns the received requests in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", "invalid body")
+		return
+	}
+	for k, v := range r.URL.Query() {
+		form[k] = append(form[k], v...)
+	}
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	signed := r.Header.Get("X-Auth") != ""
+	var authErr *apiError
+	if signed {
+		authErr = s.verify(r, body)
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Form: form, Signed: signed && authErr == nil})
+	fault := s.takeFault(path)
+	s.mu.Unlock()
+
+	if fault != nil && !fault.delay(r) {
+		return
+	}
+	if fault != nil && fault.Status != 0 {
+		fault.write(w)
+		return
+	}
+	code, respBody := s.respond(r.Method, path, form, signed, authErr)
+	if fault != nil && fault.MalformedJSON {
+		respBody = respBody[:len(respBody)/2]
+	}
+	w.Header().Set("Content-Type", contentType)
+	if signed && authErr == nil && code < 300 {
+		message := r.Header.Get("X-Auth-Nonce") + r.Header.Get("X-Auth-Timestamp") + contentType + respBody
+		w.Header().Set("X-Server-Auth-Signature", sign(s.secret, message))
+	}
+	w.WriteHeader(code)
+	w.Write([]byte(respBody))
+}
+
+// respond returns the response to a request.
+func (s *Server) respond(method, path string, form url.Values, signed bool, authErr *apiError) (int, string) {
+	h, private := route(path)
+	if private {
+		if !signed {
+			return errorResponse(http.StatusForbidden, "API0001", "Missing authentication headers")
+		}
+		if authErr != nil {
+			return errorResponse(http.StatusForbidden, authErr.code, authErr.reason)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resp, found := s.responses[path]; found {
+		return resp.code, resp.body
+	}
+	if h == nil {
+		return errorResponse(http.StatusNotFound, "", "Not found")
+	}
+	if private && method != http.MethodPost {
+		return errorResponse(http.StatusMethodNotAllowed, "", "Method not allowed")
+	}
+	return h(s.book, path, form)
+}
+
+// apiError is an error response of the api.
+type apiError struct {
+	code   string
+	reason string
+}
+
+// verify checks the authentication headers of a signed request.
+func (s *Server) verify(r *http.Request, body []byte) *apiError {
+	if r.Header.Get("X-Auth") != authPrefix+s.key {
+		return &apiError{code: "API0001", reason: "API key not found"}
+	}
+	nonce, timestamp := r.Header.Get("X-Auth-Nonce"), r.Header.Get("X-Auth-Timestamp")
+	if r.Header.Get("X-Auth-Version") != authVersion || nonce == "" || timestamp == "" {
+		return &apiError{code: "API0005", reason: "Missing authentication headers"}
+	}
+	var query string
+	if r.URL.RawQuery != "" {
+		query = "?" + r.URL.RawQuery
+	}
+	message := r.Header.Get("X-Auth") + r.Method + r.Host + r.URL.Path + query +
+		r.Header.Get("Content-Type") + nonce + timestamp + authVersion + string(body)
+	expected := sign(s.secret, message)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(r.Header.Get("X-Auth-Signature")))) {
+		return &apiError{code: "API0005", reason: "Invalid signature"}
+	}
+	return nil
+}
+
+// sign returns a hex encoded HMAC-SHA256 of the message.
+func sign(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func errorResponse(code int, apiCode, reason string) (int, string) {
+	resp := map[string]string{"status": "error", "reason": reason}
+	if apiCode != "" {
+		resp["code"] = apiCode
+	}
+	data, _ := json.Marshal(resp)
+	return code, string(data)
+}
+
+func writeError(w http.ResponseWriter, code int, apiCode, reason string) {
+	code, body := errorResponse(code, apiCode, reason)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write([]byte(body))
+}
+
+func jsonResponse(v interface{}) (int, string) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "", err.Error())
+	}
+	return http.StatusOK, string(data)
+}
+
+// Fault is an injected failure of requests.
+type Fault struct {
+	// Path limits the fault to the endpoint at path, like /balance/. If empty, all requests fail.
+	Path string
+	// Count is the number of failed requests. If zero, the fault is permanent.
+	Count int
+	// Latency delays the responses.
+	Latency time.Duration
+	// Status, if set, is the status of an error response sent instead of the real one.
+	// 429 responses have the body of the rate limit error.
+	Status int
+	// RetryAfter, if set, is sent in the Retry-After header of the error response.
+	RetryAfter time.Duration
+	// MalformedJSON truncates the response bodies.
+	MalformedJSON bool
+}
+
+// InjectFault adds a fault. Faults are matched in order, and a request is affected by one fault at most.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes the injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault returns the fault affecting a request to path, if any.
+func (s *Server) takeFault(path string) *Fault {
+	for i, f := range s.faults {
+		if f.Path != "" && f.Path != path {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// delay waits for the latency of the fault. It returns false if the request was canceled.
+func (f *Fault) delay(r *http.Request) bool {
+	if f.Latency <= 0 {
+		return true
+	}
+	t := time.NewTimer(f.Latency)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (f *Fault) write(w http.ResponseWriter) {
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((f.RetryAfter+time.Second-1)/time.Second)))
+	}
+	code, body := errorResponse(f.Status, "", http.StatusText(f.Status))
+	if f.Status == http.StatusTooManyRequests {
+		code, body = errorResponse(f.Status, "API0023", "Rate limit exceeded. Please retry later.")
+	}
+	if f.MalformedJSON {
+		body = body[:len(body)/2]
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write([]byte(body))
+}
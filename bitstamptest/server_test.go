@@ -0,0 +1,282 @@
+package bitstamptest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+	"github.com/avdva/bitstamp-go/bitstamptest"
+	"github.com/pkg/errors"
+)
+
+func newClient(t *testing.T, srv *bitstamptest.Server, opts ...bitstamp.Option) *bitstamp.Api {
+	t.Helper()
+	opts = append([]bitstamp.Option{
+		bitstamp.WithBaseURL(srv.BaseURL()),
+		bitstamp.WithCredentials(srv.Key(), srv.Secret()),
+	}, opts...)
+	api, err := bitstamp.NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.VerifyResponses = true
+	return api
+}
+
+func TestPublicEndpoints(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	api := newClient(t, srv)
+
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last != 10000 || ticker.Bid >= ticker.Ask || ticker.Time.IsZero() {
+		t.Errorf("unexpected ticker %+v", ticker)
+	}
+	tickers, err := api.GetAllTickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := tickers["ethusd"]; !found || len(tickers) != 3 {
+		t.Errorf("unexpected tickers %+v", tickers)
+	}
+	book, err := api.GetOrderBook("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 || book.Bids[0].Price >= book.Asks[0].Price {
+		t.Errorf("unexpected book %+v", book)
+	}
+	trades, err := api.GetTrades("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 3 || trades[0].Price != 10000 {
+		t.Errorf("unexpected trades %+v", trades)
+	}
+	if _, err := api.GetTicker("btcxyz"); err == nil {
+		t.Error("error expected for an invalid pair")
+	}
+}
+
+func TestSignedEndpoints(t *testing.T) {
+	srv := bitstamptest.NewServer(bitstamptest.WithCredentials("key", "secret"))
+	defer srv.Close()
+	api := newClient(t, srv)
+
+	balances, err := api.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if btc := balances.Get("btc"); btc.Available != 1 || btc.Total != 1 {
+		t.Errorf("unexpected btc balance %+v", btc)
+	}
+	if fee, found := balances.Fee("btcusd"); !found || fee != 0.5 {
+		t.Errorf("unexpected fee %v", fee)
+	}
+	pair, err := api.GetPairBalance("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.BaseAvailable != 1 || pair.QuoteAvailable != 10000 {
+		t.Errorf("unexpected pair balance %+v", pair)
+	}
+
+	for _, r := range srv.Requests() {
+		if !r.Signed {
+			t.Errorf("%s: request is not signed", r.Path)
+		}
+	}
+}
+
+func TestOrders(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	api := newClient(t, srv)
+
+	first, err := api.BuyLimitOrder("btcusd", 9000, 0.1, bitstamp.LimitOrderOpts{ClientOrderID: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Price != 9000 || first.Amount != 0.1 || first.Type != bitstamp.OrderBuy || first.ClientOrderID != "first" {
+		t.Errorf("unexpected order %+v", first)
+	}
+	second, err := api.SellLimitOrder("ethusd", 300, 2, bitstamp.LimitOrderOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := api.GetOpenOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 || orders[0].ID != first.ID || orders[1].ID != second.ID || orders[1].Symbol != "ethusd" {
+		t.Errorf("unexpected open orders %+v", orders)
+	}
+	status, err := api.GetOrderStatusParams(bitstamp.OrderStatusParams{ClientOrderID: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ID != first.ID || status.Status != bitstamp.OrderStatusOpen || status.AmountRemaining != 0.1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	canceled, err := api.CancelOrder(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.ID != first.ID || canceled.Amount != 0.1 || canceled.Symbol != "btcusd" {
+		t.Errorf("unexpected canceled order %+v", canceled)
+	}
+	if _, err := api.CancelOrder(first.ID); !errors.Is(err, bitstamp.ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound, got %v", err)
+	}
+	all, err := api.CancelAllOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Canceled) != 1 || all.Canceled[0].ID != second.ID {
+		t.Errorf("unexpected result %+v", all)
+	}
+	if orders, err := api.GetOpenOrders(); err != nil || len(orders) != 0 {
+		t.Errorf("unexpected open orders %+v, %v", orders, err)
+	}
+
+	market, err := api.BuyMarketOrder("btcusd", 0.5, bitstamp.MarketOrderOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = api.GetOrderStatus(market.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != bitstamp.OrderStatusFinished || len(status.Fills) != 1 || status.Fills[0].Amount != 0.5 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestSignatureVerification(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+
+	api := newClient(t, srv, bitstamp.WithCredentials(srv.Key(), "wrongsecret"))
+	if _, err := api.GetAccountBalance(); !errors.Is(err, bitstamp.ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication, got %v", err)
+	}
+	api = newClient(t, srv, bitstamp.WithCredentials("wrongkey", srv.Secret()))
+	if _, err := api.GetAccountBalance(); !errors.Is(err, bitstamp.ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication, got %v", err)
+	}
+	resp, err := http.Post(srv.BaseURL()+"/balance/", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for an unsigned request, got %d", resp.StatusCode)
+	}
+	for _, r := range srv.Requests() {
+		if r.Signed {
+			t.Errorf("%s: unexpected signed request", r.Path)
+		}
+	}
+}
+
+func TestCannedResponses(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	api := newClient(t, srv)
+
+	srv.SetResponse("/ticker/btcusd", http.StatusOK, `{"last": "123.45", "timestamp": "1567755304"}`)
+	srv.SetResponse("/balance/", http.StatusOK, `{"btc_available": "0.5", "btc_balance": "0.5", "btc_reserved": "0"}`)
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last != 123.45 {
+		t.Errorf("unexpected ticker %+v", ticker)
+	}
+	balances, err := api.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Get("btc").Available != 0.5 {
+		t.Errorf("unexpected balances %+v", balances)
+	}
+	// canned responses of the signed endpoints still require valid signatures.
+	invalid := newClient(t, srv, bitstamp.WithCredentials(srv.Key(), "wrongsecret"))
+	if _, err := invalid.GetAccountBalance(); !errors.Is(err, bitstamp.ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication, got %v", err)
+	}
+
+	srv.ClearResponses()
+	if ticker, err := api.GetTicker("btcusd"); err != nil || ticker.Last != 10000 {
+		t.Errorf("unexpected ticker %+v, %v", ticker, err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	api := newClient(t, srv)
+
+	srv.InjectFault(bitstamptest.Fault{Path: "/ticker/btcusd", Count: 1, Status: http.StatusTooManyRequests, RetryAfter: time.Second})
+	_, err := api.GetTicker("btcusd")
+	var rateLimitErr *bitstamp.RateLimitError
+	if !errors.Is(err, bitstamp.ErrRateLimited) || !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != time.Second {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Errorf("the fault must be removed after one request, got %v", err)
+	}
+
+	srv.InjectFault(bitstamptest.Fault{Path: "/balance/", MalformedJSON: true})
+	if _, err := api.GetAccountBalance(); err == nil {
+		t.Error("error expected for a malformed response")
+	}
+	if _, err := api.GetOrderBook("btcusd"); err != nil {
+		t.Errorf("faults must affect only their paths, got %v", err)
+	}
+	srv.ClearFaults()
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	srv.InjectFault(bitstamptest.Fault{Latency: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := api.GetTickerCtx(ctx, "btcusd"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	resp, err := http.Get(srv.BaseURL() + "/unknown/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "Not found") {
+		t.Errorf("unexpected response %d %s", resp.StatusCode, body)
+	}
+	api := newClient(t, srv)
+	if _, err := api.SellLimitOrder("btcusd", 10000, 0.25, bitstamp.LimitOrderOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	requests := srv.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %+v", requests)
+	}
+	last := requests[1]
+	if last.Method != http.MethodPost || last.Path != "/sell/btcusd/" || last.Form.Get("amount") != "0.25" || last.Form.Get("price") != "10000" {
+		t.Errorf("unexpected request %+v", last)
+	}
+}
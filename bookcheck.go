@@ -0,0 +1,132 @@
+package bitstamp
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// BookValidation is a way of checking the order books returned by GetOrderBook,
+// GetOrderBookDepth, GetOrderBookDecimal and the order book subscriptions.
+type BookValidation int
+
+const (
+	// BookValidationNone returns the books as they were sent by the api. It is the default.
+	BookValidationNone BookValidation = iota
+	// BookValidationStrict rejects unsorted and crossed books.
+	BookValidationStrict
+	// BookValidationSort sorts the levels of unsorted books, and rejects crossed books.
+	BookValidationSort
+)
+
+// Crossed reports whether the best bid is not below the best ask.
+// Books with an empty side are never crossed.
+func (b *OrderBook) Crossed() bool {
+	return len(b.Bids) > 0 && len(b.Asks) > 0 && b.Bids[0].Price >= b.Asks[0].Price
+}
+
+// Validate checks, that the bids are sorted by descending prices, the asks are sorted
+// by ascending prices, and the book is not crossed. It returns an error matching
+// either ErrUnsortedBook or ErrCrossedBook.
+func (b *OrderBook) Validate() error {
+	if i := unsortedLevel(b.Bids, true); i > 0 {
+		return errors.Wrapf(ErrUnsortedBook, "bid %d (%v) is above bid %d (%v)", i, b.Bids[i].Price, i-1, b.Bids[i-1].Price)
+	}
+	if i := unsortedLevel(b.Asks, false); i > 0 {
+		return errors.Wrapf(ErrUnsortedBook, "ask %d (%v) is below ask %d (%v)", i, b.Asks[i].Price, i-1, b.Asks[i-1].Price)
+	}
+	if b.Crossed() {
+		return errors.Wrapf(ErrCrossedBook, "best bid %v, best ask %v", b.Bids[0].Price, b.Asks[0].Price)
+	}
+	return nil
+}
+
+// Sort sorts the bids by descending prices, and the asks by ascending prices.
+// Levels with equal prices keep their order.
+func (b *OrderBook) Sort() {
+	sort.SliceStable(b.Bids, func(i, j int) bool { return b.Bids[i].Price > b.Bids[j].Price })
+	sort.SliceStable(b.Asks, func(i, j int) bool { return b.Asks[i].Price < b.Asks[j].Price })
+}
+
+func (b *OrderBook) sorted() bool {
+	return unsortedLevel(b.Bids, true) == 0 && unsortedLevel(b.Asks, false) == 0
+}
+
+// Crossed is like OrderBook.Crossed.
+func (b *DecimalOrderBook) Crossed() bool {
+	return len(b.Bids) > 0 && len(b.Asks) > 0 && b.Bids[0].Price.Cmp(b.Asks[0].Price) >= 0
+}
+
+// Validate is like OrderBook.Validate.
+func (b *DecimalOrderBook) Validate() error {
+	if i := unsortedDecimalLevel(b.Bids, true); i > 0 {
+		return errors.Wrapf(ErrUnsortedBook, "bid %d (%v) is above bid %d (%v)", i, b.Bids[i].Price, i-1, b.Bids[i-1].Price)
+	}
+	if i := unsortedDecimalLevel(b.Asks, false); i > 0 {
+		return errors.Wrapf(ErrUnsortedBook, "ask %d (%v) is below ask %d (%v)", i, b.Asks[i].Price, i-1, b.Asks[i-1].Price)
+	}
+	if b.Crossed() {
+		return errors.Wrapf(ErrCrossedBook, "best bid %v, best ask %v", b.Bids[0].Price, b.Asks[0].Price)
+	}
+	return nil
+}
+
+// Sort is like OrderBook.Sort.
+func (b *DecimalOrderBook) Sort() {
+	sort.SliceStable(b.Bids, func(i, j int) bool { return b.Bids[i].Price.Cmp(b.Bids[j].Price) > 0 })
+	sort.SliceStable(b.Asks, func(i, j int) bool { return b.Asks[i].Price.Cmp(b.Asks[j].Price) < 0 })
+}
+
+func (b *DecimalOrderBook) sorted() bool {
+	return unsortedDecimalLevel(b.Bids, true) == 0 && unsortedDecimalLevel(b.Asks, false) == 0
+}
+
+// unsortedLevel returns the index of the first level out of order, or 0, if the levels are sorted.
+func unsortedLevel(levels []Order, descending bool) int {
+	return unsortedIndex(len(levels), descending, func(i, j int) int {
+		switch {
+		case levels[i].Price < levels[j].Price:
+			return -1
+		case levels[i].Price > levels[j].Price:
+			return 1
+		}
+		return 0
+	})
+}
+
+// unsortedDecimalLevel is like unsortedLevel.
+func unsortedDecimalLevel(levels []DecimalOrder, descending bool) int {
+	return unsortedIndex(len(levels), descending, func(i, j int) int { return levels[i].Price.Cmp(levels[j].Price) })
+}
+
+// unsortedIndex returns the index of the first of n levels out of order, or 0, if the levels are sorted.
+// cmp compares the prices of two levels.
+func unsortedIndex(n int, descending bool, cmp func(i, j int) int) int {
+	for i := 1; i < n; i++ {
+		if c := cmp(i, i-1); descending && c > 0 || !descending && c < 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// checkedBook is an order book, which can be validated and sorted.
+type checkedBook interface {
+	Validate() error
+	Sort()
+	sorted() bool
+}
+
+// checkBook validates a parsed float or decimal book according to api.BookValidation.
+func (api *Api) checkBook(book checkedBook) error {
+	switch api.BookValidation {
+	case BookValidationStrict:
+		return book.Validate()
+	case BookValidationSort:
+		if !book.sorted() {
+			book.Sort()
+		}
+		return book.Validate()
+	}
+	return nil
+}
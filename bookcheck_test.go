@@ -0,0 +1,139 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestOrderBookValidate(t *testing.T) {
+	sorted := &OrderBook{
+		Bids: []Order{{Price: 10453, Amount: 0.5}, {Price: 10452.5, Amount: 0.1}, {Price: 10452.5, Amount: 1.2}},
+		Asks: []Order{{Price: 10455.51, Amount: 0.02}, {Price: 10456, Amount: 3}},
+	}
+	if err := sorted.Validate(); err != nil || sorted.Crossed() {
+		t.Errorf("unexpected error %v", err)
+	}
+	tests := []struct {
+		book     *OrderBook
+		expected error
+	}{
+		{book: &OrderBook{Bids: []Order{{Price: 1}, {Price: 2}}}, expected: ErrUnsortedBook},
+		{book: &OrderBook{Asks: []Order{{Price: 2}, {Price: 1}}}, expected: ErrUnsortedBook},
+		{book: &OrderBook{Bids: []Order{{Price: 2}}, Asks: []Order{{Price: 1}}}, expected: ErrCrossedBook},
+		{book: &OrderBook{Bids: []Order{{Price: 2}}, Asks: []Order{{Price: 2}}}, expected: ErrCrossedBook},
+	}
+	for _, test := range tests {
+		if err := test.book.Validate(); !errors.Is(err, test.expected) {
+			t.Errorf("%+v: expected %v, got %v", test.book, test.expected, err)
+		}
+	}
+	if (&OrderBook{Bids: []Order{{Price: 2}}}).Crossed() {
+		t.Error("a book with an empty side can't be crossed")
+	}
+}
+
+func TestOrderBookSort(t *testing.T) {
+	book, err := New("", "").parseOrderBook([]byte(readFixture(t, "testdata/order_book_shuffled.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Validate(); !errors.Is(err, ErrUnsortedBook) {
+		t.Errorf("expected ErrUnsortedBook, got %v", err)
+	}
+	book.Sort()
+	expected := &OrderBook{
+		Time: book.Time,
+		Bids: []Order{{Price: 10453, Amount: 0.5}, {Price: 10452.5, Amount: 0.1}, {Price: 10452.5, Amount: 1.2}, {Price: 10450, Amount: 2}},
+		Asks: []Order{{Price: 10455.51, Amount: 0.02}, {Price: 10456, Amount: 3}, {Price: 10460, Amount: 0.75}},
+	}
+	if !reflect.DeepEqual(expected, book) {
+		t.Errorf("expected %+v, got %+v", expected, book)
+	}
+}
+
+func TestGetOrderBookValidation(t *testing.T) {
+	fixtures := map[string]string{
+		"btcusd": readFixture(t, "testdata/order_book_shuffled.json"),
+		"ethusd": readFixture(t, "testdata/order_book_crossed.json"),
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/order_book/btcusd": func(url.Values) (int, string) { return 200, fixtures["btcusd"] },
+		"/order_book/ethusd": func(url.Values) (int, string) { return 200, fixtures["ethusd"] },
+	})
+	defer restore()
+	tests := []struct {
+		validation BookValidation
+		// expected are the errors for the shuffled and the crossed books.
+		shuffled, crossed error
+	}{
+		{validation: BookValidationNone},
+		{validation: BookValidationStrict, shuffled: ErrUnsortedBook, crossed: ErrCrossedBook},
+		{validation: BookValidationSort, crossed: ErrCrossedBook},
+	}
+	for _, test := range tests {
+		api, err := NewClient(WithBookValidation(test.validation))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for symbol, expected := range map[string]error{"btcusd": test.shuffled, "ethusd": test.crossed} {
+			book, err := api.GetOrderBook(symbol)
+			if expected == nil && err != nil || expected != nil && !errors.Is(err, expected) {
+				t.Errorf("validation %d, %s: expected %v, got %v", test.validation, symbol, expected, err)
+			}
+			if _, depthErr := api.GetOrderBookDepth(symbol, 2); expected == nil && depthErr != nil || expected != nil && !errors.Is(depthErr, expected) {
+				t.Errorf("validation %d, %s, depth 2: expected %v, got %v", test.validation, symbol, expected, depthErr)
+			}
+			if err == nil && test.validation == BookValidationSort && book.Validate() != nil {
+				t.Errorf("%s: the book was not sorted: %+v", symbol, book)
+			}
+		}
+	}
+	if _, err := NewClient(WithBookValidation(BookValidation(10))); err == nil {
+		t.Error("error expected for an invalid validation")
+	}
+}
+
+func TestSubscribeOrderBookValidation(t *testing.T) {
+	event := func(book string) []byte {
+		return []byte(`{"event": "data", "channel": "order_book_btcusd", "data": ` + strings.TrimSpace(book) + `}`)
+	}
+	valid := `{"timestamp": "1567755304", "bids": [["10453.00", "0.5"]], "asks": [["10455.51", "0.02"]]}`
+	messages := [][]byte{event(valid), event(readFixture(t, "testdata/order_book_crossed.json")), event(valid)}
+	srv := orderBookStreamServer(t, "order_book_btcusd", messages)
+	defer srv.Close()
+	logger := &recordingLogger{}
+	api, err := NewClient(WithBookValidation(BookValidationStrict), WithLogger(logger), WithWebsocketURL("ws"+strings.TrimPrefix(srv.URL, "http")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataChan := make(chan OrderBook, len(messages))
+	if err := api.SubscribeOrderBook("btcusd", dataChan, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(dataChan) != 2 {
+		t.Errorf("expected the crossed book to be dropped, got %d books", len(dataChan))
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	var logged bool
+	for _, m := range logger.messages {
+		logged = logged || strings.HasPrefix(m, "error: order book btcusd: ") && strings.HasSuffix(m, ": crossed order book")
+	}
+	if !logged {
+		t.Errorf("expected the crossed book to be logged, got %q", logger.messages)
+	}
+}
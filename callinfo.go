@@ -0,0 +1,51 @@
+package bitstamp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CallInfo describes the http exchange of a rest call.
+// It is filled by the calls made with a context returned by ContextWithCallInfo.
+// If the request was retried, the fields describe the last attempt.
+type CallInfo struct {
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	// Header are the response headers, like Date or Retry-After.
+	Header http.Header
+	// BodySize is the size of the response body after decompression.
+	BodySize int
+	// Duration is the time from sending the request to reading the response body.
+	Duration time.Duration
+	// Attempts is the number of sent requests, including retries.
+	Attempts int
+}
+
+// Date returns the value of the Date response header, or zero time, if it is missing or invalid.
+func (info *CallInfo) Date() time.Time {
+	t, _ := http.ParseTime(info.Header.Get("Date"))
+	return t
+}
+
+type callInfoKey struct{}
+
+// ContextWithCallInfo returns a context, which makes the rest calls made with it fill info.
+// info must not be shared by concurrent calls.
+func ContextWithCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// setCallInfo fills the CallInfo of the context, if any. resp may be nil.
+func setCallInfo(ctx context.Context, resp *http.Response, body []byte, latency time.Duration) {
+	info, ok := ctx.Value(callInfoKey{}).(*CallInfo)
+	if !ok || info == nil {
+		return
+	}
+	info.Attempts++
+	info.StatusCode, info.Header = 0, nil
+	if resp != nil {
+		info.StatusCode, info.Header = resp.StatusCode, resp.Header.Clone()
+	}
+	info.BodySize, info.Duration = len(body), latency
+}
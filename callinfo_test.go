@@ -0,0 +1,98 @@
+package bitstamp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCallInfo(t *testing.T) {
+	date := time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC)
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, `{"last": "10455.51"}`
+		},
+		"/balance/": func(url.Values) (int, string) {
+			return 200, `{"btc_available": "1.0"}`
+		},
+	})
+	defer restore()
+	fake.header = func(*http.Request, string) http.Header {
+		return http.Header{"Date": {date.Format(http.TimeFormat)}, "X-Ratelimit-Remaining": {"7999"}}
+	}
+	api := NewWithKey("key", "secret")
+
+	var info CallInfo
+	if _, err := api.GetTickerCtx(ContextWithCallInfo(context.Background(), &info), "btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if info.StatusCode != 200 || info.BodySize != len(`{"last": "10455.51"}`) || info.Attempts != 1 || info.Duration <= 0 {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if !info.Date().Equal(date) || info.Header.Get("X-Ratelimit-Remaining") != "7999" {
+		t.Errorf("unexpected headers %v", info.Header)
+	}
+
+	var signed CallInfo
+	if _, err := api.GetAccountBalanceCtx(ContextWithCallInfo(context.Background(), &signed)); err != nil {
+		t.Fatal(err)
+	}
+	if signed.StatusCode != 200 || signed.BodySize != len(`{"btc_available": "1.0"}`) || signed.Attempts != 1 || !signed.Date().Equal(date) {
+		t.Errorf("unexpected info %+v", signed)
+	}
+
+	// calls without info, or with a nil one, work as usual.
+	if _, err := api.GetTickerCtx(ContextWithCallInfo(context.Background(), nil), "btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCallInfoRetries(t *testing.T) {
+	var calls int
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			calls++
+			if calls == 1 {
+				return 429, `{"status": "error", "reason": "Too many requests"}`
+			}
+			return 200, `{"last": "1"}`
+		},
+	})
+	defer restore()
+	fake.header = func(*http.Request, string) http.Header {
+		return http.Header{"Retry-After": {"1"}}
+	}
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api, err := NewClient(WithRateLimitRetry(5 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info CallInfo
+	if _, err := api.GetTickerCtx(ContextWithCallInfo(context.Background(), &info), "btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if info.Attempts != 2 || info.StatusCode != 200 || info.BodySize != len(`{"last": "1"}`) {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestCallInfoTransportError(t *testing.T) {
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))()
+	var info CallInfo
+	if _, err := New("", "").GetTickerCtx(ContextWithCallInfo(context.Background(), &info), "btcusd"); err == nil {
+		t.Fatal("error expected")
+	}
+	if info.Attempts != 1 || info.StatusCode != 0 || info.Header != nil || !info.Date().IsZero() {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
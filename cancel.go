@@ -0,0 +1,172 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CanceledOrder is an order affected by a cancel request.
+type CanceledOrder struct {
+	ID     int64
+	Type   OrderType
+	Price  float64
+	Amount float64
+	// Symbol is a lowercase pair symbol, like btcusd.
+	Symbol string
+}
+
+// CancelAllResult is a result of a cancel all orders request.
+type CancelAllResult struct {
+	Canceled    []CanceledOrder
+	NotCanceled []CanceledOrder
+}
+
+// CancelOrder cancels an order with the given id.
+// The amount of the result is the amount, which was not executed.
+// If the order does not exist, ErrOrderNotFound is returned.
+func (api *Api) CancelOrder(id int64) (*CanceledOrder, error) {
+	return api.CancelOrderCtx(context.Background(), id)
+}
+
+// CancelOrderCtx is like CancelOrder, but uses the given context.
+func (api *Api) CancelOrderCtx(ctx context.Context, id int64) (*CanceledOrder, error) {
+	raw, err := api.cancelOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	order, err := raw.convert()
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// cancelOrder cancels an order and returns the undecoded response.
+func (api *Api) cancelOrder(ctx context.Context, id int64) (*rawCanceledOrder, error) {
+	body, err := api.post(ctx, "/cancel_order/", idValues(id))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, withSentinel(ErrOrderNotFound, err)
+		}
+		return nil, err
+	}
+	var raw rawCanceledOrder
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// CancelAllOrders cancels all open orders.
+// If some orders were not canceled, the result is returned along with ErrPartiallyCanceled.
+func (api *Api) CancelAllOrders() (*CancelAllResult, error) {
+	return api.CancelAllOrdersCtx(context.Background())
+}
+
+// CancelAllOrdersCtx is like CancelAllOrders, but uses the given context.
+func (api *Api) CancelAllOrdersCtx(ctx context.Context) (*CancelAllResult, error) {
+	return api.cancelAll(ctx, "/cancel_all_orders/")
+}
+
+// CancelAllOrdersForPair cancels all open orders for the given symbol.
+// If some orders were not canceled, the result is returned along with ErrPartiallyCanceled.
+func (api *Api) CancelAllOrdersForPair(symbol string) (*CancelAllResult, error) {
+	return api.CancelAllOrdersForPairCtx(context.Background(), symbol)
+}
+
+// CancelAllOrdersForPairCtx is like CancelAllOrdersForPair, but uses the given context.
+func (api *Api) CancelAllOrdersForPairCtx(ctx context.Context, symbol string) (*CancelAllResult, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	return api.cancelAll(ctx, "/cancel_all_orders/"+strings.ToLower(symbol)+"/")
+}
+
+func (api *Api) cancelAll(ctx context.Context, path string) (*CancelAllResult, error) {
+	body, err := api.post(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseCancelAll(body)
+}
+
+type rawCanceledOrder struct {
+	ID           interface{} `json:"id"`
+	Type         interface{} `json:"type"`
+	Price        interface{} `json:"price"`
+	Amount       interface{} `json:"amount"`
+	CurrencyPair string      `json:"currency_pair"`
+}
+
+func (r *rawCanceledOrder) UnmarshalJSON(data []byte) error {
+	// some responses list bare order ids.
+	var id json.Number
+	if err := json.Unmarshal(data, &id); err == nil {
+		r.ID = string(id)
+		return nil
+	}
+	type plain rawCanceledOrder
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+func (r rawCanceledOrder) convert() (order CanceledOrder, err error) {
+	order.Symbol = pairSymbol(r.CurrencyPair)
+	if order.ID, err = parseIntValue(r.ID); err != nil {
+		return order, errors.Wrap(err, "id parsing error")
+	}
+	if r.Type != nil {
+		if order.Type, err = parseOrderType(r.Type); err != nil {
+			return order, errors.Wrap(err, "type parsing error")
+		}
+	}
+	if r.Price != nil {
+		if order.Price, err = parseNumber("price", r.Price); err != nil {
+			return order, err
+		}
+	}
+	if r.Amount != nil {
+		if order.Amount, err = parseNumber("amount", r.Amount); err != nil {
+			return order, err
+		}
+	}
+	return order, nil
+}
+
+func parseCancelAll(data []byte) (*CancelAllResult, error) {
+	var raw struct {
+		Success     bool               `json:"success"`
+		Canceled    []rawCanceledOrder `json:"canceled"`
+		NotCanceled []rawCanceledOrder `json:"not_canceled"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	convert := func(arr []rawCanceledOrder) ([]CanceledOrder, error) {
+		result := make([]CanceledOrder, len(arr))
+		for i, r := range arr {
+			order, err := r.convert()
+			if err != nil {
+				return nil, errors.Wrapf(err, "order %d", i)
+			}
+			result[i] = order
+		}
+		return result, nil
+	}
+	var (
+		result CancelAllResult
+		err    error
+	)
+	if result.Canceled, err = convert(raw.Canceled); err != nil {
+		return nil, errors.Wrap(err, "canceled orders parsing error")
+	}
+	if result.NotCanceled, err = convert(raw.NotCanceled); err != nil {
+		return nil, errors.Wrap(err, "not canceled orders parsing error")
+	}
+	if !raw.Success || len(result.NotCanceled) > 0 {
+		return &result, errors.Wrapf(ErrPartiallyCanceled, "%d canceled, %d not canceled", len(result.Canceled), len(result.NotCanceled))
+	}
+	return &result, nil
+}
@@ -0,0 +1,53 @@
+package bitstamp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseCancelAll(t *testing.T) {
+	data := []byte(`{
+		"success": true,
+		"canceled": [
+			{"id": 1193624515, "amount": "0.01000000", "price": "8500.00", "type": 0, "currency_pair": "BTC/USD"},
+			{"id": 1193624516, "amount": "2.50000000", "price": "250.10", "type": 1, "currency_pair": "ETH/EUR"}
+		]
+	}`)
+	result, err := parseCancelAll(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &CancelAllResult{
+		Canceled: []CanceledOrder{
+			{ID: 1193624515, Type: OrderBuy, Price: 8500, Amount: 0.01, Symbol: "btcusd"},
+			{ID: 1193624516, Type: OrderSell, Price: 250.1, Amount: 2.5, Symbol: "etheur"},
+		},
+		NotCanceled: []CanceledOrder{},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("expected %+v, got %+v", expected, result)
+	}
+}
+
+func TestParseCancelAllPartial(t *testing.T) {
+	data := []byte(`{
+		"success": true,
+		"canceled": [{"id": 1, "amount": "1", "price": "1", "type": 0, "currency_pair": "BTC/USD"}],
+		"not_canceled": [2, {"id": "3"}]
+	}`)
+	result, err := parseCancelAll(data)
+	if !errors.Is(err, ErrPartiallyCanceled) {
+		t.Fatalf("expected ErrPartiallyCanceled, got %v", err)
+	}
+	if result == nil || len(result.Canceled) != 1 || len(result.NotCanceled) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.NotCanceled[0].ID != 2 || result.NotCanceled[1].ID != 3 {
+		t.Errorf("unexpected not canceled orders %+v", result.NotCanceled)
+	}
+	if _, err := parseCancelAll([]byte(`{"success": false, "canceled": []}`)); !errors.Is(err, ErrPartiallyCanceled) {
+		t.Errorf("expected ErrPartiallyCanceled, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package bitstamp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChannelType is a type of websocket channels. The channel names are the type followed by _ and the pair.
+type ChannelType string
+
+const (
+	// ChannelTypeOrderBook channels send the top 100 levels of the book.
+	ChannelTypeOrderBook ChannelType = "order_book"
+	// ChannelTypeDetailOrderBook channels send the top 100 orders with their ids.
+	ChannelTypeDetailOrderBook ChannelType = "detail_order_book"
+	// ChannelTypeDiffOrderBook channels send the changes of the book.
+	ChannelTypeDiffOrderBook ChannelType = "diff_order_book"
+	// ChannelTypeLiveTrades channels send the trades.
+	ChannelTypeLiveTrades ChannelType = "live_trades"
+	// ChannelTypeLiveOrders channels send the created, changed and deleted orders.
+	ChannelTypeLiveOrders ChannelType = "live_orders"
+	// ChannelTypeMyOrders and ChannelTypeMyTrades are private channels, see WsClient.SubscribePrivate.
+	ChannelTypeMyOrders ChannelType = "my_orders"
+	ChannelTypeMyTrades ChannelType = "my_trades"
+)
+
+var channelTypes = []ChannelType{
+	ChannelTypeOrderBook,
+	ChannelTypeDetailOrderBook,
+	ChannelTypeDiffOrderBook,
+	ChannelTypeLiveTrades,
+	ChannelTypeLiveOrders,
+	ChannelTypeMyOrders,
+	ChannelTypeMyTrades,
+}
+
+// privateChannelPrefix is the prefix of the names of private channels. The names end with - and the user id.
+const privateChannelPrefix = "private-"
+
+// Channel returns the name of the channel of the given type for a pair, like btcusd or BTC/USD.
+func (t ChannelType) Channel(pair string) string {
+	return string(t) + "_" + pairSymbol(pair)
+}
+
+// ChannelOrderBook returns the name of the order book channel of a pair, like order_book_btcusd.
+func ChannelOrderBook(pair string) string {
+	return ChannelTypeOrderBook.Channel(pair)
+}
+
+// ChannelDetailOrderBook returns the name of the detail order book channel of a pair.
+func ChannelDetailOrderBook(pair string) string {
+	return ChannelTypeDetailOrderBook.Channel(pair)
+}
+
+// ChannelDiffOrderBook returns the name of the order book updates channel of a pair.
+func ChannelDiffOrderBook(pair string) string {
+	return ChannelTypeDiffOrderBook.Channel(pair)
+}
+
+// ChannelLiveTrades returns the name of the trades channel of a pair.
+func ChannelLiveTrades(pair string) string {
+	return ChannelTypeLiveTrades.Channel(pair)
+}
+
+// ChannelLiveOrders returns the name of the orders channel of a pair.
+func ChannelLiveOrders(pair string) string {
+	return ChannelTypeLiveOrders.Channel(pair)
+}
+
+// privateChannel returns the name of a private channel of a user, like private-my_orders_btcusd-42.
+func privateChannel(channel string, userID int64) string {
+	return privateChannelPrefix + channel + "-" + strconv.FormatInt(userID, 10)
+}
+
+// ParseChannel splits a channel name into the type and the pair. Private channel names,
+// like private-my_orders_btcusd-42, are accepted too.
+func ParseChannel(name string) (ChannelType, string, error) {
+	channel := name
+	if strings.HasPrefix(channel, privateChannelPrefix) {
+		idx := strings.LastIndexByte(channel, '-')
+		if idx < len(privateChannelPrefix) {
+			return "", "", errors.Errorf("channel %q: no user id", name)
+		}
+		if _, err := strconv.ParseInt(channel[idx+1:], 10, 64); err != nil {
+			return "", "", errors.Errorf("channel %q: invalid user id", name)
+		}
+		channel = channel[len(privateChannelPrefix):idx]
+	}
+	for _, t := range channelTypes {
+		if !strings.HasPrefix(channel, string(t)+"_") {
+			continue
+		}
+		pair := channel[len(t)+1:]
+		if !validChannelPair(pair) {
+			return "", "", errors.Errorf("channel %q: invalid pair %q", name, pair)
+		}
+		return t, pair, nil
+	}
+	return "", "", errors.Errorf("unknown channel %q", name)
+}
+
+// validChannelPair reports whether pair is a non-empty lowercase symbol.
+func validChannelPair(pair string) bool {
+	if pair == "" {
+		return false
+	}
+	for i := 0; i < len(pair); i++ {
+		if c := pair[i]; !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
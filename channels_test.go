@@ -0,0 +1,85 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChannelBuilders(t *testing.T) {
+	tests := []struct {
+		channel  string
+		expected string
+	}{
+		{channel: ChannelOrderBook("btcusd"), expected: "order_book_btcusd"},
+		{channel: ChannelOrderBook("BTC/USD"), expected: "order_book_btcusd"},
+		{channel: ChannelDetailOrderBook("btceur"), expected: "detail_order_book_btceur"},
+		{channel: ChannelDiffOrderBook("xrpusd"), expected: "diff_order_book_xrpusd"},
+		{channel: ChannelLiveTrades("ETH/EUR"), expected: "live_trades_etheur"},
+		{channel: ChannelLiveOrders("ethbtc"), expected: "live_orders_ethbtc"},
+		{channel: ChannelTypeMyTrades.Channel("btcusd"), expected: "my_trades_btcusd"},
+		{channel: privateChannel(ChannelTypeMyOrders.Channel("btcusd"), 42), expected: "private-my_orders_btcusd-42"},
+	}
+	for _, test := range tests {
+		if test.channel != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, test.channel)
+		}
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		name string
+		// expected is empty for invalid names.
+		expected ChannelType
+		pair     string
+	}{
+		{name: "order_book_btcusd", expected: ChannelTypeOrderBook, pair: "btcusd"},
+		{name: "detail_order_book_btceur", expected: ChannelTypeDetailOrderBook, pair: "btceur"},
+		{name: "diff_order_book_xrpusd", expected: ChannelTypeDiffOrderBook, pair: "xrpusd"},
+		{name: "live_trades_etheur", expected: ChannelTypeLiveTrades, pair: "etheur"},
+		{name: "live_orders_ethbtc", expected: ChannelTypeLiveOrders, pair: "ethbtc"},
+		{name: "my_orders_btcusd", expected: ChannelTypeMyOrders, pair: "btcusd"},
+		{name: "private-my_orders_btcusd-42", expected: ChannelTypeMyOrders, pair: "btcusd"},
+		{name: "private-my_trades_usdcusd-1234567", expected: ChannelTypeMyTrades, pair: "usdcusd"},
+		{name: ""},
+		{name: "order_book"},
+		{name: "order_book_"},
+		{name: "orderbook_btcusd"},
+		{name: "live_trade_btcusd"},
+		{name: "order_book_BTCUSD"},
+		{name: "order_book_btc/usd"},
+		{name: "private-my_orders_btcusd"},
+		{name: "private-my_orders_btcusd-"},
+		{name: "private-my_orders_btcusd-x"},
+		{name: "private--42"},
+	}
+	for _, test := range tests {
+		typ, pair, err := ParseChannel(test.name)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%q: error expected, got %s %s", test.name, typ, pair)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.name, err)
+		} else if typ != test.expected || pair != test.pair {
+			t.Errorf("%q: expected %s %s, got %s %s", test.name, test.expected, test.pair, typ, pair)
+		}
+	}
+}
+
+func TestWsEventPair(t *testing.T) {
+	tests := map[string]string{
+		"live_trades_btcusd":          "btcusd",
+		"private-my_trades_ethusd-42": "ethusd",
+		"":                            "",
+		"unknown_btcusd":              "",
+	}
+	for channel, expected := range tests {
+		ev := WsEvent{Event: "data", Channel: channel, Data: json.RawMessage(`{}`)}
+		if pair := ev.Pair(); pair != expected {
+			t.Errorf("%q: expected %q, got %q", channel, expected, pair)
+		}
+	}
+}
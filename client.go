@@ -0,0 +1,147 @@
+package bitstamp
+
+import (
+	"context"
+	"time"
+)
+
+// Client is implemented by Api. Code depending on it, rather than on *Api,
+// can be tested with a fake, like the one of the bitstampfake package.
+type Client interface {
+	MarketDataClient
+	TradingClient
+	AccountClient
+	FundingClient
+}
+
+var _ Client = (*Api)(nil)
+
+// MarketDataClient is implemented by clients of the public market data endpoints.
+type MarketDataClient interface {
+	GetTicker(symbol string) (*Ticker, error)
+	GetTickerCtx(ctx context.Context, symbol string) (*Ticker, error)
+	GetTickerHour(symbol string) (*Ticker, error)
+	GetTickerHourCtx(ctx context.Context, symbol string) (*Ticker, error)
+	GetAllTickers() (map[string]Ticker, error)
+	GetAllTickersCtx(ctx context.Context) (map[string]Ticker, error)
+	GetEurUsd() (*ConversionRate, error)
+	GetEurUsdCtx(ctx context.Context) (*ConversionRate, error)
+	GetOrderBook(symbol string) (*OrderBook, error)
+	GetOrderBookCtx(ctx context.Context, symbol string) (*OrderBook, error)
+	GetOrderBookDepth(symbol string, depth int) (*OrderBook, error)
+	GetOrderBookDepthCtx(ctx context.Context, symbol string, depth int) (*OrderBook, error)
+	GetOrderBookGrouped(symbol string, group int) (*GroupedOrderBook, error)
+	GetOrderBookGroupedCtx(ctx context.Context, symbol string, group int) (*GroupedOrderBook, error)
+	GetTrades(symbol string) ([]Trade, error)
+	GetTradesCtx(ctx context.Context, symbol string) ([]Trade, error)
+	GetTradesParams(symbol string, interval Interval) ([]Trade, error)
+	GetTradesParamsCtx(ctx context.Context, symbol string, interval Interval) ([]Trade, error)
+	GetOHLC(symbol string, opts OHLCOpts) ([]Candle, error)
+	GetOHLCCtx(ctx context.Context, symbol string, opts OHLCOpts) ([]Candle, error)
+	GetCurrencies() ([]Currency, error)
+	GetCurrenciesCtx(ctx context.Context) ([]Currency, error)
+	GetTradingPairsInfo() ([]PairInfo, error)
+	GetTradingPairsInfoCtx(ctx context.Context) ([]PairInfo, error)
+	GetTickerDecimal(symbol string) (*DecimalTicker, error)
+	GetTickerDecimalCtx(ctx context.Context, symbol string) (*DecimalTicker, error)
+	GetOrderBookDecimal(symbol string) (*DecimalOrderBook, error)
+	GetOrderBookDecimalCtx(ctx context.Context, symbol string) (*DecimalOrderBook, error)
+	GetTradesDecimal(symbol string) ([]DecimalTrade, error)
+	GetTradesDecimalCtx(ctx context.Context, symbol string) ([]DecimalTrade, error)
+	GetMarketSnapshot(ctx context.Context, symbol string) (*Snapshot, error)
+}
+
+// TradingClient is implemented by clients placing, canceling and querying orders.
+type TradingClient interface {
+	BuyLimitOrder(symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error)
+	BuyLimitOrderCtx(ctx context.Context, symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error)
+	SellLimitOrder(symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error)
+	SellLimitOrderCtx(ctx context.Context, symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error)
+	BuyMarketOrder(symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error)
+	BuyMarketOrderCtx(ctx context.Context, symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error)
+	SellMarketOrder(symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error)
+	SellMarketOrderCtx(ctx context.Context, symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error)
+	BuyInstantOrder(symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error)
+	BuyInstantOrderCtx(ctx context.Context, symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error)
+	SellInstantOrder(symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error)
+	SellInstantOrderCtx(ctx context.Context, symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error)
+	BuyLimitOrderDecimal(symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error)
+	BuyLimitOrderDecimalCtx(ctx context.Context, symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error)
+	SellLimitOrderDecimal(symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error)
+	SellLimitOrderDecimalCtx(ctx context.Context, symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error)
+	ReplaceOrder(ctx context.Context, orderID int64, newPrice, newAmount float64, opts LimitOrderOpts) (*ReplaceResult, error)
+	CancelOrder(id int64) (*CanceledOrder, error)
+	CancelOrderCtx(ctx context.Context, id int64) (*CanceledOrder, error)
+	CancelAllOrders() (*CancelAllResult, error)
+	CancelAllOrdersCtx(ctx context.Context) (*CancelAllResult, error)
+	CancelAllOrdersForPair(symbol string) (*CancelAllResult, error)
+	CancelAllOrdersForPairCtx(ctx context.Context, symbol string) (*CancelAllResult, error)
+	GetOpenOrders() ([]OpenOrder, error)
+	GetOpenOrdersCtx(ctx context.Context) ([]OpenOrder, error)
+	GetOpenOrdersForPair(symbol string) ([]OpenOrder, error)
+	GetOpenOrdersForPairCtx(ctx context.Context, symbol string) ([]OpenOrder, error)
+	GetOrderStatus(id int64) (*OrderStatusResult, error)
+	GetOrderStatusCtx(ctx context.Context, id int64) (*OrderStatusResult, error)
+	GetOrderStatusParams(params OrderStatusParams) (*OrderStatusResult, error)
+	GetOrderStatusParamsCtx(ctx context.Context, params OrderStatusParams) (*OrderStatusResult, error)
+}
+
+// AccountClient is implemented by clients of the balances, fees and transactions of an account.
+type AccountClient interface {
+	GetAccountBalance() (*Balances, error)
+	GetAccountBalanceCtx(ctx context.Context) (*Balances, error)
+	GetAccountBalanceDecimal() (*DecimalBalances, error)
+	GetAccountBalanceDecimalCtx(ctx context.Context) (*DecimalBalances, error)
+	GetPairBalance(symbol string) (*PairBalance, error)
+	GetPairBalanceCtx(ctx context.Context, symbol string) (*PairBalance, error)
+	GetTradingFees() (map[string]TradingFee, error)
+	GetTradingFeesCtx(ctx context.Context) (map[string]TradingFee, error)
+	GetTradingFeesForPair(symbol string) (*TradingFee, error)
+	GetTradingFeesForPairCtx(ctx context.Context, symbol string) (*TradingFee, error)
+	GetUserTransactions(params UserTransactionsParams) ([]UserTransaction, error)
+	GetUserTransactionsCtx(ctx context.Context, params UserTransactionsParams) ([]UserTransaction, error)
+	GetCryptoTransactions(params CryptoTransactionsParams) (*CryptoTransactions, error)
+	GetCryptoTransactionsCtx(ctx context.Context, params CryptoTransactionsParams) (*CryptoTransactions, error)
+	GetWebsocketsToken() (*WebsocketToken, error)
+	GetWebsocketsTokenCtx(ctx context.Context) (*WebsocketToken, error)
+}
+
+// FundingClient is implemented by clients of deposits, withdrawals and transfers.
+type FundingClient interface {
+	GetDepositAddress(currency string) (*DepositAddress, error)
+	GetDepositAddressCtx(ctx context.Context, currency string) (*DepositAddress, error)
+	CryptoWithdraw(currency, address string, amount float64, opts WithdrawOpts) (int64, error)
+	CryptoWithdrawCtx(ctx context.Context, currency, address string, amount float64, opts WithdrawOpts) (int64, error)
+	WithdrawBTC(address string, amount float64) (int64, error)
+	WithdrawBTCCtx(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawETH(address string, amount float64) (int64, error)
+	WithdrawETHCtx(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawUSDC(address string, amount float64) (int64, error)
+	WithdrawUSDCCtx(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawUSDT(address string, amount float64) (int64, error)
+	WithdrawUSDTCtx(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawLINK(address string, amount float64) (int64, error)
+	WithdrawLINKCtx(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawPAX(address string, amount float64) (int64, error)
+	WithdrawPAXCtx(ctx context.Context, address string, amount float64) (int64, error)
+	WithdrawXRP(address string, amount float64, destinationTag *int64) (int64, error)
+	WithdrawXRPCtx(ctx context.Context, address string, amount float64, destinationTag *int64) (int64, error)
+	WithdrawXLM(address string, amount float64, memoID *string) (int64, error)
+	WithdrawXLMCtx(ctx context.Context, address string, amount float64, memoID *string) (int64, error)
+	GetWithdrawalRequests(since time.Time, limit int) ([]WithdrawalRequest, error)
+	GetWithdrawalRequestsCtx(ctx context.Context, since time.Time, limit int) ([]WithdrawalRequest, error)
+	OpenBankWithdrawal(params BankWithdrawalParams) (int64, error)
+	OpenBankWithdrawalCtx(ctx context.Context, params BankWithdrawalParams) (int64, error)
+	GetBankWithdrawalStatus(id int64) (*BankWithdrawalStatus, error)
+	GetBankWithdrawalStatusCtx(ctx context.Context, id int64) (*BankWithdrawalStatus, error)
+	CancelBankWithdrawal(id int64) (*CanceledBankWithdrawal, error)
+	CancelBankWithdrawalCtx(ctx context.Context, id int64) (*CanceledBankWithdrawal, error)
+	NewLiquidationAddress(liquidationCurrency string) (string, error)
+	NewLiquidationAddressCtx(ctx context.Context, liquidationCurrency string) (string, error)
+	GetLiquidationAddressInfo(address string, since time.Time) ([]LiquidationAddressInfo, error)
+	GetLiquidationAddressInfoCtx(ctx context.Context, address string, since time.Time) ([]LiquidationAddressInfo, error)
+	TransferSubToMain(amount float64, currency, subAccount string) (*TransferResult, error)
+	TransferSubToMainCtx(ctx context.Context, amount float64, currency, subAccount string) (*TransferResult, error)
+	TransferMainToSub(amount float64, currency, subAccount string) (*TransferResult, error)
+	TransferMainToSubCtx(ctx context.Context, amount float64, currency, subAccount string) (*TransferResult, error)
+}
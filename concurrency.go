@@ -0,0 +1,62 @@
+package bitstamp
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// InFlightMetrics may be implemented by Metrics to receive the number of in-flight rest requests.
+// It is called when a request starts or ends, if WithMaxConcurrentRequests is used.
+type InFlightMetrics interface {
+	ObserveInFlight(n int)
+}
+
+// requestSemaphore bounds the number of in-flight rest requests.
+type requestSemaphore struct {
+	slots    chan struct{}
+	inFlight int64
+}
+
+func newRequestSemaphore(n int) *requestSemaphore {
+	return &requestSemaphore{slots: make(chan struct{}, n)}
+}
+
+// acquire waits for a free slot until ctx is done, and returns the number of in-flight requests.
+func (s *requestSemaphore) acquire(ctx context.Context) (int, error) {
+	select {
+	case s.slots <- struct{}{}:
+		return int(atomic.AddInt64(&s.inFlight, 1)), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// release frees a slot and returns the number of in-flight requests.
+func (s *requestSemaphore) release() int {
+	n := atomic.AddInt64(&s.inFlight, -1)
+	<-s.slots
+	return int(n)
+}
+
+// acquireRequest waits until a request can be sent, if the number of concurrent requests is limited.
+// The returned function must be called after the response body is read.
+func (api *Api) acquireRequest(ctx context.Context) (func(), error) {
+	sem := api.semaphore
+	if sem == nil {
+		return func() {}, nil
+	}
+	n, err := sem.acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "waiting for a request slot")
+	}
+	api.observeInFlight(n)
+	return func() { api.observeInFlight(sem.release()) }, nil
+}
+
+func (api *Api) observeInFlight(n int) {
+	if m, ok := api.metrics().(InFlightMetrics); ok {
+		m.ObserveInFlight(n)
+	}
+}
@@ -0,0 +1,133 @@
+package bitstamp
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// inFlightMetrics records the numbers of in-flight requests.
+type inFlightMetrics struct {
+	recordingMetrics
+	inFlight []int
+}
+
+func (m *inFlightMetrics) ObserveInFlight(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight = append(m.inFlight, n)
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	var current, max int
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		current++
+		if current > max {
+			max = current
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		current--
+		mu.Unlock()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"last": "1"}`)),
+			Request:    req,
+		}, nil
+	}))()
+	metrics := &inFlightMetrics{}
+	api, err := NewClient(WithMaxConcurrentRequests(4), WithMetrics(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.GetTicker("btcusd"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if max != 4 {
+		t.Errorf("expected at most 4 concurrent requests, got %d", max)
+	}
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.inFlight) != 40 {
+		t.Fatalf("expected 40 observations, got %d", len(metrics.inFlight))
+	}
+	for _, n := range metrics.inFlight {
+		if n < 0 || n > 4 {
+			t.Errorf("unexpected in-flight value %d", n)
+		}
+	}
+	if last := metrics.inFlight[len(metrics.inFlight)-1]; last != 0 {
+		t.Errorf("expected no in-flight requests at the end, got %d", last)
+	}
+}
+
+func TestMaxConcurrentRequestsCancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	defer withTransport(hangingTransport(started))()
+	api, err := NewClient(WithMaxConcurrentRequests(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := api.GetTickerCtx(ctx, "btcusd")
+		done <- err
+	}()
+	<-started
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer waitCancel()
+	if _, err := api.GetTickerCtx(waitCtx, "btcusd"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded while waiting for a slot, got %v", err)
+	}
+	select {
+	case <-started:
+		t.Error("the second request must not be sent")
+	default:
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Canceled, got %v", err)
+	}
+	// the slot is free after the first request ended.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	go func() { <-started }()
+	if _, err := api.GetTickerCtx(ctx, "btcusd"); !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(fmt.Sprint(err), "timed out") {
+		t.Errorf("expected a timed out request, got %v", err)
+	}
+}
+
+func TestMaxConcurrentRequestsOption(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if _, err := NewClient(WithMaxConcurrentRequests(n)); err == nil {
+			t.Errorf("%d: error expected", n)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config is the content of a config file. See LoadConfig.
+type Config struct {
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+	// BaseURL, if set, is the url of the rest api.
+	BaseURL string `json:"base_url"`
+	// WebsocketURL, if set, is the url of the websocket api.
+	WebsocketURL string `json:"websocket_url"`
+	// Timeout, if set, is the request timeout, like 30s.
+	Timeout string `json:"timeout"`
+	// RateLimit, if set, enables the client side rate limit.
+	RateLimit *RateLimitConfig `json:"rate_limit"`
+	// RateLimitRetry, if set, is the retry budget of rate limited public requests, like 1m.
+	RateLimitRetry string `json:"rate_limit_retry"`
+
+	// User and Password are the credentials of old config files.
+	//
+	// Deprecated: use APIKey and APISecret.
+	User     string
+	Password string
+}
+
+// RateLimitConfig are the parameters of Api.EnableRateLimit.
+type RateLimitConfig struct {
+	Requests int    `json:"requests"`
+	Interval string `json:"interval"`
+	Burst    int    `json:"burst"`
+}
+
+// LoadConfig reads and validates a config file.
+// Files with .yaml or .yml extension are parsed as yaml, other files as json.
+// Only a subset of yaml is supported: nested mappings of scalars and comments.
+func LoadConfig(cfgfile string) (*Config, error) {
+	data, err := ioutil.ReadFile(cfgfile)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.Errorf("config %s: empty file", cfgfile)
+	}
+	switch strings.ToLower(filepath.Ext(cfgfile)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, errors.Wrapf(err, "config %s", cfgfile)
+		}
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "config %s", cfgfile)
+	}
+	if _, err := cfg.options(); err != nil {
+		return nil, errors.Wrapf(err, "config %s", cfgfile)
+	}
+	return &cfg, nil
+}
+
+// legacy returns true, if the config has only User and Password credentials.
+func (c *Config) legacy() bool {
+	return c.APIKey == "" && c.APISecret == "" && (c.User != "" || c.Password != "")
+}
+
+// options validates the config and converts it to options.
+func (c *Config) options() ([]Option, error) {
+	var opts []Option
+	switch {
+	case c.legacy():
+		if c.User == "" {
+			return nil, errors.New("User is required")
+		}
+		if c.Password == "" {
+			return nil, errors.New("Password is required")
+		}
+		user, password := c.User, c.Password
+		opts = append(opts, func(api *Api) error {
+			api.User, api.Password = user, password
+			return nil
+		})
+	case c.APIKey == "":
+		return nil, errors.New("api_key is required")
+	case c.APISecret == "":
+		return nil, errors.New("api_secret is required")
+	default:
+		opts = append(opts, WithCredentials(c.APIKey, c.APISecret))
+	}
+	if c.BaseURL != "" {
+		if err := validateURL(c.BaseURL, "http", "https"); err != nil {
+			return nil, errors.Wrap(err, "base_url")
+		}
+		opts = append(opts, WithBaseURL(c.BaseURL))
+	}
+	if c.WebsocketURL != "" {
+		if err := validateURL(c.WebsocketURL, "ws", "wss"); err != nil {
+			return nil, errors.Wrap(err, "websocket_url")
+		}
+		opts = append(opts, WithWebsocketURL(c.WebsocketURL))
+	}
+	if c.Timeout != "" {
+		timeout, err := parsePositiveDuration(c.Timeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "timeout")
+		}
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if c.RateLimit != nil {
+		interval, err := parsePositiveDuration(c.RateLimit.Interval)
+		if err != nil {
+			return nil, errors.Wrap(err, "rate_limit.interval")
+		}
+		if c.RateLimit.Requests <= 0 {
+			return nil, errors.New("rate_limit.requests must be positive")
+		}
+		if c.RateLimit.Burst <= 0 {
+			return nil, errors.New("rate_limit.burst must be positive")
+		}
+		opts = append(opts, WithRateLimit(c.RateLimit.Requests, interval, c.RateLimit.Burst))
+	}
+	if c.RateLimitRetry != "" {
+		budget, err := parsePositiveDuration(c.RateLimitRetry)
+		if err != nil {
+			return nil, errors.Wrap(err, "rate_limit_retry")
+		}
+		opts = append(opts, WithRateLimitRetry(budget))
+	}
+	return opts, nil
+}
+
+func parsePositiveDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("value is required")
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("%s is not positive", s)
+	}
+	return d, nil
+}
+
+// NewFromConfig creates a new api object given a config file. See LoadConfig.
+// opts are applied after the config settings, so they can override them.
+// Old json files with User and Password are still accepted,
+// in which case a deprecation message is logged.
+func NewFromConfig(cfgfile string, opts ...Option) (*Api, error) {
+	cfg, err := LoadConfig(cfgfile)
+	if err != nil {
+		return nil, err
+	}
+	cfgOpts, err := cfg.options()
+	if err != nil {
+		return nil, errors.Wrapf(err, "config %s", cfgfile)
+	}
+	api, err := NewClient(append(cfgOpts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.legacy() {
+		api.logger().Infof("config %s: User and Password are deprecated, use api_key and api_secret", cfgfile)
+	}
+	return api, nil
+}
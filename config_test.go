@@ -0,0 +1,128 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file with the given name to a temporary directory.
+// The returned function removes the directory.
+func writeConfig(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "bitstamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig("testdata/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Config{
+		APIKey:    "jsonkey",
+		APISecret: "jsonsecret",
+		BaseURL:   "http://127.0.0.1:8080/api/v2",
+		Timeout:   "30s",
+		RateLimit: &RateLimitConfig{Requests: 8000, Interval: "10m", Burst: 10},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cfg)
+	}
+	cfg, err = LoadConfig("testdata/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = &Config{
+		APIKey:         "yamlkey",
+		APISecret:      "yaml#secret",
+		BaseURL:        "http://127.0.0.1:8080/api/v2",
+		WebsocketURL:   "ws://127.0.0.1:8081",
+		RateLimit:      &RateLimitConfig{Requests: 8000, Interval: "10m", Burst: 10},
+		RateLimitRetry: "1m",
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cfg)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "empty.json", content: " \n", expected: "empty file"},
+		{name: "empty.yaml", content: "# nothing\n", expected: "empty yaml document"},
+		{name: "nokey.json", content: `{"api_secret": "secret"}`, expected: "api_key is required"},
+		{name: "nosecret.yml", content: "api_key: key\n", expected: "api_secret is required"},
+		{name: "nopassword.json", content: `{"User": "user"}`, expected: "Password is required"},
+		{name: "unknown.json", content: `{"url": "http://localhost"}`, expected: "api_key is required"},
+		{name: "url.json", content: `{"api_key": "k", "api_secret": "s", "base_url": "localhost"}`, expected: "base_url"},
+		{name: "timeout.yaml", content: "api_key: k\napi_secret: s\ntimeout: 10\n", expected: "timeout"},
+		{name: "limit.yaml", content: "api_key: k\napi_secret: s\nrate_limit:\n  requests: 1\n  interval: 1s\n", expected: "rate_limit.burst must be positive"},
+		{name: "syntax.json", content: `{"api_key": `, expected: "unexpected end of JSON input"},
+		{name: "syntax.yaml", content: "api_key: k\n  api_secret: s\n", expected: "line 2: unexpected indentation"},
+	}
+	for _, test := range tests {
+		path, cleanup := writeConfig(t, test.name, test.content)
+		_, err := LoadConfig(path)
+		cleanup()
+		if err == nil || !strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: expected %q with the file path, got %v", test.name, test.expected, err)
+		}
+	}
+	if _, err := LoadConfig("testdata/missing.json"); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	client := &http.Client{}
+	api, err := NewFromConfig("testdata/config.json", WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Key != "jsonkey" || api.Secret != "jsonsecret" || api.HTTPClient != client {
+		t.Errorf("unexpected settings %+v", *api)
+	}
+	if api.baseURL() != "http://127.0.0.1:8080/api/v2" || api.Timeout != 30*time.Second {
+		t.Errorf("unexpected settings %+v", *api)
+	}
+	if remaining, ok := api.RateLimitRemaining(); !ok || remaining != 10 {
+		t.Errorf("expected 10 remaining requests, got %d, %v", remaining, ok)
+	}
+	api, err = NewFromConfig("testdata/config.yaml", WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Key != "yamlkey" || api.WebsocketURL != "ws://127.0.0.1:8081" || api.RateLimitRetryBudget != time.Minute || api.Timeout != time.Minute {
+		t.Errorf("unexpected settings %+v", *api)
+	}
+}
+
+func TestNewFromConfigLegacy(t *testing.T) {
+	logger := &recordingLogger{}
+	api, err := NewFromConfig("testdata/config_legacy.json", WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.User != "user" || api.Password != "password" || api.Key != "" {
+		t.Errorf("unexpected credentials %+v", *api)
+	}
+	if len(logger.messages) != 1 || !strings.HasPrefix(logger.messages[0], "info: config testdata/config_legacy.json: User and Password are deprecated") {
+		t.Errorf("unexpected messages %q", logger.messages)
+	}
+}
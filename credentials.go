@@ -0,0 +1,32 @@
+package bitstamp
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Credentials are an api key and a secret of an account.
+type Credentials struct {
+	Key    string
+	Secret string
+}
+
+// NewWithAccounts creates a new api object given a set of named credentials.
+// Use WithAccount to select the account, which signs the requests.
+func NewWithAccounts(accounts map[string]Credentials) *Api {
+	return &Api{Accounts: accounts, clock: newMonotonicClock()}
+}
+
+// WithAccount returns a copy of the api object, which signs requests with the credentials
+// of the given account. Public requests are not affected.
+// If the account is unknown, signed requests of the returned object fail with ErrUnknownAccount
+// without being sent.
+func (api *Api) WithAccount(name string) *Api {
+	result := *api
+	creds, found := api.Accounts[name]
+	if !found {
+		result.err = errors.Wrapf(ErrUnknownAccount, "%q", name)
+		return &result
+	}
+	result.Key, result.Secret, result.err = creds.Key, creds.Secret, nil
+	return &result
+}
@@ -0,0 +1,73 @@
+package bitstamp
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWithAccount(t *testing.T) {
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(url.Values) (int, string) {
+			return 200, `{"usd_available": "1.00"}`
+		},
+		"/open_orders/all/": func(url.Values) (int, string) {
+			return 200, `[]`
+		},
+		"/buy/btcusd/": func(url.Values) (int, string) {
+			return 200, `{"id": "1", "datetime": "2019-09-06 07:35:04", "type": "0", "price": "100", "amount": "1"}`
+		},
+	})
+	defer restore()
+	var keys []string
+	fake.header = func(req *http.Request, _ string) http.Header {
+		keys = append(keys, req.Header.Get("X-Auth"))
+		return nil
+	}
+	api := NewWithAccounts(map[string]Credentials{
+		"main": {Key: "main-key", Secret: "main-secret"},
+		"sub1": {Key: "sub1-key", Secret: "sub1-secret"},
+	})
+	if _, err := api.WithAccount("main").GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	sub := api.WithAccount("sub1")
+	if _, err := sub.GetOpenOrders(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"BITSTAMP main-key", "BITSTAMP sub1-key", "BITSTAMP sub1-key"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d requests, got %d", len(expected), len(keys))
+	}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Errorf("request %d: expected %q, got %q", i, expected[i], keys[i])
+		}
+	}
+	if api.Key != "" {
+		t.Errorf("the original api object must not change")
+	}
+}
+
+func TestWithUnknownAccount(t *testing.T) {
+	fake, restore := withFakeExchange(nil)
+	defer restore()
+	api := NewWithAccounts(map[string]Credentials{"main": {Key: "key", Secret: "secret"}}).WithAccount("sub2")
+	if _, err := api.GetAccountBalance(); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("ErrUnknownAccount expected, got %v", err)
+	}
+	if _, err := api.SellMarketOrder("btcusd", 1, MarketOrderOpts{}); !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("ErrUnknownAccount expected, got %v", err)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("unexpected calls %v", fake.calls)
+	}
+	if _, err := api.WithAccount("main").GetAccountBalance(); errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("the account must be selectable again, got %v", err)
+	}
+}
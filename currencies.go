@@ -0,0 +1,112 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CurrencyType is a type of a currency.
+type CurrencyType string
+
+const (
+	// CurrencyCrypto is a cryptocurrency.
+	CurrencyCrypto CurrencyType = "crypto"
+	// CurrencyFiat is a fiat currency.
+	CurrencyFiat CurrencyType = "fiat"
+)
+
+// Currency describes a currency.
+type Currency struct {
+	// Code is a lowercase currency code, like btc.
+	Code string
+	Name string
+	// Symbol is a display symbol, like ₿. May be empty.
+	Symbol string
+	Type   CurrencyType
+	// Decimals is the number of decimals of amounts.
+	Decimals int
+	// Logo is an url of the currency logo.
+	Logo string
+	// Available reports whether the currency is available.
+	Available  bool
+	Deposit    bool
+	Withdrawal bool
+}
+
+// FormatAmount formats an amount of the currency with at most c.Decimals decimals.
+func (c Currency) FormatAmount(amount float64) string {
+	return formatDecimals(amount, c.Decimals)
+}
+
+// GetCurrencies returns descriptions of all currencies.
+func (api *Api) GetCurrencies() ([]Currency, error) {
+	return api.GetCurrenciesCtx(context.Background())
+}
+
+// GetCurrenciesCtx is like GetCurrencies, but uses the given context.
+func (api *Api) GetCurrenciesCtx(ctx context.Context) ([]Currency, error) {
+	body, err := api.get(ctx, "/currencies/")
+	if err != nil {
+		return nil, err
+	}
+	return parseCurrencies(body)
+}
+
+func parseCurrencies(data []byte) ([]Currency, error) {
+	var raw []struct {
+		Currency   string      `json:"currency"`
+		Name       string      `json:"name"`
+		Symbol     string      `json:"symbol"`
+		Type       string      `json:"type"`
+		Decimals   interface{} `json:"decimals"`
+		Logo       string      `json:"logo"`
+		Available  interface{} `json:"available"`
+		Deposit    interface{} `json:"deposit"`
+		Withdrawal interface{} `json:"withdrawal"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]Currency, len(raw))
+	for i, r := range raw {
+		if r.Currency == "" {
+			return nil, errors.Errorf("currency %d: empty code", i)
+		}
+		c := Currency{
+			Code:       strings.ToLower(r.Currency),
+			Name:       r.Name,
+			Symbol:     r.Symbol,
+			Type:       CurrencyType(strings.ToLower(r.Type)),
+			Logo:       r.Logo,
+			Available:  flagValue(r.Available),
+			Deposit:    flagValue(r.Deposit),
+			Withdrawal: flagValue(r.Withdrawal),
+		}
+		decimals, err := parseIntValue(r.Decimals)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: decimals parsing error", c.Code)
+		}
+		c.Decimals = int(decimals)
+		result[i] = c
+	}
+	return result, nil
+}
+
+// flagValue converts a decoded json value, either a bool, a number or a string like "Enabled", to bool.
+func flagValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(v) {
+		case "enabled", "true", "1", "yes":
+			return true
+		}
+	}
+	return false
+}
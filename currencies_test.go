@@ -0,0 +1,54 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestParseCurrencies(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/currencies.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	currencies, err := parseCurrencies(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Currency{
+		{
+			Code:       "btc",
+			Name:       "Bitcoin",
+			Symbol:     "₿",
+			Type:       CurrencyCrypto,
+			Decimals:   8,
+			Logo:       "https://assets.bitstamp.net/static/webapp/images/currencies/btc.svg",
+			Available:  true,
+			Deposit:    true,
+			Withdrawal: true,
+		},
+		{
+			Code:      "usd",
+			Name:      "US dollar",
+			Symbol:    "$",
+			Type:      CurrencyFiat,
+			Decimals:  2,
+			Logo:      "https://assets.bitstamp.net/static/webapp/images/currencies/usd.svg",
+			Available: true,
+			Deposit:   true,
+		},
+		{
+			Code:     "xlm",
+			Name:     "Stellar",
+			Type:     CurrencyCrypto,
+			Decimals: 7,
+			Logo:     "https://assets.bitstamp.net/static/webapp/images/currencies/xlm.svg",
+		},
+	}
+	if !reflect.DeepEqual(expected, currencies) {
+		t.Errorf("expected %+v, got %+v", expected, currencies)
+	}
+	if s := currencies[2].FormatAmount(1.123456789); s != "1.1234568" {
+		t.Errorf("unexpected amount %s", s)
+	}
+}
@@ -0,0 +1,140 @@
+package bitstamp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultDebugLimit is the max dumped size of a body or a websocket frame, if no limit is set.
+const defaultDebugLimit = 4096
+
+// debugHeaders are the headers, which values are redacted in the dumps.
+var debugHeaders = map[string]bool{
+	"X-Auth":                  true,
+	"X-Auth-Signature":        true,
+	"X-Server-Auth-Signature": true,
+	"Authorization":           true,
+	"Proxy-Authorization":     true,
+	"Cookie":                  true,
+	"Set-Cookie":              true,
+}
+
+// sensitiveFields are the names of json and form fields, which values are redacted in the dumps.
+const sensitiveFields = `token|auth|key|secret|signature|password|api_key|api_secret`
+
+var (
+	jsonSecretRe = regexp.MustCompile(`("(?:` + sensitiveFields + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	formSecretRe = regexp.MustCompile(`(^|&)(` + sensitiveFields + `)=[^&]*`)
+)
+
+// debugDumper writes the raw rest and websocket traffic. It is safe for concurrent use.
+type debugDumper struct {
+	mu sync.Mutex
+	w  io.Writer
+	// limit is the max dumped size of a body or a frame. If negative, there is no limit.
+	limit int
+}
+
+// dumpRequest writes the request line, the headers and the body of a request.
+// secrets are replaced wherever they appear.
+func (d *debugDumper) dumpRequest(req *http.Request, secrets ...string) {
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = ioutil.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, ">>> %s %s\n", req.Method, req.URL)
+	d.writeHeaders(&buf, req.Header)
+	d.writeBody(&buf, body, secrets)
+	d.write(buf.String(), secrets)
+}
+
+// dumpResponse writes the status, the headers and the body of a response,
+// or the error, if no response was received.
+func (d *debugDumper) dumpResponse(req *http.Request, resp *http.Response, body []byte, latency time.Duration, err error, secrets ...string) {
+	var buf bytes.Buffer
+	if resp == nil {
+		fmt.Fprintf(&buf, "<<< %s %s (%v): %v\n", req.Method, req.URL, latency, err)
+	} else {
+		fmt.Fprintf(&buf, "<<< %s %s %d %s (%v)\n", req.Method, req.URL, resp.StatusCode, http.StatusText(resp.StatusCode), latency)
+		d.writeHeaders(&buf, resp.Header)
+		d.writeBody(&buf, body, secrets)
+		if err != nil {
+			fmt.Fprintf(&buf, "error: %v\n", err)
+		}
+	}
+	d.write(buf.String(), secrets)
+}
+
+// dumpFrame writes a websocket frame. direction is either >>> for sent frames, or <<< for received ones.
+func (d *debugDumper) dumpFrame(direction string, frame []byte, secrets ...string) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s websocket\n", direction)
+	d.writeBody(&buf, frame, secrets)
+	d.write(buf.String(), secrets)
+}
+
+func (d *debugDumper) writeHeaders(buf *bytes.Buffer, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			if debugHeaders[k] {
+				v = redacted
+			}
+			fmt.Fprintf(buf, "%s: %s\n", k, v)
+		}
+	}
+}
+
+func (d *debugDumper) writeBody(buf *bytes.Buffer, body []byte, secrets []string) {
+	if len(body) == 0 {
+		return
+	}
+	// secrets are replaced before truncation, which could cut them.
+	s := redactSecrets(redactBody(string(body)), secrets)
+	buf.WriteByte('\n')
+	if d.limit >= 0 && len(s) > d.limit {
+		fmt.Fprintf(buf, "%s... (%d bytes truncated)\n", s[:d.limit], len(s)-d.limit)
+		return
+	}
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+}
+
+// write writes an entry prefixed with the current time.
+func (d *debugDumper) write(entry string, secrets []string) {
+	entry = redactSecrets(entry, secrets)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fmt.Fprintf(d.w, "%s %s\n", time.Now().UTC().Format(time.RFC3339Nano), entry)
+}
+
+// redactBody replaces the values of sensitive json and form fields.
+func redactBody(body string) string {
+	body = jsonSecretRe.ReplaceAllString(body, `${1}"`+redacted+`"`)
+	return formSecretRe.ReplaceAllString(body, `${1}${2}=`+redacted)
+}
+
+func redactSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.Replace(s, secret, redacted, -1)
+		}
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package bitstamp
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDebugRequests(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/websockets_token/": func(url.Values) (int, string) {
+			return http.StatusOK, `{"token": "wstoken123", "user_id": 1, "valid_sec": 60}`
+		},
+		"/buy/btcusd/": func(url.Values) (int, string) {
+			return http.StatusOK, `{"id": "1", "price": "100.0", "amount": "1.0", "type": "0", "datetime": "2019-09-06 07:35:04"}`
+		},
+	})
+	defer restore()
+	var buf bytes.Buffer
+	api, err := NewClient(WithCredentials("apikey123", "apisecret456"), WithDebug(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetWebsocketsToken(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	dump := buf.String()
+	for _, secret := range []string{"apikey123", "apisecret456", "wstoken123"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("%s is not redacted:\n%s", secret, dump)
+		}
+	}
+	for _, s := range []string{
+		">>> POST https://www.bitstamp.net/api/v2/websockets_token/\n",
+		"X-Auth: REDACTED\n",
+		"X-Auth-Signature: REDACTED\n",
+		"X-Auth-Version: v2\n",
+		"<<< POST https://www.bitstamp.net/api/v2/websockets_token/ 200 OK",
+		`{"token": "REDACTED", "user_id": 1, "valid_sec": 60}`,
+		"\namount=1&price=100\n",
+		`"datetime": "2019-09-06 07:35:04"`,
+	} {
+		if !strings.Contains(dump, s) {
+			t.Errorf("%q is not in the dump:\n%s", s, dump)
+		}
+	}
+	entries := regexp.MustCompile(`(?m)^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+)?Z (>>>|<<<) `).FindAllString(dump, -1)
+	if len(entries) != 4 {
+		t.Errorf("expected 4 timestamped entries, got %d:\n%s", len(entries), dump)
+	}
+}
+
+func TestDebugLimit(t *testing.T) {
+	var buf bytes.Buffer
+	api, err := NewClient(WithDebug(&buf), WithDebugLimit(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.debug.dumpFrame("<<<", []byte(`{"event": "data", "channel": "live_trades_btcusd"}`))
+	if !strings.Contains(buf.String(), "\n{\"event\": ... (40 bytes truncated)\n") {
+		t.Errorf("unexpected dump %q", buf.String())
+	}
+	// secrets are redacted before truncation.
+	buf.Reset()
+	api.debug.dumpFrame(">>>", []byte("prefix apisecret456"), "apisecret456")
+	if !strings.Contains(buf.String(), "prefix RED... (5 bytes truncated)") {
+		t.Errorf("unexpected dump %q", buf.String())
+	}
+	if _, err := NewClient(WithDebugLimit(10)); err == nil {
+		t.Error("error expected without debug mode")
+	}
+	if _, err := NewClient(WithDebug(nil)); err == nil {
+		t.Error("error expected for a nil writer")
+	}
+}
+
+func TestDebugWebsocket(t *testing.T) {
+	var buf bytes.Buffer
+	api, err := NewClient(WithDebug(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscribeTestOrderBook(t, api)
+	dump := buf.String()
+	for _, s := range []string{
+		">>> websocket\n\n{\"event\":\"bts:subscribe\",\"channel\":\"\",\"data\":{\"channel\":\"order_book_btcusd\"}}\n",
+		"<<< websocket\n\n{\"event\":\"bts:subscription_succeeded\"",
+		"<<< websocket\n\n{\"event\":\n",
+	} {
+		if !strings.Contains(dump, s) {
+			t.Errorf("%q is not in the dump:\n%s", s, dump)
+		}
+	}
+	event, err := privateSubscribeEvent(&WebsocketToken{Token: "wstoken123", UserID: 1}, "my_orders_btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	api.debug.dumpFrame(">>>", event.Data)
+	if strings.Contains(buf.String(), "wstoken123") || !strings.Contains(buf.String(), `"auth":"REDACTED"`) {
+		t.Errorf("the token is not redacted: %s", buf.String())
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := map[string]string{
+		`{"key": "k", "nonce": "n", "api_secret":"s\"q"}`: `{"key": "REDACTED", "nonce": "n", "api_secret":"REDACTED"}`,
+		`amount=1&token=abc&price=2`:                      `amount=1&token=REDACTED&price=2`,
+		`signature=abc`:                                   `signature=REDACTED`,
+		`{"tokens": "x", "monkey": "y"}`:                  `{"tokens": "x", "monkey": "y"}`,
+	}
+	for body, expected := range tests {
+		if redacted := redactBody(body); redacted != expected {
+			t.Errorf("%s: expected %s, got %s", body, expected, redacted)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxDecimalScale limits the number of digits after the point of parsed decimals.
+const maxDecimalScale = 1000
+
+// Decimal is an exact decimal number, used instead of float64 to avoid representation errors
+// of prices and amounts, like 0.1+0.2 != 0.3. The zero value is 0.
+// Decimals are immutable and safe for concurrent use.
+type Decimal struct {
+	// the number is unscaled * 10^-scale. nil means zero.
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns value * 10^-scale, so NewDecimal(1, 8) is 0.00000001.
+func NewDecimal(value int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(big.NewInt(value), pow10(-scale))}
+	}
+	return Decimal{unscaled: big.NewInt(value), scale: scale}
+}
+
+// ParseDecimal parses a decimal number, like -12.345 or 1E-8.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return Decimal{}, errors.Errorf("invalid decimal %q", s)
+		}
+		mantissa = s[:i]
+	}
+	digits := mantissa
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		digits = digits[1:]
+	}
+	intPart, fracPart := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		intPart, fracPart = digits[:i], digits[i+1:]
+	}
+	if intPart+fracPart == "" || strings.Trim(intPart+fracPart, "0123456789") != "" {
+		return Decimal{}, errors.Errorf("invalid decimal %q", s)
+	}
+	scale := int64(len(fracPart)) - exp
+	if scale > maxDecimalScale || scale < -maxDecimalScale {
+		return Decimal{}, errors.Errorf("decimal %q is out of range", s)
+	}
+	unscaled, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if strings.HasPrefix(mantissa, "-") {
+		unscaled.Neg(unscaled)
+	}
+	if scale < 0 {
+		return Decimal{unscaled: unscaled.Mul(unscaled, pow10(int32(-scale)))}, nil
+	}
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal, but panics if s is invalid.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DecimalFromFloat converts a float to a decimal. The float is rounded to 15 significant digits,
+// so that 0.1 is converted to 0.1, rather than to 0.1000000000000000055511151231257827.
+// NaN and infinities are converted to zero.
+func DecimalFromFloat(v float64) Decimal {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return Decimal{}
+	}
+	d, err := ParseDecimal(formatFloat(v))
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+// Float64 returns the nearest float to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d without an exponent. Trailing zeros after the point are kept,
+// so the parsed strings are formatted as is.
+func (d Decimal) String() string {
+	if d.unscaled == nil {
+		return "0"
+	}
+	s := d.unscaled.String()
+	var sign string
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if d.scale == 0 {
+		return sign + s
+	}
+	if pad := int(d.scale) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	point := len(s) - int(d.scale)
+	return sign + s[:point] + "." + s[point:]
+}
+
+// Sign returns -1, 0 or 1 for negative, zero and positive d.
+func (d Decimal) Sign() int {
+	if d.unscaled == nil {
+		return 0
+	}
+	return d.unscaled.Sign()
+}
+
+// IsZero checks if d is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and other, and returns -1, 0 or 1.
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := align(d, other)
+	return a.Cmp(b)
+}
+
+// Equal checks if d and other are equal numbers, so 1.0 equals 1.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b := align(d, other)
+	return Decimal{unscaled: a.Add(a, b), scale: maxScale(d, other)}
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b := align(d, other)
+	return Decimal{unscaled: a.Sub(a, b), scale: maxScale(d, other)}
+}
+
+// Mul returns d * other.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.bigInt(), other.bigInt()), scale: d.scale + other.scale}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.bigInt()), scale: d.scale}
+}
+
+// MarshalJSON encodes d as a json string, like the api does.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON decodes a json string or number. null is decoded as zero.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// bigInt returns a copy of the unscaled value.
+func (d Decimal) bigInt() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.unscaled)
+}
+
+// align returns the unscaled values of a and b with the same scale.
+func align(a, b Decimal) (*big.Int, *big.Int) {
+	x, y := a.bigInt(), b.bigInt()
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+	}
+	return x, y
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,315 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{"0", "0"},
+		{"0.00000001", "0.00000001"},
+		{"1E-8", "0.00000001"},
+		{"1.5e3", "1500"},
+		{"-12.345", "-12.345"},
+		{"+7", "7"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"0.10000000", "0.10000000"},
+		{"10455.51", "10455.51"},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789"},
+		{"-0.0", "0.0"},
+	}
+	for _, test := range tests {
+		d, err := ParseDecimal(test.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.in, err)
+			continue
+		}
+		if got := d.String(); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.in, test.expected, got)
+		}
+	}
+	for _, invalid := range []string{"", "-", ".", "1.2.3", "abc", "1e", "1e1.5", "0x10", "1 ", "NaN", "Inf", "1e10000"} {
+		if _, err := ParseDecimal(invalid); err == nil {
+			t.Errorf("%q: error expected", invalid)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	if sum := MustParseDecimal("0.1").Add(MustParseDecimal("0.2")); !sum.Equal(MustParseDecimal("0.3")) {
+		t.Errorf("0.1+0.2: expected 0.3, got %s", sum)
+	}
+	if diff := MustParseDecimal("1").Sub(MustParseDecimal("0.00000001")); diff.String() != "0.99999999" {
+		t.Errorf("expected 0.99999999, got %s", diff)
+	}
+	if prod := MustParseDecimal("10455.51").Mul(MustParseDecimal("0.00100000")); !prod.Equal(MustParseDecimal("10.45551")) {
+		t.Errorf("expected 10.45551, got %s", prod)
+	}
+	if neg := MustParseDecimal("1.5").Neg(); neg.String() != "-1.5" || neg.Sign() != -1 {
+		t.Errorf("expected -1.5, got %s", neg)
+	}
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || zero.Sign() != 0 || zero.Float64() != 0 {
+		t.Errorf("unexpected zero value %s", zero)
+	}
+	if MustParseDecimal("1.10").Cmp(MustParseDecimal("1.1")) != 0 || MustParseDecimal("1.09").Cmp(MustParseDecimal("1.1")) != -1 {
+		t.Error("unexpected comparison result")
+	}
+	if d := NewDecimal(1, 8); d.String() != "0.00000001" {
+		t.Errorf("expected 0.00000001, got %s", d)
+	}
+	if d := NewDecimal(15, -2); d.String() != "1500" {
+		t.Errorf("expected 1500, got %s", d)
+	}
+}
+
+func TestDecimalFloatConversion(t *testing.T) {
+	tests := []struct {
+		in       float64
+		expected string
+	}{
+		{0.1, "0.1"},
+		{0.1 + 0.2, "0.3"},
+		{1e-8, "0.00000001"},
+		{10455.51, "10455.51"},
+		{-2.5, "-2.5"},
+		{1.5e21, "1500000000000000000000"},
+	}
+	for _, test := range tests {
+		d := DecimalFromFloat(test.in)
+		if got := d.String(); got != test.expected {
+			t.Errorf("%v: expected %s, got %s", test.in, test.expected, got)
+		}
+	}
+	if f := MustParseDecimal("0.00000001").Float64(); f != 1e-8 {
+		t.Errorf("expected 1e-8, got %v", f)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A, B, C Decimal
+	}
+	if err := json.Unmarshal([]byte(`{"A": "0.00000001", "B": 10455.51, "C": null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "0.00000001" || v.B.String() != "10455.51" || !v.C.IsZero() {
+		t.Errorf("unexpected values %s, %s, %s", v.A, v.B, v.C)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"A":"0.00000001","B":"10455.51","C":"0"}`; string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+	for _, invalid := range []string{`"x"`, `true`, `{}`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(invalid), &d); err == nil {
+			t.Errorf("%s: error expected", invalid)
+		}
+	}
+}
+
+func TestGetTickerDecimal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ticker_hour.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+	})
+	defer restore()
+	ticker, err := New("", "").GetTickerDecimal("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last.String() != "10455.51" || ticker.Volume.String() != "142.71522468" || !ticker.Time.Equal(time.Unix(1567755304, 0)) {
+		t.Errorf("unexpected ticker %+v", ticker)
+	}
+	if f := ticker.Float(); f.Last != 10455.51 || f.Volume != 142.71522468 || f.Timestamp != 1567755304 {
+		t.Errorf("unexpected float ticker %+v", f)
+	}
+}
+
+func TestParseDecimalOrderBook(t *testing.T) {
+	book, err := parseDecimalOrderBook([]byte(`{"timestamp": "1567755304", "bids": [["10453.00", "0.10000000"], ["0.00000001", "1E+8"]], "asks": [["10455.51", "0.00000001", "12345"]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !book.Time.Equal(time.Unix(1567755304, 0)) || len(book.Bids) != 2 || len(book.Asks) != 1 {
+		t.Fatalf("unexpected book %+v", book)
+	}
+	if book.Bids[0].Amount.String() != "0.10000000" || book.Bids[1].Price.String() != "0.00000001" || book.Bids[1].Amount.String() != "100000000" {
+		t.Errorf("unexpected bids %v", book.Bids)
+	}
+	if f := book.Float(); f.Asks[0] != (Order{Price: 10455.51, Amount: 1e-8}) {
+		t.Errorf("unexpected float asks %v", f.Asks)
+	}
+	invalid := []string{
+		`{"bids": [["1"]], "asks": []}`,
+		`{"bids": [], "asks": [["x", "1"]]}`,
+		`{"timestamp": "x", "bids": [], "asks": []}`,
+		`{"bids": []}`,
+		`{"bids": [], "asks": null}`,
+		`{"asks": []}`,
+	}
+	for _, data := range invalid {
+		if _, err := parseDecimalOrderBook([]byte(data)); err == nil {
+			t.Errorf("%s: error expected", data)
+		}
+	}
+	// the time and the missing sides are handled as in parseOrderBook.
+	for _, data := range []string{
+		`{"timestamp": "1567755304", "microtimestamp": "1567755304968123", "bids": [], "asks": []}`,
+		`{"timestamp": 1567755304, "bids": [], "asks": []}`,
+		`{"bids": [], "asks": []}`,
+		`{"bids": []}`,
+	} {
+		decimal, decimalErr := parseDecimalOrderBook([]byte(data))
+		float, floatErr := New("", "").parseOrderBook([]byte(data))
+		if (decimalErr == nil) != (floatErr == nil) {
+			t.Errorf("%s: errors differ: %v, %v", data, decimalErr, floatErr)
+			continue
+		}
+		if decimalErr != nil {
+			continue
+		}
+		if d := decimal.Time.Sub(float.Time); d > time.Second || d < -time.Second {
+			t.Errorf("%s: times differ: %v, %v", data, decimal.Time, float.Time)
+		}
+	}
+}
+
+func TestGetOrderBookDecimalValidation(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/order_book/btcusd": func(url.Values) (int, string) {
+			return 200, readFixture(t, "testdata/order_book_shuffled.json")
+		},
+	})
+	defer restore()
+	api := New("", "")
+	if _, err := api.GetOrderBookDecimal("btcusd"); err != nil {
+		t.Errorf("unexpected error without validation %v", err)
+	}
+	api.BookValidation = BookValidationStrict
+	if _, err := api.GetOrderBookDecimal("btcusd"); !errors.Is(err, ErrUnsortedBook) {
+		t.Errorf("expected ErrUnsortedBook, got %v", err)
+	}
+	api.BookValidation = BookValidationSort
+	book, err := api.GetOrderBookDecimal("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !book.sorted() {
+		t.Errorf("expected a sorted book, got %+v", book)
+	}
+}
+
+func TestParseDecimalTrades(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/transactions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trades, err := parseDecimalTrades(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DecimalTrade{
+		Time:   time.Unix(1567755304, 0),
+		TID:    98765432,
+		Price:  MustParseDecimal("10455.51"),
+		Amount: MustParseDecimal("0.02000000"),
+		Type:   TradeBuy,
+	}
+	if len(trades) == 0 || trades[0].Time != expected.Time || trades[0].TID != expected.TID ||
+		trades[0].Price.String() != "10455.51" || trades[0].Amount.String() != "0.02000000" || trades[0].Type != expected.Type {
+		t.Errorf("expected %+v, got %+v", expected, trades)
+	}
+	if _, err := parseDecimalTrades([]byte(`[{"date": "1", "tid": "1", "amount": "1", "price": "1", "type": "2"}]`)); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestGetAccountBalanceDecimal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/balance.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+	})
+	defer restore()
+	balances, err := NewWithKey("key", "secret").GetAccountBalanceDecimal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	btc := balances.Get("BTC")
+	if btc.Available.String() != "0.50234567" || btc.Reserved.String() != "0.01000000" || btc.Total.String() != "0.51234567" {
+		t.Errorf("unexpected btc balance %+v", btc)
+	}
+	if sum := btc.Available.Add(btc.Reserved); !sum.Equal(btc.Total) {
+		t.Errorf("available + reserved: expected %s, got %s", btc.Total, sum)
+	}
+	if fee := balances.Fees["btcusd"]; fee.String() != "0.500" {
+		t.Errorf("unexpected fee %s", fee)
+	}
+	if _, found := balances.Currencies["btc_withdrawal"]; found {
+		t.Error("withdrawal fees must be skipped")
+	}
+
+	// unknown fields may be non-numeric, and must not create empty balances, as in parseBalances.
+	unknown := []byte(`{"btc_available": "1.5", "btc_status": "active", "eth_label": {"x": 1}}`)
+	decimals, err := parseDecimalBalances(unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	floats, err := parseBalances(unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decimals.Currencies) != 1 || len(floats.Currencies) != 1 || decimals.Currencies["btc"].Available.String() != "1.5" {
+		t.Errorf("unexpected balances %+v, %+v", decimals.Currencies, floats.Currencies)
+	}
+	if _, err := parseDecimalBalances([]byte(`{"btc_available": "x"}`)); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestLimitOrderDecimal(t *testing.T) {
+	var sent url.Values
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/buy/btcusd/": func(values url.Values) (int, string) {
+			sent = values
+			return 200, `{"id": "1", "datetime": "2019-09-06 07:35:04", "type": "0", "price": "0.00000001", "amount": "123456789.123456789"}`
+		},
+	})
+	defer restore()
+	api := NewWithKey("key", "secret")
+	api.SkipMinimumOrderCheck = true
+	_, err := api.BuyLimitOrderDecimal("btcusd", MustParseDecimal("0.00000001"), MustParseDecimal("123456789.123456789"), LimitOrderOpts{IOC: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Get("price") != "0.00000001" || sent.Get("amount") != "123456789.123456789" || sent.Get("ioc_order") != "True" {
+		t.Errorf("unexpected values %v", sent)
+	}
+	for _, invalid := range [][2]Decimal{{{}, NewDecimal(1, 0)}, {NewDecimal(1, 0), NewDecimal(-1, 0)}} {
+		if _, err := api.SellLimitOrderDecimal("btcusd", invalid[0], invalid[1], LimitOrderOpts{}); err == nil {
+			t.Errorf("%v: error expected", invalid)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DecimalTicker is like Ticker, but with decimal prices and volumes.
+type DecimalTicker struct {
+	Last   Decimal
+	High   Decimal
+	Low    Decimal
+	Ask    Decimal
+	Bid    Decimal
+	Open   Decimal
+	Volume Decimal
+	VWAP   Decimal
+	Open24 Decimal
+	// PercentChange24 is the price change in percents since Open24.
+	PercentChange24 Decimal
+	Side            OrderType
+	Timestamp       int64
+	Time            time.Time
+}
+
+// UnmarshalJSON decodes a ticker.
+func (t *DecimalTicker) UnmarshalJSON(data []byte) error {
+	var ticker Ticker
+	if err := json.Unmarshal(data, &ticker); err != nil {
+		return err
+	}
+	var raw struct {
+		Last            Decimal `json:"last"`
+		High            Decimal `json:"high"`
+		Low             Decimal `json:"low"`
+		Ask             Decimal `json:"ask"`
+		Bid             Decimal `json:"bid"`
+		Open            Decimal `json:"open"`
+		Volume          Decimal `json:"volume"`
+		VWAP            Decimal `json:"vwap"`
+		Open24          Decimal `json:"open_24"`
+		PercentChange24 Decimal `json:"percent_change_24"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = DecimalTicker{
+		Last:            raw.Last,
+		High:            raw.High,
+		Low:             raw.Low,
+		Ask:             raw.Ask,
+		Bid:             raw.Bid,
+		Open:            raw.Open,
+		Volume:          raw.Volume,
+		VWAP:            raw.VWAP,
+		Open24:          raw.Open24,
+		PercentChange24: raw.PercentChange24,
+		Side:            ticker.Side,
+		Timestamp:       ticker.Timestamp,
+		Time:            ticker.Time,
+	}
+	return nil
+}
+
+// Float converts the ticker to float values.
+func (t *DecimalTicker) Float() Ticker {
+	return Ticker{
+		Last:            t.Last.Float64(),
+		High:            t.High.Float64(),
+		Low:             t.Low.Float64(),
+		Ask:             t.Ask.Float64(),
+		Bid:             t.Bid.Float64(),
+		Open:            t.Open.Float64(),
+		Volume:          t.Volume.Float64(),
+		VWAP:            t.VWAP.Float64(),
+		Open24:          t.Open24.Float64(),
+		PercentChange24: t.PercentChange24.Float64(),
+		Side:            t.Side,
+		Timestamp:       t.Timestamp,
+		Time:            t.Time,
+	}
+}
+
+// DecimalOrder is like Order, but with a decimal price and amount.
+type DecimalOrder struct {
+	Price  Decimal
+	Amount Decimal
+}
+
+// Float converts the order to float values.
+func (o DecimalOrder) Float() Order {
+	return Order{Price: o.Price.Float64(), Amount: o.Amount.Float64()}
+}
+
+// DecimalOrderBook is like OrderBook, but with decimal prices and amounts.
+type DecimalOrderBook struct {
+	Time time.Time
+	Asks []DecimalOrder
+	Bids []DecimalOrder
+}
+
+// Float converts the order book to float values.
+func (b *DecimalOrderBook) Float() OrderBook {
+	result := OrderBook{Time: b.Time, Asks: make([]Order, len(b.Asks)), Bids: make([]Order, len(b.Bids))}
+	for i, o := range b.Asks {
+		result.Asks[i] = o.Float()
+	}
+	for i, o := range b.Bids {
+		result.Bids[i] = o.Float()
+	}
+	return result
+}
+
+// DecimalTrade is like Trade, but with a decimal price and amount.
+type DecimalTrade struct {
+	Time   time.Time
+	TID    int64
+	Price  Decimal
+	Amount Decimal
+	Type   TradeType
+}
+
+// Float converts the trade to float values.
+func (t DecimalTrade) Float() Trade {
+	return Trade{
+		Time:   t.Time,
+		ID:     fmt.Sprint(t.TID),
+		Price:  t.Price.Float64(),
+		Amount: t.Amount.Float64(),
+		TID:    t.TID,
+		Type:   t.Type,
+	}
+}
+
+// DecimalBalance is like Balance, but with decimal values.
+type DecimalBalance struct {
+	Available Decimal
+	Reserved  Decimal
+	Total     Decimal
+}
+
+// Float converts the balance to float values.
+func (b DecimalBalance) Float() Balance {
+	return Balance{Available: b.Available.Float64(), Reserved: b.Reserved.Float64(), Total: b.Total.Float64()}
+}
+
+// DecimalBalances are like Balances, but with decimal values.
+type DecimalBalances struct {
+	// Currencies maps lowercase currency names to their balances.
+	Currencies map[string]DecimalBalance
+	// Fees maps lowercase pair symbols to the trading fee in percents.
+	Fees map[string]Decimal
+}
+
+// Get returns the balance of the given currency.
+// A zero balance is returned for unknown currencies.
+func (b *DecimalBalances) Get(currency string) DecimalBalance {
+	return b.Currencies[strings.ToLower(currency)]
+}
+
+// GetTickerDecimal is like GetTicker, but returns decimal values.
+func (api *Api) GetTickerDecimal(symbol string) (*DecimalTicker, error) {
+	return api.GetTickerDecimalCtx(context.Background(), symbol)
+}
+
+// GetTickerDecimalCtx is like GetTickerDecimal, but uses the given context.
+func (api *Api) GetTickerDecimalCtx(ctx context.Context, symbol string) (*DecimalTicker, error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/ticker/"+symbol)
+	if err != nil {
+		return nil, err
+	}
+	var ticker DecimalTicker
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return nil, err
+	}
+	return &ticker, nil
+}
+
+// GetOrderBookDecimal is like GetOrderBook, but returns decimal values.
+func (api *Api) GetOrderBookDecimal(symbol string) (*DecimalOrderBook, error) {
+	return api.GetOrderBookDecimalCtx(context.Background(), symbol)
+}
+
+// GetOrderBookDecimalCtx is like GetOrderBookDecimal, but uses the given context.
+func (api *Api) GetOrderBookDecimalCtx(ctx context.Context, symbol string) (*DecimalOrderBook, error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/order_book/"+symbol)
+	if err != nil {
+		return nil, err
+	}
+	book, err := parseDecimalOrderBook(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.checkBook(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func parseDecimalOrderBook(data []byte) (*DecimalOrderBook, error) {
+	var raw struct {
+		Timestamp      *flexibleInt64 `json:"timestamp"`
+		Microtimestamp *flexibleInt64 `json:"microtimestamp"`
+		Bids           *[][]Decimal   `json:"bids"`
+		Asks           *[][]Decimal   `json:"asks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &DecimalOrderBook{Time: bookTime(raw.Timestamp, raw.Microtimestamp)}
+	if raw.Bids == nil {
+		return nil, errors.New("bids are missing")
+	}
+	if raw.Asks == nil {
+		return nil, errors.New("asks are missing")
+	}
+	var err error
+	if result.Bids, err = decimalOrders(*raw.Bids); err != nil {
+		return nil, errors.Wrap(err, "bids parsing error")
+	}
+	if result.Asks, err = decimalOrders(*raw.Asks); err != nil {
+		return nil, errors.Wrap(err, "asks parsing error")
+	}
+	return result, nil
+}
+
+func decimalOrders(levels [][]Decimal) ([]DecimalOrder, error) {
+	result := make([]DecimalOrder, len(levels))
+	for i, level := range levels {
+		if len(level) < 2 {
+			return nil, errors.Errorf("level %d: expected at least 2 elements, got %d", i, len(level))
+		}
+		result[i] = DecimalOrder{Price: level[0], Amount: level[1]}
+	}
+	return result, nil
+}
+
+// GetTradesDecimal is like GetTrades, but returns decimal values.
+func (api *Api) GetTradesDecimal(symbol string) ([]DecimalTrade, error) {
+	return api.GetTradesDecimalCtx(context.Background(), symbol)
+}
+
+// GetTradesDecimalCtx is like GetTradesDecimal, but uses the given context.
+func (api *Api) GetTradesDecimalCtx(ctx context.Context, symbol string) ([]DecimalTrade, error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/transactions/"+symbol)
+	if err != nil {
+		return nil, errors.Wrap(err, "get transactions error")
+	}
+	return parseDecimalTrades(body)
+}
+
+func parseDecimalTrades(data []byte) ([]DecimalTrade, error) {
+	var raw []struct {
+		Date   interface{} `json:"date"`
+		TID    interface{} `json:"tid"`
+		Price  Decimal     `json:"price"`
+		Amount Decimal     `json:"amount"`
+		Type   interface{} `json:"type"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]DecimalTrade, len(raw))
+	for i, r := range raw {
+		date, err := parseIntValue(r.Date)
+		if err != nil {
+			return nil, errors.Wrap(err, "date parsing error")
+		}
+		tid, err := parseIntValue(r.TID)
+		if err != nil {
+			return nil, errors.Wrap(err, "tid parsing error")
+		}
+		typ, err := parseIntValue(r.Type)
+		if err != nil {
+			return nil, errors.Wrap(err, "type parsing error")
+		}
+		if typ != int64(TradeBuy) && typ != int64(TradeSell) {
+			return nil, errors.Errorf("unknown trade type %d", typ)
+		}
+		result[i] = DecimalTrade{Time: time.Unix(date, 0), TID: tid, Price: r.Price, Amount: r.Amount, Type: TradeType(typ)}
+	}
+	return result, nil
+}
+
+// GetAccountBalanceDecimal is like GetAccountBalance, but returns decimal values.
+func (api *Api) GetAccountBalanceDecimal() (*DecimalBalances, error) {
+	return api.GetAccountBalanceDecimalCtx(context.Background())
+}
+
+// GetAccountBalanceDecimalCtx is like GetAccountBalanceDecimal, but uses the given context.
+func (api *Api) GetAccountBalanceDecimalCtx(ctx context.Context) (*DecimalBalances, error) {
+	body, err := api.read(ctx, "/balance/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseDecimalBalances(body)
+}
+
+func parseDecimalBalances(data []byte) (*DecimalBalances, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &DecimalBalances{
+		Currencies: make(map[string]DecimalBalance),
+		Fees:       make(map[string]Decimal),
+	}
+	for key, value := range raw {
+		name, field, ok := splitBalanceKey(key)
+		if !ok || string(value) == "null" {
+			continue
+		}
+		var target *Decimal
+		b := result.Currencies[name]
+		switch field {
+		case "available":
+			target = &b.Available
+		case "balance":
+			target = &b.Total
+		case "reserved":
+			target = &b.Reserved
+		case "fee":
+			var fee Decimal
+			if err := json.Unmarshal(value, &fee); err != nil {
+				return nil, errors.Wrapf(err, "%s parsing error", key)
+			}
+			result.Fees[name] = fee
+			continue
+		default:
+			continue
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			return nil, errors.Wrapf(err, "%s parsing error", key)
+		}
+		result.Currencies[name] = b
+	}
+	return result, nil
+}
+
+// BuyLimitOrderDecimal is like BuyLimitOrder, but the price and the amount are decimals,
+// which are sent as is.
+func (api *Api) BuyLimitOrderDecimal(symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error) {
+	return api.BuyLimitOrderDecimalCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// BuyLimitOrderDecimalCtx is like BuyLimitOrderDecimal, but uses the given context.
+func (api *Api) BuyLimitOrderDecimalCtx(ctx context.Context, symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error) {
+	values, err := limitOrderDecimalValues(price, amount, opts)
+	if err != nil {
+		return nil, err
+	}
+	return api.sendLimitOrder(ctx, "buy", symbol, values, opts)
+}
+
+// SellLimitOrderDecimal is like SellLimitOrder, but the price and the amount are decimals,
+// which are sent as is.
+func (api *Api) SellLimitOrderDecimal(symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error) {
+	return api.SellLimitOrderDecimalCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// SellLimitOrderDecimalCtx is like SellLimitOrderDecimal, but uses the given context.
+func (api *Api) SellLimitOrderDecimalCtx(ctx context.Context, symbol string, price, amount Decimal, opts LimitOrderOpts) (*OrderResult, error) {
+	values, err := limitOrderDecimalValues(price, amount, opts)
+	if err != nil {
+		return nil, err
+	}
+	return api.sendLimitOrder(ctx, "sell", symbol, values, opts)
+}
+
+func limitOrderDecimalValues(price, amount Decimal, opts LimitOrderOpts) (url.Values, error) {
+	if price.Sign() <= 0 {
+		return nil, errors.New("price must be positive")
+	}
+	if amount.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	values := url.Values{}
+	values.Set("price", price.String())
+	values.Set("amount", amount.String())
+	if err := opts.apply(values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
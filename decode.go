@@ -0,0 +1,262 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// datetimeLayout is the layout of datetime fields used by the private endpoints.
+// Fractional seconds are optional.
+const datetimeLayout = "2006-01-02 15:04:05.999999999"
+
+// parseDatetime parses an UTC datetime field.
+func parseDatetime(s string) (time.Time, error) {
+	return time.Parse(datetimeLayout, s)
+}
+
+// flexibleInt64 is an integer encoded as a json string or number, depending on the endpoint or the channel.
+// Fractions of numbers are truncated.
+type flexibleInt64 int64
+
+func (v *flexibleInt64) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*v = flexibleInt64(n)
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return errors.Errorf("invalid integer %s", data)
+	}
+	*v = flexibleInt64(f)
+	return nil
+}
+
+// numberField is a numeric field decoded with parseNumber. The name must be set before decoding.
+// Missing and null fields are zero.
+type numberField struct {
+	name  string
+	value float64
+}
+
+func (f *numberField) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := parseRawNumber(f.name, data)
+	if err != nil {
+		return err
+	}
+	f.value = v
+	return nil
+}
+
+// jsonKind returns the kind of an encoded json value, like "an object", for error messages.
+func jsonKind(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "nothing"
+	}
+	switch data[0] {
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	case '"':
+		return "a string"
+	case 't', 'f':
+		return "a boolean"
+	case 'n':
+		return "null"
+	default:
+		return "a number"
+	}
+}
+
+// unixMicro returns the local time of unix microseconds, like 1567755304968123.
+func unixMicro(micros int64) time.Time {
+	return time.Unix(micros/1e6, micros%1e6*int64(time.Microsecond))
+}
+
+// parseTimeValue converts a decoded json value to time. The value is either
+// a datetime string, or unix seconds encoded as a string or a number.
+func parseTimeValue(value interface{}) (time.Time, error) {
+	var seconds float64
+	switch v := value.(type) {
+	case string:
+		if t, err := parseDatetime(v); err == nil {
+			return t, nil
+		}
+		var err error
+		if seconds, err = strconv.ParseFloat(v, 64); err != nil {
+			return time.Time{}, errors.Errorf("invalid time %q", v)
+		}
+	case float64:
+		seconds = v
+	default:
+		return time.Time{}, errors.Errorf("unexpected value type %T", value)
+	}
+	whole := math.Floor(seconds)
+	micros := math.Round((seconds - whole) * 1e6)
+	return time.Unix(int64(whole), int64(micros)*int64(time.Microsecond)), nil
+}
+
+// parseNumber converts the value of the named numeric field to float64. The value is a decoded
+// json string or number, a json.Number, or an undecoded json.RawMessage. Empty strings are zero,
+// and exponents, like 1e-8, are accepted. The errors name the field, and quote the raw value.
+func parseNumber(field string, value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return parseNumberText(field, v)
+	case json.Number:
+		return parseNumberText(field, string(v))
+	case json.RawMessage:
+		return parseRawNumber(field, v)
+	case nil:
+		return 0, errors.Errorf("%s parsing error: missing value", field)
+	default:
+		return 0, errors.Errorf("%s parsing error: unexpected value type %T", field, value)
+	}
+}
+
+// parseRawNumber is parseNumber for undecoded values, which avoids boxing them on the hot paths.
+func parseRawNumber(field string, raw json.RawMessage) (float64, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		return 0, errors.Errorf("%s parsing error: missing value", field)
+	case raw[0] == '"':
+		if len(raw) < 2 || raw[len(raw)-1] != '"' {
+			return 0, errors.Errorf("%s parsing error: invalid number %s", field, raw)
+		}
+		if bytes.IndexByte(raw, '\\') < 0 {
+			return parseNumberText(field, string(raw[1:len(raw)-1]))
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, errors.Errorf("%s parsing error: invalid number %s", field, raw)
+		}
+		return parseNumberText(field, s)
+	case raw[0] == '-' || raw[0] >= '0' && raw[0] <= '9':
+		return parseNumberText(field, string(raw))
+	default:
+		return 0, errors.Errorf("%s parsing error: expected a number or a string, got %s", field, jsonKind(raw))
+	}
+}
+
+// parseNumberText parses a decimal number, possibly with an exponent.
+// Unlike strconv.ParseFloat, it rejects infinities, NaN, hexadecimal and out of range values.
+func parseNumberText(field, s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c == '.' || c == '-' || c == '+' || c == 'e' || c == 'E') {
+			return 0, invalidNumber(field, s)
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, invalidNumber(field, s)
+	}
+	return v, nil
+}
+
+// rawNumberText returns the text of an undecoded number or string, as it was sent by the api.
+func rawNumberText(raw json.RawMessage) string {
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' && bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1 : len(raw)-1])
+	}
+	var n rawNumber
+	if err := n.UnmarshalJSON(raw); err != nil {
+		return ""
+	}
+	return string(n)
+}
+
+func invalidNumber(field, s string) error {
+	// the copy keeps s from escaping, so that the callers may pass strings converted from bytes without allocating.
+	return errors.Errorf("%s parsing error: invalid number %q", field, string([]byte(s)))
+}
+
+// parseIntValue converts a decoded json value, either a string or a number, to int64.
+func parseIntValue(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, errors.Errorf("unexpected value type %T", value)
+	}
+}
+
+// stringValue converts a decoded json string or number to string.
+// nil is converted to an empty string.
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// pairSymbol converts a pair name, like BTC/USD, to a symbol, like btcusd.
+func pairSymbol(name string) string {
+	return strings.ToLower(strings.Replace(name, "/", "", -1))
+}
+
+// significantDigits is the number of significant digits of formatted values.
+// float64 holds 15 significant decimal digits exactly, the rest are representation errors.
+const significantDigits = 15
+
+// normalizeFloat rounds v to significantDigits, so that 0.1+0.2 becomes 0.3.
+func normalizeFloat(v float64) float64 {
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return v
+	}
+	normalized, err := strconv.ParseFloat(strconv.FormatFloat(v, 'e', significantDigits-1, 64), 64)
+	if err != nil {
+		return v
+	}
+	return normalized
+}
+
+// formatFloat formats a price or an amount as a plain decimal string,
+// without an exponent and representation errors.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(normalizeFloat(v), 'f', -1, 64)
+}
+
+// formatDecimals formats v as a plain decimal string with at most decimals digits after the point.
+// Halves are rounded away from zero.
+func formatDecimals(v float64, decimals int) string {
+	return formatDecimalsMode(v, decimals, RoundHalfUp)
+}
+
+// formatDecimalsMode is like formatDecimals, but rounds with the given mode.
+func formatDecimalsMode(v float64, decimals int, mode RoundingMode) string {
+	s := roundDecimals(normalizeFloat(v), decimals, mode)
+	if strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
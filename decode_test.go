@@ -0,0 +1,196 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v        float64
+		expected string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{1e-5, "0.00001"},
+		{0.00000001, "0.00000001"},
+		{1.5e-8, "0.000000015"},
+		{1e-20, "0.00000000000000000001"},
+		{123456789, "123456789"},
+		{1e21, "1000000000000000000000"},
+		{artifact, "0.3"},
+		{10455.51 * 3, "31366.53"},
+		{1.1 * 1.1, "1.21"},
+		{0.1, "0.1"},
+		{2.675, "2.675"},
+		{-0.00012, "-0.00012"},
+		{10455.12345678, "10455.12345678"},
+		{0.123456789012345678, "0.123456789012346"},
+	}
+	for _, test := range tests {
+		got := formatFloat(test.v)
+		if got != test.expected {
+			t.Errorf("%v: expected %s, got %s", test.v, test.expected, got)
+		}
+		if strings.ContainsAny(got, "eE") {
+			t.Errorf("%v: exponent in %s", test.v, got)
+		}
+	}
+}
+
+func TestFormatDecimals(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		expected string
+	}{
+		{1e-5, 8, "0.00001"},
+		{1e-9, 8, "0"},
+		{5e-9, 8, "0.00000001"},
+		{artifact, 8, "0.3"},
+		{1.005, 2, "1.01"},
+		{2.675, 2, "2.68"},
+		{0.1 * 3, 6, "0.3"},
+		{10.1234567, 6, "10.123457"},
+		{1e15, 8, "1000000000000000"},
+		{123.5, 0, "124"},
+		{100, 2, "100"},
+		{math.MaxInt32 + 0.125, 2, "2147483647.13"},
+	}
+	for _, test := range tests {
+		got := formatDecimals(test.v, test.decimals)
+		if got != test.expected {
+			t.Errorf("%v with %d decimals: expected %s, got %s", test.v, test.decimals, test.expected, got)
+		}
+		if strings.ContainsAny(got, "eE") {
+			t.Errorf("%v: exponent in %s", test.v, got)
+		}
+	}
+}
+
+func TestFlexibleInt64(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected int64
+		err      bool
+	}{
+		{data: `1567755304`, expected: 1567755304},
+		{data: `"1567755304"`, expected: 1567755304},
+		{data: `-1`, expected: -1},
+		{data: `1567755304.0`, expected: 1567755304},
+		{data: `1.567755304e9`, expected: 1567755304},
+		{data: `"1567755304.9"`, expected: 1567755304},
+		{data: `1567755304968123`, expected: 1567755304968123},
+		{data: `"9223372036854775807"`, expected: math.MaxInt64},
+		{data: `null`},
+		{data: `""`, err: true},
+		{data: `"now"`, err: true},
+		{data: `"NaN"`, err: true},
+		{data: `1e19`, err: true},
+		{data: `true`, err: true},
+		{data: `[1]`, err: true},
+		{data: `{}`, err: true},
+	}
+	for _, test := range tests {
+		var v flexibleInt64
+		err := json.Unmarshal([]byte(test.data), &v)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: error expected, got %d", test.data, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.data, err)
+		} else if int64(v) != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.data, test.expected, v)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected float64
+		// err is a part of the expected error.
+		err string
+	}{
+		{data: `"9385.46"`, expected: 9385.46},
+		{data: `9385.46`, expected: 9385.46},
+		{data: `"0.00000000"`},
+		{data: `0`},
+		{data: `-1.5`, expected: -1.5},
+		{data: `"-1.5"`, expected: -1.5},
+		{data: `""`},
+		{data: `" "`},
+		{data: `" 1.5 "`, expected: 1.5},
+		{data: `"1e-8"`, expected: 1e-8},
+		{data: `"1.5E+3"`, expected: 1500},
+		{data: `1.5e3`, expected: 1500},
+		{data: `"1"`, expected: 1},
+		{data: `"1.5"`, expected: 1.5},
+		{data: `null`, err: `amount parsing error: missing value`},
+		{data: `"abc"`, err: `amount parsing error: invalid number "abc"`},
+		{data: `"1,000.5"`, err: `amount parsing error: invalid number "1,000.5"`},
+		{data: `"1.2.3"`, err: `amount parsing error: invalid number "1.2.3"`},
+		{data: `"NaN"`, err: `amount parsing error: invalid number "NaN"`},
+		{data: `"Inf"`, err: `amount parsing error: invalid number "Inf"`},
+		{data: `"0x10"`, err: `amount parsing error: invalid number "0x10"`},
+		{data: `"1e999"`, err: `amount parsing error: invalid number "1e999"`},
+		{data: `true`, err: `amount parsing error`},
+		{data: `[1]`, err: `amount parsing error`},
+		{data: `{}`, err: `amount parsing error`},
+	}
+	check := func(kind, data string, v float64, err error, expected float64, expectedErr string) {
+		t.Helper()
+		if expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), expectedErr) {
+				t.Errorf("%s %s: expected error %q, got %v", kind, data, expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", kind, data, err)
+		} else if v != expected {
+			t.Errorf("%s %s: expected %v, got %v", kind, data, expected, v)
+		}
+	}
+	for _, test := range tests {
+		v, err := parseNumber("amount", json.RawMessage(test.data))
+		check("raw", test.data, v, err, test.expected, test.err)
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(test.data), &decoded); err != nil {
+			t.Fatalf("%s: %v", test.data, err)
+		}
+		v, err = parseNumber("amount", decoded)
+		check("decoded", test.data, v, err, test.expected, test.err)
+
+		field := numberField{name: "amount"}
+		err = json.Unmarshal([]byte(test.data), &field)
+		if test.data == `null` {
+			// null fields are zero.
+			check("field", test.data, field.value, err, 0, "")
+		} else {
+			check("field", test.data, field.value, err, test.expected, test.err)
+		}
+	}
+	if _, err := parseNumber("amount", json.RawMessage(nil)); err == nil || !strings.Contains(err.Error(), "amount parsing error: missing value") {
+		t.Errorf("expected a missing value error, got %v", err)
+	}
+	if v, err := parseNumber("amount", json.Number("")); err != nil || v != 0 {
+		t.Errorf("expected zero, got %v, %v", v, err)
+	}
+	var ticker Ticker
+	if err := json.Unmarshal([]byte(`{"last": 9385.46, "open_24": "", "vwap": "9.4e3"}`), &ticker); err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last != 9385.46 || ticker.Open24 != 0 || ticker.VWAP != 9400 {
+		t.Errorf("unexpected ticker %+v", ticker)
+	}
+	if err := json.Unmarshal([]byte(`{"last": "9385.46", "vwap": "n/a"}`), &ticker); err == nil || !strings.Contains(err.Error(), `vwap parsing error: invalid number "n/a"`) {
+		t.Errorf("expected a vwap error, got %v", err)
+	}
+}
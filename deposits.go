@@ -0,0 +1,63 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DepositAddress is an address for crypto deposits.
+type DepositAddress struct {
+	Address string
+	// DestinationTag is a destination tag of XRP deposits, if any.
+	DestinationTag string
+	// MemoID is a memo of XLM and similar deposits, if any.
+	MemoID string
+}
+
+// GetDepositAddress returns the deposit address for the given currency.
+func (api *Api) GetDepositAddress(currency string) (*DepositAddress, error) {
+	return api.GetDepositAddressCtx(context.Background(), currency)
+}
+
+// GetDepositAddressCtx is like GetDepositAddress, but uses the given context.
+func (api *Api) GetDepositAddressCtx(ctx context.Context, currency string) (*DepositAddress, error) {
+	currency = strings.ToLower(currency)
+	if err := validateCurrency(currency); err != nil {
+		return nil, err
+	}
+	body, err := api.read(ctx, "/"+currency+"_address/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseDepositAddress(body)
+}
+
+// parseDepositAddress decodes either a bare address string, or an object with the address and extra fields.
+func parseDepositAddress(data []byte) (*DepositAddress, error) {
+	var address string
+	if err := json.Unmarshal(data, &address); err == nil {
+		if address == "" {
+			return nil, errors.New("empty address")
+		}
+		return &DepositAddress{Address: address}, nil
+	}
+	var raw struct {
+		Address        string      `json:"address"`
+		DestinationTag interface{} `json:"destination_tag"`
+		MemoID         interface{} `json:"memo_id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Address == "" {
+		return nil, errors.New("empty address")
+	}
+	return &DepositAddress{
+		Address:        raw.Address,
+		DestinationTag: stringValue(raw.DestinationTag),
+		MemoID:         stringValue(raw.MemoID),
+	}, nil
+}
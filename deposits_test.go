@@ -0,0 +1,49 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseDepositAddress(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected DepositAddress
+	}{
+		{
+			fixture:  "testdata/deposit_address_btc.json",
+			expected: DepositAddress{Address: "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa"},
+		},
+		{
+			fixture:  "testdata/deposit_address_eth.json",
+			expected: DepositAddress{Address: "0x5a3b5c0e2b9b5e8d0b4d7a6c5b8e3f2a1d0c9b8a"},
+		},
+		{
+			fixture:  "testdata/deposit_address_xrp.json",
+			expected: DepositAddress{Address: "rDsbeomae4FXwgQTJp9Rs64Qg9vDiTCdBv", DestinationTag: "89012345"},
+		},
+		{
+			fixture:  "testdata/deposit_address_xlm.json",
+			expected: DepositAddress{Address: "GAHK7EEG2WWHVKDNT4CEQFZGKF2LGDSW2IVM4S5DP42RBW3K6BTODB4A", MemoID: "1072345"},
+		},
+	}
+	for _, test := range tests {
+		data, err := ioutil.ReadFile(test.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address, err := parseDepositAddress(data)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.fixture, err)
+			continue
+		}
+		if *address != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.fixture, test.expected, *address)
+		}
+	}
+	for _, invalid := range []string{`""`, `{}`, `[]`, `42`} {
+		if _, err := parseDepositAddress([]byte(invalid)); err == nil {
+			t.Errorf("%s: error expected", invalid)
+		}
+	}
+}
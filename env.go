@@ -0,0 +1,57 @@
+package bitstamp
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvAPIKey    = "BITSTAMP_API_KEY"
+	EnvAPISecret = "BITSTAMP_API_SECRET"
+	EnvBaseURL   = "BITSTAMP_BASE_URL"
+)
+
+// NewFromEnv creates a new api object with the credentials from the BITSTAMP_API_KEY
+// and BITSTAMP_API_SECRET environment variables. If BITSTAMP_BASE_URL is set,
+// it is used as the base url. opts are applied after the environment settings,
+// so they can override them.
+func NewFromEnv(opts ...Option) (*Api, error) {
+	key, err := credentialFromEnv(EnvAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := credentialFromEnv(EnvAPISecret)
+	if err != nil {
+		return nil, err
+	}
+	envOpts := []Option{WithCredentials(key, secret)}
+	if baseURL := os.Getenv(EnvBaseURL); baseURL != "" {
+		envOpts = append(envOpts, func(api *Api) error {
+			return errors.Wrap(WithBaseURL(baseURL)(api), EnvBaseURL)
+		})
+	}
+	return NewClient(append(envOpts, opts...)...)
+}
+
+// credentialFromEnv returns the value of the given variable,
+// checking it is a non-empty alphanumeric string.
+func credentialFromEnv(name string) (string, error) {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return "", errors.Errorf("%s is not set", name)
+	}
+	if value == "" {
+		return "", errors.Errorf("%s is empty", name)
+	}
+	if strings.TrimFunc(value, isAlphanumeric) != "" {
+		return "", errors.Errorf("%s must be alphanumeric", name)
+	}
+	return value, nil
+}
+
+func isAlphanumeric(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,90 @@
+package bitstamp
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// setEnv sets the environment variables, unsetting the empty ones.
+// The returned function restores the previous values.
+func setEnv(vars map[string]string) func() {
+	prev := make(map[string]*string, len(vars))
+	for name, value := range vars {
+		if v, found := os.LookupEnv(name); found {
+			prev[name] = &v
+		} else {
+			prev[name] = nil
+		}
+		if value == "" {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, value)
+		}
+	}
+	return func() {
+		for name, value := range prev {
+			if value == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *value)
+			}
+		}
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	defer setEnv(map[string]string{
+		EnvAPIKey:    "abcDEF123",
+		EnvAPISecret: "secret456",
+		EnvBaseURL:   "http://127.0.0.1:8080/api/v2",
+	})()
+	client := &http.Client{}
+	api, err := NewFromEnv(WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Key != "abcDEF123" || api.Secret != "secret456" {
+		t.Errorf("unexpected credentials %q, %q", api.Key, api.Secret)
+	}
+	if api.BaseURL != "http://127.0.0.1:8080/api/v2" || api.HTTPClient != client {
+		t.Errorf("unexpected settings %+v", *api)
+	}
+	api, err = NewFromEnv(WithBaseURL("https://example.com/api/v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.BaseURL != "https://example.com/api/v2" {
+		t.Errorf("the options must override the environment, got %s", api.BaseURL)
+	}
+}
+
+func TestNewFromEnvErrors(t *testing.T) {
+	tests := []struct {
+		vars     map[string]string
+		expected string
+	}{
+		{vars: map[string]string{EnvAPIKey: "", EnvAPISecret: "secret"}, expected: "BITSTAMP_API_KEY is not set"},
+		{vars: map[string]string{EnvAPIKey: "key", EnvAPISecret: ""}, expected: "BITSTAMP_API_SECRET is not set"},
+		{vars: map[string]string{EnvAPIKey: "key with spaces", EnvAPISecret: "secret"}, expected: "BITSTAMP_API_KEY must be alphanumeric"},
+		{vars: map[string]string{EnvAPIKey: "key", EnvAPISecret: "secret\n"}, expected: "BITSTAMP_API_SECRET must be alphanumeric"},
+		{vars: map[string]string{EnvAPIKey: "key", EnvAPISecret: "secret", EnvBaseURL: "localhost"}, expected: "BITSTAMP_BASE_URL"},
+	}
+	for _, test := range tests {
+		if _, found := test.vars[EnvBaseURL]; !found {
+			test.vars[EnvBaseURL] = ""
+		}
+		restore := setEnv(test.vars)
+		_, err := NewFromEnv()
+		restore()
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("expected %q, got %v", test.expected, err)
+		}
+	}
+	defer setEnv(map[string]string{EnvAPIKey: "key", EnvAPISecret: "secret", EnvBaseURL: ""})()
+	os.Setenv(EnvAPIKey, "")
+	if _, err := NewFromEnv(); err == nil || !strings.Contains(err.Error(), "BITSTAMP_API_KEY is empty") {
+		t.Errorf("expected an empty key error, got %v", err)
+	}
+}
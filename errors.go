@@ -0,0 +1,407 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrOrderNotFound is returned if the requested order does not exist.
+	// Api errors about missing orders match it.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrPartiallyCanceled is returned if some of the orders were not canceled.
+	ErrPartiallyCanceled = errors.New("some orders were not canceled")
+	// ErrOrderNotFilled is returned if a fill-or-kill or an immediate-or-cancel
+	// order could not be executed.
+	ErrOrderNotFilled = errors.New("order could not be filled")
+	// ErrAlreadyFilled is returned if an order to be replaced was already fully executed.
+	ErrAlreadyFilled = errors.New("order already filled")
+	// ErrInsufficientFunds is returned if there is not enough balance for the operation.
+	// Api errors about insufficient balance match it.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrWithdrawalNotAllowed is returned if withdrawals are not allowed for the account or the api key.
+	ErrWithdrawalNotAllowed = errors.New("withdrawal not allowed")
+	// ErrSubAccountNotFound is returned if the requested sub account does not exist.
+	ErrSubAccountNotFound = errors.New("sub account not found")
+	// ErrBadResponseSignature is returned if a response signature does not match the expected one.
+	ErrBadResponseSignature = errors.New("bad response signature")
+	// ErrTravelRuleInfoRequired is returned if a withdrawal was rejected because of missing
+	// or invalid beneficiary information. See TravelRuleInfo.
+	ErrTravelRuleInfoRequired = errors.New("travel rule information required")
+	// ErrUnknownSymbol is returned if a symbol is not in the list of trading pairs.
+	// See EnableSymbolValidation. Api errors about invalid currency pairs match it.
+	ErrUnknownSymbol = errors.New("unknown symbol")
+	// ErrUnknownCurrency is returned if a currency is in none of the cached trading pairs.
+	ErrUnknownCurrency = errors.New("unknown currency")
+	// ErrBelowMinimumOrder is returned if an order value is below the minimum of the pair.
+	// The error also matches *MinimumOrderError, if it was detected before sending the order.
+	// Api errors about the minimum order size match it.
+	ErrBelowMinimumOrder = errors.New("order below minimum")
+	// ErrUnknownAccount is returned if an account passed to WithAccount is not configured.
+	ErrUnknownAccount = errors.New("unknown account")
+	// ErrRateLimited is returned if a request was rejected because of the rate limit.
+	// The error also matches *RateLimitError, if the response status code is 429.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrAuthentication is matched by api errors about invalid credentials, signatures or permissions.
+	ErrAuthentication = errors.New("authentication failed")
+	// ErrMaintenance is matched by api errors returned, when the api is unavailable because of maintenance.
+	ErrMaintenance = errors.New("api under maintenance")
+	// ErrNoCredentials is returned by private requests of an api object without a key and a secret.
+	// The requests are not sent.
+	ErrNoCredentials = errors.New("no api credentials")
+	// ErrUnsortedBook is returned if the bids of an order book are not sorted by descending prices,
+	// or the asks are not sorted by ascending prices. See BookValidation.
+	ErrUnsortedBook = errors.New("unsorted order book")
+	// ErrCrossedBook is returned if the best bid of an order book is not below the best ask.
+	// See BookValidation.
+	ErrCrossedBook = errors.New("crossed order book")
+
+	// ErrInvalidSymbol is the same error as ErrUnknownSymbol.
+	ErrInvalidSymbol = ErrUnknownSymbol
+	// ErrMinimumOrderSize is the same error as ErrBelowMinimumOrder.
+	ErrMinimumOrderSize = ErrBelowMinimumOrder
+	// ErrWrongChannelType is returned by the decoding methods of WsEvent, if the event
+	// is from a channel of another type.
+	ErrWrongChannelType = errors.New("wrong channel type")
+)
+
+// APIError is an error returned by the api in a response body,
+// or a response with a non-2xx status code.
+type APIError struct {
+	// StatusCode is the http status code of the response.
+	// It is zero for errors decoded without a response.
+	StatusCode int
+	// Code is an api error code, like API0004. May be empty.
+	Code string
+	// Reason is a human readable error description.
+	// If the response body is not an api error, it is the status text.
+	Reason string
+	// Fields maps request field names to their errors, if the api reported them.
+	// Errors not related to a particular field are stored under the "__all__" key.
+	Fields map[string][]string
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	msg := "api error"
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.StatusCode != 0 && !isSuccessStatus(e.StatusCode) {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	return msg + ": " + e.Reason
+}
+
+// Is reports whether the error falls into the class of a sentinel error,
+// like ErrInsufficientFunds, based on the status code and the reason.
+func (e *APIError) Is(target error) bool {
+	sentinel := apiErrorSentinel(e)
+	return sentinel != nil && sentinel == target
+}
+
+// apiErrorStatuses maps http status codes to sentinel errors.
+var apiErrorStatuses = map[int]error{
+	http.StatusTooManyRequests:    ErrRateLimited,
+	http.StatusUnauthorized:       ErrAuthentication,
+	http.StatusForbidden:          ErrAuthentication,
+	http.StatusServiceUnavailable: ErrMaintenance,
+}
+
+// apiErrorPatterns maps lowercase reason fragments to sentinel errors.
+var apiErrorPatterns = []struct {
+	fragment string
+	sentinel error
+}{
+	{"rate limit", ErrRateLimited},
+	{"too many requests", ErrRateLimited},
+	{"maintenance", ErrMaintenance},
+	{"api key", ErrAuthentication},
+	{"signature", ErrAuthentication},
+	{"permission", ErrAuthentication},
+	{"authentication", ErrAuthentication},
+	{"order not found", ErrOrderNotFound},
+	{"you have only", ErrInsufficientFunds},
+	{"insufficient", ErrInsufficientFunds},
+	{"not enough", ErrInsufficientFunds},
+	{"minimum order size", ErrBelowMinimumOrder},
+	{"currency pair", ErrUnknownSymbol},
+}
+
+// apiErrorSentinel returns the sentinel error matching an api error, or nil.
+// The reason takes precedence over the status code.
+func apiErrorSentinel(e *APIError) error {
+	reason := strings.ToLower(e.Reason)
+	for _, p := range apiErrorPatterns {
+		if strings.Contains(reason, p.fragment) {
+			return p.sentinel
+		}
+	}
+	return apiErrorStatuses[e.StatusCode]
+}
+
+func isSuccessStatus(code int) bool {
+	return code >= 200 && code < 300
+}
+
+// responseError returns an *APIError for a response with a non-2xx status code,
+// or with an api error in the body, and nil otherwise.
+// If the body is an api error, its code and reason are used.
+func responseError(statusCode int, body []byte) error {
+	// large successful responses, like order books, must not be decoded twice.
+	if isSuccessStatus(statusCode) && !mayBeAPIError(body) {
+		return nil
+	}
+	apiErr, ok := parseAPIError(body).(*APIError)
+	switch {
+	case ok:
+	case isSuccessStatus(statusCode):
+		return nil
+	default:
+		apiErr = &APIError{Reason: http.StatusText(statusCode)}
+	}
+	apiErr.StatusCode, apiErr.Body = statusCode, body
+	return apiErr
+}
+
+// ResponseSignatureError describes a response with an invalid X-Server-Auth-Signature header.
+type ResponseSignatureError struct {
+	Expected string
+	// Received is the header value. It is empty if the header is absent.
+	Received string
+}
+
+func (e *ResponseSignatureError) Error() string {
+	if e.Received == "" {
+		return "no signature, expected " + e.Expected
+	}
+	return fmt.Sprintf("expected signature %s, got %s", e.Expected, e.Received)
+}
+
+// MinimumOrderError describes an order, which value is below the minimum of the pair.
+type MinimumOrderError struct {
+	// Value is the order value in Currency.
+	Value float64
+	// Minimum is the minimum order value in Currency.
+	Minimum  float64
+	Currency string
+}
+
+func (e *MinimumOrderError) Error() string {
+	return fmt.Sprintf("order value %v %s is below the minimum of %v %s", e.Value, e.Currency, e.Minimum, e.Currency)
+}
+
+// sentinelError binds a sentinel error to an underlying error,
+// so that both errors.Is(err, sentinel) and errors.As(err, &apiErr) work.
+type sentinelError struct {
+	sentinel error
+	err      error
+}
+
+func (e *sentinelError) Error() string {
+	return e.sentinel.Error() + ": " + e.err.Error()
+}
+
+func (e *sentinelError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.err
+}
+
+// withSentinel returns an error matching both sentinel and err.
+func withSentinel(sentinel, err error) error {
+	return &sentinelError{sentinel: sentinel, err: err}
+}
+
+// OrderErrorKind is a category of an order placement error.
+type OrderErrorKind int
+
+const (
+	// OrderErrorOther is an error, which doesn't fall into other categories.
+	OrderErrorOther OrderErrorKind = iota
+	// OrderErrorInsufficientFunds means there is not enough balance for the order.
+	OrderErrorInsufficientFunds
+	// OrderErrorMinimumSize means the order is smaller than the minimum order size.
+	OrderErrorMinimumSize
+	// OrderErrorPriceBand means the price is too far from the market price.
+	OrderErrorPriceBand
+	// OrderErrorPrecision means the price or the amount has too many decimals.
+	OrderErrorPrecision
+)
+
+func (k OrderErrorKind) String() string {
+	switch k {
+	case OrderErrorInsufficientFunds:
+		return "insufficient funds"
+	case OrderErrorMinimumSize:
+		return "minimum order size"
+	case OrderErrorPriceBand:
+		return "price band"
+	case OrderErrorPrecision:
+		return "invalid precision"
+	default:
+		return "other"
+	}
+}
+
+// orderErrorPatterns maps lowercase message fragments to error categories.
+var orderErrorPatterns = []struct {
+	fragment string
+	kind     OrderErrorKind
+}{
+	{"you have only", OrderErrorInsufficientFunds},
+	{"insufficient", OrderErrorInsufficientFunds},
+	{"not enough", OrderErrorInsufficientFunds},
+	{"minimum order size", OrderErrorMinimumSize},
+	{"above market price", OrderErrorPriceBand},
+	{"below market price", OrderErrorPriceBand},
+	{"decimal places", OrderErrorPrecision},
+	{"precision", OrderErrorPrecision},
+}
+
+// OrderError is an api error returned for an order placement request.
+type OrderError struct {
+	Kind OrderErrorKind
+	// Fields maps request field names to their errors.
+	// Errors not related to a particular field are stored under the "__all__" key.
+	Fields map[string][]string
+	// Err is the original api error.
+	Err *APIError
+}
+
+func (e *OrderError) Error() string {
+	if e.Kind == OrderErrorOther {
+		return "order error: " + e.Err.Reason
+	}
+	return fmt.Sprintf("order error (%s): %s", e.Kind, e.Err.Reason)
+}
+
+// Unwrap returns the original api error.
+func (e *OrderError) Unwrap() error {
+	return e.Err
+}
+
+// newOrderError converts an api error to an *OrderError.
+// Rate limit errors and other errors are returned as is.
+func newOrderError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || errors.Is(err, ErrRateLimited) {
+		return err
+	}
+	return &OrderError{
+		Kind:   classifyOrderError(apiErr),
+		Fields: apiErr.Fields,
+		Err:    apiErr,
+	}
+}
+
+func classifyOrderError(apiErr *APIError) OrderErrorKind {
+	reason := strings.ToLower(apiErr.Reason)
+	for _, p := range orderErrorPatterns {
+		if strings.Contains(reason, p.fragment) {
+			return p.kind
+		}
+	}
+	return OrderErrorOther
+}
+
+// mayBeAPIError cheaply checks, whether body may be an error envelope, which is an object containing "error".
+func mayBeAPIError(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '{' && bytes.Contains(body, []byte(`"error"`))
+}
+
+// parseAPIError returns an *APIError if data is an error response, and nil otherwise.
+func parseAPIError(data []byte) error {
+	var resp struct {
+		Status string          `json:"status"`
+		Reason json.RawMessage `json:"reason"`
+		Code   string          `json:"code"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		// not an object, so it is not an error response.
+		return nil
+	}
+	switch {
+	case resp.Status == "error":
+		reason, fields := parseReason(resp.Reason)
+		return &APIError{Code: resp.Code, Reason: reason, Fields: fields}
+	case resp.Error != "":
+		return &APIError{Code: resp.Code, Reason: resp.Error}
+	}
+	return nil
+}
+
+// parseReason decodes the reason field, which is either a string,
+// or an object mapping field names to lists of errors.
+func parseReason(reason json.RawMessage) (string, map[string][]string) {
+	var str string
+	if err := json.Unmarshal(reason, &str); err == nil {
+		return str, nil
+	}
+	var fields map[string][]string
+	if err := json.Unmarshal(reason, &fields); err != nil {
+		return string(reason), nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		msg := strings.Join(fields[name], " ")
+		if name != "__all__" {
+			msg = name + ": " + msg
+		}
+		parts = append(parts, msg)
+	}
+	return strings.Join(parts, "; "), fields
+}
+
+// apiReasonContains checks if err is an api error, which reason contains
+// any of the given lowercase fragments.
+func apiReasonContains(err error, fragments ...string) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	reason := strings.ToLower(apiErr.Reason)
+	for _, fragment := range fragments {
+		if strings.Contains(reason, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNotFound checks if err is an api error about a missing object.
+func isNotFound(err error) bool {
+	return apiReasonContains(err, "not found")
+}
+
+// isTimestampError checks if err is an api error about an invalid nonce or timestamp.
+func isTimestampError(err error) bool {
+	return apiReasonContains(err, "nonce", "timestamp")
+}
+
+// notFilledReasons are the lowercase api reasons of the FOK and IOC orders, which could not be filled.
+var notFilledReasons = []string{
+	"fill or kill order could not be filled",
+	"immediate or cancel order could not be filled",
+}
+
+// isNotFilled checks if err is an api error about an order, which could not be filled.
+func isNotFilled(err error) bool {
+	return apiReasonContains(err, notFilledReasons...)
+}
@@ -0,0 +1,326 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected *APIError
+	}{
+		{
+			data:     `{"status": "error", "reason": "Invalid nonce", "code": "API0004"}`,
+			expected: &APIError{Code: "API0004", Reason: "Invalid nonce"},
+		},
+		{
+			data:     `{"status": "error", "reason": {"__all__": ["Missing amount"]}}`,
+			expected: &APIError{Reason: "Missing amount", Fields: map[string][]string{"__all__": {"Missing amount"}}},
+		},
+		{
+			data: `{"status": "error", "reason": {"price": ["Price is more than 20% above market price."], "amount": ["Too small.", "Invalid."]}}`,
+			expected: &APIError{
+				Reason: "amount: Too small. Invalid.; price: Price is more than 20% above market price.",
+				Fields: map[string][]string{
+					"price":  {"Price is more than 20% above market price."},
+					"amount": {"Too small.", "Invalid."},
+				},
+			},
+		},
+		{
+			data:     `{"error": "Invalid currency pair"}`,
+			expected: &APIError{Reason: "Invalid currency pair"},
+		},
+		{data: `{"usd_balance": "1.00"}`},
+		{data: `[{"tid": "1"}]`},
+		{data: `not json`},
+	}
+	for _, test := range tests {
+		err := parseAPIError([]byte(test.data))
+		if test.expected == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.data, err)
+			}
+			continue
+		}
+		apiErr, ok := err.(*APIError)
+		if !ok {
+			t.Errorf("%s: expected *APIError, got %v", test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(apiErr, test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.data, *test.expected, *apiErr)
+		}
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	if !isNotFound(&APIError{Reason: "Order not found."}) {
+		t.Error("not found error expected")
+	}
+	if isNotFound(&APIError{Reason: "Invalid nonce"}) {
+		t.Error("unexpected not found error")
+	}
+	err := withSentinel(ErrOrderNotFound, &APIError{Reason: "Order not found."})
+	if !errors.Is(err, ErrOrderNotFound) {
+		t.Error("ErrOrderNotFound expected")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Error("*APIError expected")
+	}
+}
+
+func TestOrderError(t *testing.T) {
+	tests := []struct {
+		data   string
+		kind   OrderErrorKind
+		fields []string
+	}{
+		{
+			data:   `{"status": "error", "reason": {"__all__": ["You have only 10.00 USD available. Check your account balance for details."]}}`,
+			kind:   OrderErrorInsufficientFunds,
+			fields: []string{"__all__"},
+		},
+		{
+			data:   `{"status": "error", "reason": {"__all__": ["Minimum order size is 25.0 USD."]}}`,
+			kind:   OrderErrorMinimumSize,
+			fields: []string{"__all__"},
+		},
+		{
+			data:   `{"status": "error", "reason": {"price": ["Price is more than 20% above market price."]}}`,
+			kind:   OrderErrorPriceBand,
+			fields: []string{"price"},
+		},
+		{
+			data:   `{"status": "error", "reason": {"amount": ["Ensure that there are no more than 8 decimal places."]}}`,
+			kind:   OrderErrorPrecision,
+			fields: []string{"amount"},
+		},
+		{
+			data: `{"status": "error", "reason": "Invalid nonce", "code": "API0004"}`,
+			kind: OrderErrorOther,
+		},
+	}
+	for _, test := range tests {
+		err := newOrderError(parseAPIError([]byte(test.data)))
+		var orderErr *OrderError
+		if !errors.As(err, &orderErr) {
+			t.Errorf("%s: expected *OrderError, got %v", test.data, err)
+			continue
+		}
+		if orderErr.Kind != test.kind {
+			t.Errorf("%s: expected kind %s, got %s", test.data, test.kind, orderErr.Kind)
+		}
+		for _, field := range test.fields {
+			if len(orderErr.Fields[field]) == 0 {
+				t.Errorf("%s: no errors for field %s", test.data, field)
+			}
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: the api error must be accessible", test.data)
+		}
+	}
+	transportErr := errors.New("connection reset")
+	if err := newOrderError(transportErr); err != transportErr {
+		t.Errorf("expected the original error, got %v", err)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		code     int
+		body     string
+		expected *APIError
+		msg      string
+	}{
+		{
+			code:     404,
+			body:     `<html><body>Not Found</body></html>`,
+			expected: &APIError{StatusCode: 404, Reason: "Not Found", Body: []byte(`<html><body>Not Found</body></html>`)},
+			msg:      "api error (status 404): Not Found",
+		},
+		{
+			code:     403,
+			body:     `{"status": "error", "reason": "Invalid signature", "code": "API0005"}`,
+			expected: &APIError{StatusCode: 403, Code: "API0005", Reason: "Invalid signature", Body: []byte(`{"status": "error", "reason": "Invalid signature", "code": "API0005"}`)},
+			msg:      "api error API0005 (status 403): Invalid signature",
+		},
+		{
+			code: 200,
+			body: `{"status": "error", "reason": "Invalid nonce", "code": "API0004"}`,
+			expected: &APIError{
+				StatusCode: 200, Code: "API0004", Reason: "Invalid nonce",
+				Body: []byte(`{"status": "error", "reason": "Invalid nonce", "code": "API0004"}`),
+			},
+			msg: "api error API0004: Invalid nonce",
+		},
+		{
+			code: 200,
+			body: `{"status": "error", "reason": {"amount": ["Ensure this value is greater than or equal to 1E-8."]}}`,
+			expected: &APIError{
+				StatusCode: 200, Reason: "amount: Ensure this value is greater than or equal to 1E-8.",
+				Fields: map[string][]string{"amount": {"Ensure this value is greater than or equal to 1E-8."}},
+				Body:   []byte(`{"status": "error", "reason": {"amount": ["Ensure this value is greater than or equal to 1E-8."]}}`),
+			},
+			msg: "api error: amount: Ensure this value is greater than or equal to 1E-8.",
+		},
+	}
+	for _, test := range tests {
+		err := responseError(test.code, []byte(test.body))
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%d: expected *APIError, got %v", test.code, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expected, apiErr) {
+			t.Errorf("%d: expected %+v, got %+v", test.code, test.expected, apiErr)
+		}
+		if err.Error() != test.msg {
+			t.Errorf("%d: expected %q, got %q", test.code, test.msg, err.Error())
+		}
+	}
+	for _, body := range []string{`{"last": "1.5"}`, `[]`, `{"status": "ok"}`, `{"status": "ok", "reason": "error"}`} {
+		if err := responseError(200, []byte(body)); err != nil {
+			t.Errorf("%s: unexpected error %v", body, err)
+		}
+	}
+	for _, body := range []string{`{"status":"error","reason":"x"}`, " \n{\"error\": \"x\"}"} {
+		if err := responseError(200, []byte(body)); err == nil {
+			t.Errorf("%s: error expected", body)
+		}
+	}
+	// successful responses without an error envelope are not decoded.
+	book, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { responseError(200, book) }); allocs != 0 {
+		t.Errorf("expected no allocations for an order book, got %v", allocs)
+	}
+}
+
+func TestResponseStatus(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(url.Values) (int, string) {
+			return 403, `{"status": "error", "reason": "Invalid signature", "code": "API0005"}`
+		},
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, `{"status": "error", "reason": "Invalid currency pair", "code": "API0003"}`
+		},
+	})
+	defer restore()
+	var apiErr *APIError
+	if _, err := New("", "").GetTicker("xyzusd"); !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("expected a 404 api error, got %v", err)
+	}
+	if _, err := NewWithKey("key", "secret").GetAccountBalance(); !errors.As(err, &apiErr) || apiErr.StatusCode != 403 {
+		t.Errorf("expected a 403 api error, got %v", err)
+	}
+	if _, err := New("", "").GetTicker("btcusd"); !errors.As(err, &apiErr) || apiErr.Code != "API0003" {
+		t.Errorf("expected an api error from the body, got %v", err)
+	}
+}
+
+func TestAPIErrorSentinels(t *testing.T) {
+	// every error fixture must be listed, with the status code it is recorded with.
+	fixtures := map[string]struct {
+		statusCode int
+		sentinel   error
+	}{
+		"error_api_key_not_found.json":  {403, ErrAuthentication},
+		"error_insufficient_order.json": {400, ErrInsufficientFunds},
+		"error_invalid_pair.json":       {200, ErrUnknownSymbol},
+		"error_invalid_signature.json":  {403, ErrAuthentication},
+		"error_maintenance.json":        {503, ErrMaintenance},
+		"error_order_not_found.json":    {404, ErrOrderNotFound},
+		"error_rate_limited.json":       {429, ErrRateLimited},
+		"market_order_min_size.json":    {200, ErrBelowMinimumOrder},
+		"withdrawal_insufficient.json":  {200, ErrInsufficientFunds},
+		"withdrawal_not_allowed.json":   {200, nil},
+		"withdrawal_travel_rule.json":   {200, nil},
+	}
+	sentinels := []error{ErrRateLimited, ErrAuthentication, ErrMaintenance, ErrOrderNotFound,
+		ErrInsufficientFunds, ErrBelowMinimumOrder, ErrUnknownSymbol}
+	files, err := filepath.Glob("testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Base(file)
+		fixture, found := fixtures[name]
+		if parseAPIError(data) == nil {
+			if found {
+				t.Errorf("%s: not an api error", name)
+			}
+			continue
+		}
+		if !found {
+			t.Errorf("%s: the error fixture is not listed", name)
+			continue
+		}
+		err = responseError(fixture.statusCode, data)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !strings.Contains(err.Error(), apiErr.Reason) {
+			t.Errorf("%s: expected an api error, got %v", name, err)
+		}
+		for _, sentinel := range sentinels {
+			if matched := errors.Is(err, sentinel); matched != (sentinel == fixture.sentinel) {
+				t.Errorf("%s: errors.Is(%v) = %v", name, sentinel, matched)
+			}
+		}
+	}
+	// the status code is used, if the reason is not recognized.
+	for code, sentinel := range map[int]error{401: ErrAuthentication, 403: ErrAuthentication, 429: ErrRateLimited, 503: ErrMaintenance, 500: nil} {
+		err := responseError(code, []byte(`<html></html>`))
+		if sentinel != nil && !errors.Is(err, sentinel) {
+			t.Errorf("%d: expected %v, got %v", code, sentinel, err)
+		}
+		if sentinel == nil && errors.Is(err, ErrMaintenance) {
+			t.Errorf("%d: unexpected %v", code, ErrMaintenance)
+		}
+	}
+}
+
+func TestAPIErrorSentinelsThroughRequests(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(url.Values) (int, string) {
+			return http.StatusForbidden, `{"status": "error", "reason": "Invalid signature", "code": "API0005"}`
+		},
+		"/buy/btcusd/": func(url.Values) (int, string) {
+			return http.StatusOK, `{"status": "error", "reason": {"__all__": ["You have only 10.00 USD available."]}}`
+		},
+	})
+	defer restore()
+	api := NewWithKey("key", "secret")
+	if _, err := api.GetAccountBalance(); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected %v, got %v", ErrAuthentication, err)
+	}
+	_, err := api.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{})
+	var orderErr *OrderError
+	if !errors.Is(err, ErrInsufficientFunds) || !errors.As(err, &orderErr) {
+		t.Errorf("expected an order error matching %v, got %v", ErrInsufficientFunds, err)
+	}
+}
+
+func TestErrorAliases(t *testing.T) {
+	if !errors.Is(ErrUnknownSymbol, ErrInvalidSymbol) {
+		t.Error("ErrUnknownSymbol must match ErrInvalidSymbol")
+	}
+	if !errors.Is(withSentinel(ErrBelowMinimumOrder, &MinimumOrderError{}), ErrMinimumOrderSize) {
+		t.Error("ErrBelowMinimumOrder must match ErrMinimumOrderSize")
+	}
+}
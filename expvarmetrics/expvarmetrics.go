@@ -0,0 +1,65 @@
+// Package expvarmetrics publishes the measurements of a bitstamp client with expvar.
+// It is an example of a bitstamp.Metrics adapter: adapters for Prometheus
+// or other metrics libraries can be written the same way, without adding
+// their dependencies to the bitstamp package.
+package expvarmetrics
+
+import (
+	"expvar"
+	"strconv"
+	"time"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+)
+
+var _ bitstamp.Metrics = (*Metrics)(nil)
+
+// Metrics is a bitstamp.Metrics, which counts the measurements in expvar maps.
+type Metrics struct {
+	// requests counts rest requests by "endpoint code" keys.
+	requests *expvar.Map
+	// requestSeconds sums the request latencies by endpoint.
+	requestSeconds *expvar.Map
+	// wsMessages and wsBytes count websocket messages and their sizes by channel.
+	wsMessages *expvar.Map
+	wsBytes    *expvar.Map
+	// errors counts errors by kind.
+	errors *expvar.Map
+}
+
+// New creates metrics published as an expvar map with the given name.
+// The map has requests, request_seconds, ws_messages, ws_bytes and errors keys.
+// Like expvar.Publish, it panics if the name is already used.
+func New(name string) *Metrics {
+	m := &Metrics{
+		requests:       new(expvar.Map).Init(),
+		requestSeconds: new(expvar.Map).Init(),
+		wsMessages:     new(expvar.Map).Init(),
+		wsBytes:        new(expvar.Map).Init(),
+		errors:         new(expvar.Map).Init(),
+	}
+	root := expvar.NewMap(name)
+	root.Set("requests", m.requests)
+	root.Set("request_seconds", m.requestSeconds)
+	root.Set("ws_messages", m.wsMessages)
+	root.Set("ws_bytes", m.wsBytes)
+	root.Set("errors", m.errors)
+	return m
+}
+
+// ObserveRequest implements bitstamp.Metrics.
+func (m *Metrics) ObserveRequest(endpoint string, code int, d time.Duration) {
+	m.requests.Add(endpoint+" "+strconv.Itoa(code), 1)
+	m.requestSeconds.AddFloat(endpoint, d.Seconds())
+}
+
+// ObserveWSMessage implements bitstamp.Metrics.
+func (m *Metrics) ObserveWSMessage(channel string, bytes int) {
+	m.wsMessages.Add(channel, 1)
+	m.wsBytes.Add(channel, int64(bytes))
+}
+
+// ObserveError implements bitstamp.Metrics.
+func (m *Metrics) ObserveError(kind string) {
+	m.errors.Add(kind, 1)
+}
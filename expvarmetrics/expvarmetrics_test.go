@@ -0,0 +1,36 @@
+package expvarmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"testing"
+	"time"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New("bitstamp_test")
+	m.ObserveRequest("/balance/", 200, time.Second)
+	m.ObserveRequest("/balance/", 200, time.Second/2)
+	m.ObserveRequest("/balance/", 403, time.Second)
+	m.ObserveWSMessage("live_trades_btcusd", 100)
+	m.ObserveWSMessage("live_trades_btcusd", 50)
+	m.ObserveError(bitstamp.ErrorKindAuthentication)
+
+	var published map[string]map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("bitstamp_test").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[string]float64{
+		"requests":        {"/balance/ 200": 2, "/balance/ 403": 1},
+		"request_seconds": {"/balance/": 2.5},
+		"ws_messages":     {"live_trades_btcusd": 2},
+		"ws_bytes":        {"live_trades_btcusd": 150},
+		"errors":          {"authentication": 1},
+	}
+	if !reflect.DeepEqual(published, expected) {
+		t.Errorf("expected %v, got %v", expected, published)
+	}
+}
@@ -4,5 +4,5 @@ go 1.13
 
 require (
 	github.com/gorilla/websocket v1.4.1
-	github.com/pkg/errors v0.8.0
+	github.com/pkg/errors v0.9.1
 )
@@ -0,0 +1,116 @@
+package bitstamp
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// redacted replaces the request signature in the requests passed to hooks.
+const redacted = "REDACTED"
+
+// RequestHook is called before a rest request is sent.
+// It receives a copy of the request with the final url and headers, and the signature redacted.
+// Headers added or changed by the hook are sent with the request, except for the
+// authentication headers and Content-Type. Changes of the url and the body are ignored,
+// so hooks can't break the signature.
+type RequestHook func(req *http.Request)
+
+// ResponseHook is called after a rest request.
+// resp is nil if no response was received. Its body can be read by the hook.
+// latency is the time from sending the request to reading the response body,
+// and err is the transport error, if any.
+type ResponseHook func(resp *http.Response, latency time.Duration, err error)
+
+// do sends the request, calling the hooks, and returns the response with its body.
+// If the request context has no deadline, Api.Timeout is applied.
+// resp is not nil if a response was received, even if its body could not be read.
+func (api *Api) do(req *http.Request) (resp *http.Response, body []byte, err error) {
+	if _, ok := req.Context().Deadline(); !ok && api.timeout() > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), api.timeout())
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	api.setHeaders(req.Header)
+	if api.HTTPClient == nil && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	api.runRequestHooks(req)
+	if api.debug != nil {
+		api.debug.dumpRequest(req, api.Key, api.Secret)
+	}
+	release, err := api.acquireRequest(req.Context())
+	if err != nil {
+		api.observeError(err)
+		return nil, nil, err
+	}
+	start := time.Now()
+	resp, err = api.httpClient().Do(req)
+	if err == nil {
+		body, err = readBody(resp)
+	}
+	latency := time.Since(start)
+	release()
+	api.runResponseHooks(resp, body, latency, err)
+	setCallInfo(req.Context(), resp, body, latency)
+	if api.debug != nil {
+		api.debug.dumpResponse(req, resp, body, latency, err, api.Key, api.Secret)
+	}
+	var code int
+	if resp != nil {
+		code = resp.StatusCode
+	}
+	api.metrics().ObserveRequest(api.endpoint(req.URL), code, latency)
+	traceStatus(req.Context(), code)
+	if err != nil {
+		err = requestError(req.Context(), err)
+		api.observeError(err)
+	}
+	return resp, body, err
+}
+
+func (api *Api) runRequestHooks(req *http.Request) {
+	if len(api.RequestHooks) == 0 {
+		return
+	}
+	hookReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		hookReq.Body, _ = req.GetBody()
+	}
+	if hookReq.Header.Get("X-Auth-Signature") != "" {
+		hookReq.Header.Set("X-Auth-Signature", redacted)
+	}
+	for _, hook := range api.RequestHooks {
+		hook(hookReq)
+	}
+	for k := range req.Header {
+		if _, found := hookReq.Header[k]; !found && !isProtectedHeader(k) {
+			delete(req.Header, k)
+		}
+	}
+	for k, v := range hookReq.Header {
+		if !isProtectedHeader(k) {
+			req.Header[k] = v
+		}
+	}
+}
+
+func (api *Api) runResponseHooks(resp *http.Response, body []byte, latency time.Duration, err error) {
+	for _, hook := range api.ResponseHooks {
+		var hookResp *http.Response
+		if resp != nil {
+			copied := *resp
+			copied.Body = ioutil.NopCloser(bytes.NewReader(body))
+			hookResp = &copied
+		}
+		hook(hookResp, latency, err)
+	}
+}
+
+// isProtectedHeader checks if the canonical header key can't be changed by request hooks.
+func isProtectedHeader(key string) bool {
+	return strings.HasPrefix(key, "X-Auth") || key == "Content-Type"
+}
@@ -0,0 +1,166 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRequestHooks(t *testing.T) {
+	var balanceValues url.Values
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(values url.Values) (int, string) {
+			balanceValues = values
+			return 200, `{"usd_available": "1.00"}`
+		},
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, `{"last": "10455.51"}`
+		},
+	})
+	defer restore()
+	var sent []http.Header
+	fake.header = func(req *http.Request, _ string) http.Header {
+		sent = append(sent, req.Header.Clone())
+		return nil
+	}
+	var seen []*http.Request
+	var seenURLs, seenBodies []string
+	api, err := NewClient(
+		WithCredentials("key", "secret"),
+		WithRequestHook(func(req *http.Request) {
+			seen = append(seen, req)
+			seenURLs = append(seenURLs, req.URL.String())
+			if req.Body != nil {
+				body, _ := ioutil.ReadAll(req.Body)
+				seenBodies = append(seenBodies, string(body))
+			}
+			req.Header.Set("X-Correlation-Id", "42")
+			req.Header.Set("X-Auth-Signature", "broken")
+			req.Header.Set("Content-Type", "text/plain")
+			req.Body = ioutil.NopCloser(strings.NewReader("amount=100"))
+			req.URL.Path = "/api/v2/withdrawal/"
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	// the endpoint is not faked, only the request matters.
+	api.GetUserTransactions(UserTransactionsParams{Offset: 1})
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 || len(sent) != 3 {
+		t.Fatalf("expected 3 requests, got %d seen and %d sent", len(seen), len(sent))
+	}
+	if u := seenURLs[0]; u != "https://www.bitstamp.net/api/v2/ticker/btcusd" {
+		t.Errorf("unexpected url %s", u)
+	}
+	if seen[1].Header.Get("X-Auth") != "BITSTAMP key" {
+		t.Errorf("the hook must see the auth headers, got %v", seen[1].Header)
+	}
+	if len(seenBodies) != 2 || seenBodies[0] != "offset=1" || seenBodies[1] != "" {
+		t.Errorf("unexpected bodies %q", seenBodies)
+	}
+	for i, header := range sent {
+		if header.Get("X-Correlation-Id") != "42" {
+			t.Errorf("request %d: no correlation id", i)
+		}
+	}
+	for i, header := range sent[1:] {
+		if sig := header.Get("X-Auth-Signature"); sig == "broken" || sig == redacted || sig == "" {
+			t.Errorf("request %d: the signature must not be changed, got %q", i+1, sig)
+		}
+	}
+	if sent[1].Get("Content-Type") != formContentType {
+		t.Errorf("the content type must not be changed, got %q", sent[1].Get("Content-Type"))
+	}
+	for _, req := range seen[1:] {
+		if sig := req.Header.Get("X-Auth-Signature"); sig != "broken" {
+			t.Errorf("the hook must see a copy of the request, got %q", sig)
+		}
+	}
+	if !fake.called("/balance/") || fake.called("/withdrawal/") || len(balanceValues) != 0 {
+		t.Errorf("the url and the body must not be changed, got %v", balanceValues)
+	}
+}
+
+func TestRequestHookRedaction(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/": func(url.Values) (int, string) { return 200, `{}` },
+	})
+	defer restore()
+	var signature string
+	api := NewWithKey("key", "secret")
+	api.RequestHooks = []RequestHook{func(req *http.Request) {
+		signature = req.Header.Get("X-Auth-Signature")
+	}}
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if signature != redacted {
+		t.Errorf("expected a redacted signature, got %q", signature)
+	}
+}
+
+func TestResponseHooks(t *testing.T) {
+	fails := true
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if fails {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: 404,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"status": "error", "reason": "Not found"}`)),
+			Request:    req,
+		}, nil
+	}))()
+	type call struct {
+		status  int
+		body    string
+		latency time.Duration
+		err     error
+	}
+	var calls []call
+	api, err := NewClient(WithResponseHook(func(resp *http.Response, latency time.Duration, err error) {
+		c := call{latency: latency, err: err}
+		if resp != nil {
+			body, _ := ioutil.ReadAll(resp.Body)
+			c.status, c.body = resp.StatusCode, string(body)
+		}
+		calls = append(calls, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err == nil {
+		t.Error("error expected")
+	}
+	fails = false
+	_, err = api.GetTicker("btcusd")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Reason != "Not found" {
+		t.Errorf("the hook must not consume the body, got %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].err == nil || calls[0].status != 0 {
+		t.Errorf("expected a transport error, got %+v", calls[0])
+	}
+	if calls[1].err != nil || calls[1].status != 404 || calls[1].body != `{"status": "error", "reason": "Not found"}` {
+		t.Errorf("unexpected call %+v", calls[1])
+	}
+	for i, c := range calls {
+		if c.latency < 0 {
+			t.Errorf("call %d: negative latency %v", i, c.latency)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package bitstamp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/avdva/bitstamp-go/bitstamptest"
+	"github.com/pkg/errors"
+)
+
+func newTestServerClient(t *testing.T, srv *bitstamptest.Server, opts ...Option) *Api {
+	t.Helper()
+	opts = append([]Option{WithBaseURL(srv.BaseURL()), WithCredentials(srv.Key(), srv.Secret())}, opts...)
+	api, err := NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+func TestServerRateLimitRetry(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(bitstamptest.Fault{Path: "/order_book/btcusd", Count: 2, Status: http.StatusTooManyRequests, RetryAfter: time.Second})
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api := newTestServerClient(t, srv, WithRateLimitRetry(5*time.Second))
+	book, err := api.GetOrderBook("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) == 0 {
+		t.Errorf("unexpected book %+v", book)
+	}
+	if len(delays) != 2 || delays[0] != time.Second || delays[1] != time.Second {
+		t.Errorf("expected two 1s delays, got %v", delays)
+	}
+	if requests := srv.Requests(); len(requests) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(requests))
+	}
+}
+
+func TestServerPrivateRequestsAreNotRetried(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(bitstamptest.Fault{Path: "/buy/btcusd/", Count: 1, Status: http.StatusTooManyRequests})
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api := newTestServerClient(t, srv, WithRateLimitRetry(5*time.Second))
+	if _, err := api.BuyLimitOrder("btcusd", 9000, 0.1, LimitOrderOpts{}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if orders, err := api.GetOpenOrders(); err != nil || len(orders) != 0 {
+		t.Errorf("unexpected open orders %+v, %v", orders, err)
+	}
+}
+
+func TestServerResponseSignatures(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	api := newTestServerClient(t, srv)
+	api.VerifyResponses = true
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	srv.InjectFault(bitstamptest.Fault{Path: "/balance/", Count: 1, MalformedJSON: true})
+	if _, err := api.GetAccountBalance(); err == nil {
+		t.Error("error expected for a malformed response")
+	}
+}
+
+func TestServerRawNumbers(t *testing.T) {
+	srv := bitstamptest.NewServer()
+	defer srv.Close()
+	api := newTestServerClient(t, srv, WithRawNumbers())
+	trades, err := api.GetTrades("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) == 0 || trades[0].PriceRaw != "10000.00" || trades[0].AmountRaw != "0.01000000" {
+		t.Errorf("unexpected trades %+v", trades)
+	}
+}
@@ -0,0 +1,152 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NewLiquidationAddress creates a new address, which deposits are automatically
+// converted to liquidationCurrency, and returns it.
+func (api *Api) NewLiquidationAddress(liquidationCurrency string) (string, error) {
+	return api.NewLiquidationAddressCtx(context.Background(), liquidationCurrency)
+}
+
+// NewLiquidationAddressCtx is like NewLiquidationAddress, but uses the given context.
+func (api *Api) NewLiquidationAddressCtx(ctx context.Context, liquidationCurrency string) (string, error) {
+	liquidationCurrency = strings.ToLower(liquidationCurrency)
+	if err := validateCurrency(liquidationCurrency); err != nil {
+		return "", err
+	}
+	values := url.Values{}
+	values.Set("liquidation_currency", liquidationCurrency)
+	body, err := api.post(ctx, "/liquidation_address/new/", values)
+	if err != nil {
+		return "", err
+	}
+	address, err := parseDepositAddress(body)
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
+}
+
+// LiquidationTrade is a trade, which converted a deposit.
+type LiquidationTrade struct {
+	// Price is the exchange rate of the trade.
+	Price float64
+	// Amount is the converted amount in the deposit currency.
+	Amount float64
+	Fee    float64
+}
+
+// LiquidationOrder is an order, which converted a deposit.
+type LiquidationOrder struct {
+	OrderID int64
+	// Count is the number of trades reported by the api.
+	Count  int64
+	Trades []LiquidationTrade
+}
+
+// LiquidationAddressInfo is a state of a liquidation address.
+type LiquidationAddressInfo struct {
+	Address string
+	// Symbol is a lowercase pair symbol, like btcusd.
+	Symbol string
+	Orders []LiquidationOrder
+}
+
+// GetLiquidationAddressInfo returns the conversions made since the given time.
+// If address is empty, all the liquidation addresses are returned.
+// If since is zero, the api default is used.
+func (api *Api) GetLiquidationAddressInfo(address string, since time.Time) ([]LiquidationAddressInfo, error) {
+	return api.GetLiquidationAddressInfoCtx(context.Background(), address, since)
+}
+
+// GetLiquidationAddressInfoCtx is like GetLiquidationAddressInfo, but uses the given context.
+func (api *Api) GetLiquidationAddressInfoCtx(ctx context.Context, address string, since time.Time) ([]LiquidationAddressInfo, error) {
+	values := url.Values{}
+	if address != "" {
+		values.Set("address", address)
+	}
+	if !since.IsZero() {
+		now := time.Now()
+		if since.After(now) {
+			return nil, errors.New("since must not be in the future")
+		}
+		values.Set("timedelta", strconv.FormatInt(int64(now.Sub(since)/time.Second), 10))
+	}
+	body, err := api.read(ctx, "/liquidation_address/info/", values)
+	if err != nil {
+		return nil, err
+	}
+	return parseLiquidationAddressInfo(body)
+}
+
+func parseLiquidationAddressInfo(data []byte) ([]LiquidationAddressInfo, error) {
+	var raw []struct {
+		Address      string `json:"address"`
+		CurrencyPair string `json:"currency_pair"`
+		Transactions []struct {
+			OrderID interface{}              `json:"order_id"`
+			Count   interface{}              `json:"count"`
+			Trades  []map[string]interface{} `json:"trades"`
+		} `json:"transactions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]LiquidationAddressInfo, len(raw))
+	for i, r := range raw {
+		info := LiquidationAddressInfo{
+			Address: r.Address,
+			Symbol:  pairSymbol(r.CurrencyPair),
+			Orders:  make([]LiquidationOrder, len(r.Transactions)),
+		}
+		var base string
+		if idx := strings.IndexByte(r.CurrencyPair, '/'); idx > 0 {
+			base = strings.ToLower(r.CurrencyPair[:idx])
+		}
+		for j, tr := range r.Transactions {
+			orderID, err := parseIntValue(tr.OrderID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "address %d, transaction %d: order id parsing error", i, j)
+			}
+			order := LiquidationOrder{OrderID: orderID, Trades: make([]LiquidationTrade, len(tr.Trades))}
+			if tr.Count != nil {
+				if order.Count, err = parseIntValue(tr.Count); err != nil {
+					return nil, errors.Wrapf(err, "address %d, transaction %d: count parsing error", i, j)
+				}
+			}
+			for k, fields := range tr.Trades {
+				trade, err := parseLiquidationTrade(fields, base)
+				if err != nil {
+					return nil, errors.Wrapf(err, "address %d, transaction %d, trade %d", i, j, k)
+				}
+				order.Trades[k] = trade
+			}
+			info.Orders[j] = order
+		}
+		result[i] = info
+	}
+	return result, nil
+}
+
+// parseLiquidationTrade decodes a trade, which amount key is named after the base currency, like btc_amount.
+func parseLiquidationTrade(fields map[string]interface{}, base string) (trade LiquidationTrade, err error) {
+	if trade.Price, err = parseNumber("exchange rate", fields["exchange_rate"]); err != nil {
+		return trade, err
+	}
+	if trade.Amount, err = parseNumber("amount", fields[base+"_amount"]); err != nil {
+		return trade, err
+	}
+	if trade.Fee, err = parseNumber("fees", fields["fees"]); err != nil {
+		return trade, err
+	}
+	return trade, nil
+}
@@ -0,0 +1,64 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseLiquidationAddressInfo(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/liquidation_address_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := parseLiquidationAddressInfo(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []LiquidationAddressInfo{
+		{
+			Address: "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa",
+			Symbol:  "btcusd",
+			Orders: []LiquidationOrder{
+				{
+					OrderID: 1193624600,
+					Count:   2,
+					Trades: []LiquidationTrade{
+						{Price: 8650, Amount: 0.1, Fee: 4.33},
+						{Price: 8651.5, Amount: 0.05, Fee: 2.16},
+					},
+				},
+			},
+		},
+		{
+			Address: "0x5a3b5c0e2b9b5e8d0b4d7a6c5b8e3f2a1d0c9b8a",
+			Symbol:  "etheur",
+			Orders:  []LiquidationOrder{},
+		},
+	}
+	if !reflect.DeepEqual(expected, info) {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+}
+
+func TestNewLiquidationAddress(t *testing.T) {
+	var sent url.Values
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/liquidation_address/new/": func(values url.Values) (int, string) {
+			sent = values
+			return 200, `{"address": "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa"}`
+		},
+	})
+	defer restore()
+	address, err := NewWithKey("key", "secret").NewLiquidationAddress("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa" {
+		t.Errorf("unexpected address %s", address)
+	}
+	if sent.Get("liquidation_currency") != "usd" {
+		t.Errorf("unexpected values %q", sent.Encode())
+	}
+}
@@ -0,0 +1,22 @@
+package bitstamp
+
+// Logger receives the internal log messages of the package.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// nopLogger discards all the messages. It is used if no logger is set.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+func (nopLogger) Errorf(string, ...interface{}) {}
+
+func (api *Api) logger() Logger {
+	if api.Logger != nil {
+		return api.Logger
+	}
+	return nopLogger{}
+}
@@ -0,0 +1,98 @@
+package bitstamp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// recordingLogger stores the messages with their levels.
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) log(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, level+": "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) { l.log("debug", format, args...) }
+func (l *recordingLogger) Infof(format string, args ...interface{})  { l.log("info", format, args...) }
+func (l *recordingLogger) Errorf(format string, args ...interface{}) { l.log("error", format, args...) }
+
+// orderBookServer is a websocket server, which sends a subscription event and a malformed message.
+func orderBookServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		var sub WsEvent
+		if err := conn.ReadJSON(&sub); err != nil {
+			t.Error(err)
+			return
+		}
+		conn.WriteJSON(WsEvent{Event: "bts:subscription_succeeded", Channel: "order_book_btcusd"})
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event":`))
+		// wait for the unsubscription.
+		conn.ReadMessage()
+	}))
+}
+
+func subscribeTestOrderBook(t *testing.T, api *Api) {
+	srv := orderBookServer(t)
+	defer srv.Close()
+	api.WebsocketURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	if err := api.SubscribeOrderBook("btcusd", make(chan OrderBook, 1), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	api, err := NewClient(WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscribeTestOrderBook(t, api)
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	expected := "debug: order book btcusd: bts:subscription_succeeded event"
+	if len(logger.messages) == 0 || logger.messages[0] != expected {
+		t.Errorf("expected %q, got %q", expected, logger.messages)
+	}
+	if _, err := NewClient(WithLogger(nil)); err == nil {
+		t.Error("error expected for a nil logger")
+	}
+}
+
+func TestNoStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	subscribeTestOrderBook(t, New("", ""))
+	os.Stdout = stdout
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > 0 {
+		t.Errorf("unexpected output %q", out)
+	}
+}
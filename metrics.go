@@ -0,0 +1,91 @@
+package bitstamp
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of errors passed to Metrics.ObserveError.
+const (
+	// ErrorKindTimeout is a request, which timed out.
+	ErrorKindTimeout = "timeout"
+	// ErrorKindCanceled is a request, which context was canceled.
+	ErrorKindCanceled = "canceled"
+	// ErrorKindTransport is a request, which failed without a response.
+	ErrorKindTransport = "transport"
+	// ErrorKindRateLimited is a rate limited request.
+	ErrorKindRateLimited = "rate_limited"
+	// ErrorKindAuthentication is a request rejected because of the credentials,
+	// or a response with an invalid signature.
+	ErrorKindAuthentication = "authentication"
+	// ErrorKindAPI is any other error returned by the api.
+	ErrorKindAPI = "api"
+	// ErrorKindWebsocket is a failed websocket read.
+	ErrorKindWebsocket = "websocket"
+	// ErrorKindDecode is a websocket message, which could not be decoded.
+	ErrorKindDecode = "decode"
+)
+
+// Metrics receives measurements of rest requests and websocket messages.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called after each rest request, including retries.
+	// endpoint is the api path, like /balance/. code is the status code,
+	// or zero if no response was received.
+	ObserveRequest(endpoint string, code int, d time.Duration)
+	// ObserveWSMessage is called for each received websocket message.
+	// channel is empty if the message could not be decoded.
+	ObserveWSMessage(channel string, bytes int)
+	// ObserveError is called for each failed rest request and websocket read.
+	// kind is one of the ErrorKind constants.
+	ObserveError(kind string)
+}
+
+// nopMetrics discards all the measurements. It is used if no metrics are set.
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, int, time.Duration) {}
+func (nopMetrics) ObserveWSMessage(string, int)              {}
+func (nopMetrics) ObserveError(string)                       {}
+
+func (api *Api) metrics() Metrics {
+	if api.Metrics != nil {
+		return api.Metrics
+	}
+	return nopMetrics{}
+}
+
+// endpoint returns the path of u relative to the base url.
+func (api *Api) endpoint(u *url.URL) string {
+	if base, err := url.Parse(api.baseURL()); err == nil {
+		return strings.TrimPrefix(u.Path, base.Path)
+	}
+	return u.Path
+}
+
+// observeError reports a failed rest request to the metrics.
+func (api *Api) observeError(err error) {
+	api.metrics().ObserveError(errorKind(err))
+}
+
+func errorKind(err error) string {
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorKindTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, ErrRateLimited):
+		return ErrorKindRateLimited
+	case errors.Is(err, ErrAuthentication), errors.Is(err, ErrBadResponseSignature):
+		return ErrorKindAuthentication
+	case errors.As(err, &apiErr):
+		return ErrorKindAPI
+	default:
+		return ErrorKindTransport
+	}
+}
@@ -0,0 +1,109 @@
+package bitstamp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// recordingMetrics stores the measurements as strings.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	requests []string
+	messages []string
+	errors   []string
+}
+
+func (m *recordingMetrics) ObserveRequest(endpoint string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, fmt.Sprintf("%s %d", endpoint, code))
+}
+
+func (m *recordingMetrics) ObserveWSMessage(channel string, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, fmt.Sprintf("%s %d", channel, bytes))
+}
+
+func (m *recordingMetrics) ObserveError(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors = append(m.errors, kind)
+}
+
+func TestRequestMetrics(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return http.StatusOK, `{"last": "100.0"}`
+		},
+		"/balance/": func(url.Values) (int, string) {
+			return http.StatusForbidden, `{"status": "error", "reason": "Invalid signature", "code": "API0005"}`
+		},
+		"/transactions/btcusd": func(url.Values) (int, string) {
+			return http.StatusTooManyRequests, `{"status": "error", "reason": "Too many requests"}`
+		},
+	})
+	defer restore()
+	metrics := &recordingMetrics{}
+	api, err := NewClient(WithCredentials("key", "secret"), WithMetrics(metrics), WithBaseURL("http://127.0.0.1/api/v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	api.GetAccountBalance()
+	api.GetTrades("btcusd")
+	expected := []string{"/ticker/btcusd 200", "/balance/ 403", "/transactions/btcusd 429"}
+	if !reflect.DeepEqual(metrics.requests, expected) {
+		t.Errorf("expected requests %q, got %q", expected, metrics.requests)
+	}
+	expected = []string{ErrorKindAuthentication, ErrorKindRateLimited}
+	if !reflect.DeepEqual(metrics.errors, expected) {
+		t.Errorf("expected errors %q, got %q", expected, metrics.errors)
+	}
+	if _, err := NewClient(WithMetrics(nil)); err == nil {
+		t.Error("error expected for nil metrics")
+	}
+}
+
+func TestTransportErrorMetrics(t *testing.T) {
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))()
+	metrics := &recordingMetrics{}
+	api := &Api{Metrics: metrics}
+	api.GetTicker("btcusd")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	api.GetTickerCtx(ctx, "btcusd")
+	expected := []string{ErrorKindTransport, ErrorKindCanceled}
+	if !reflect.DeepEqual(metrics.errors, expected) {
+		t.Errorf("expected errors %q, got %q", expected, metrics.errors)
+	}
+	if expected := []string{"/ticker/btcusd 0", "/ticker/btcusd 0"}; !reflect.DeepEqual(metrics.requests, expected) {
+		t.Errorf("expected requests %q, got %q", expected, metrics.requests)
+	}
+}
+
+func TestWebsocketMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	subscribeTestOrderBook(t, &Api{Metrics: metrics})
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	expected := []string{"order_book_btcusd 81", " 9"}
+	if !reflect.DeepEqual(metrics.messages, expected) {
+		t.Errorf("expected messages %q, got %q", expected, metrics.messages)
+	}
+	if len(metrics.errors) == 0 || metrics.errors[0] != ErrorKindDecode {
+		t.Errorf("expected a decode error, got %q", metrics.errors)
+	}
+}
@@ -0,0 +1,206 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxOHLCLimit is the max number of candles returned by a single request.
+const maxOHLCLimit = 1000
+
+// ohlcSteps are the supported candle durations.
+var ohlcSteps = []time.Duration{
+	time.Minute,
+	3 * time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	4 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	72 * time.Hour,
+}
+
+// Candle is an OHLC candle. Time is the candle start.
+type Candle struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// OHLCOpts are parameters of an OHLC request.
+type OHLCOpts struct {
+	// Step is a candle duration. It is required and must be one of
+	// 1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 24h or 72h.
+	Step time.Duration
+	// Limit is the max number of candles, up to 1000. If zero, 1000 is used.
+	Limit int
+	// Start and End, if set, limit the time range of the candles.
+	Start time.Time
+	End   time.Time
+	// ExcludeCurrentCandle excludes the candle, which is not closed yet.
+	ExcludeCurrentCandle bool
+}
+
+func (o OHLCOpts) values() (url.Values, error) {
+	if err := validateOHLCStep(o.Step); err != nil {
+		return nil, err
+	}
+	limit := o.Limit
+	switch {
+	case limit < 0 || limit > maxOHLCLimit:
+		return nil, errors.Errorf("limit must be within [0, %d]", maxOHLCLimit)
+	case limit == 0:
+		limit = maxOHLCLimit
+	}
+	if !o.Start.IsZero() && !o.End.IsZero() && o.End.Before(o.Start) {
+		return nil, errors.New("end must not be before start")
+	}
+	values := url.Values{}
+	values.Set("step", strconv.FormatInt(int64(o.Step/time.Second), 10))
+	values.Set("limit", strconv.Itoa(limit))
+	if !o.Start.IsZero() {
+		values.Set("start", strconv.FormatInt(o.Start.Unix(), 10))
+	}
+	if !o.End.IsZero() {
+		values.Set("end", strconv.FormatInt(o.End.Unix(), 10))
+	}
+	if o.ExcludeCurrentCandle {
+		values.Set("exclude_current_candle", "true")
+	}
+	return values, nil
+}
+
+func validateOHLCStep(step time.Duration) error {
+	for _, s := range ohlcSteps {
+		if s == step {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported step %v", step)
+}
+
+// GetOHLC returns candles of the given symbol in ascending time order.
+func (api *Api) GetOHLC(symbol string, opts OHLCOpts) ([]Candle, error) {
+	return api.GetOHLCCtx(context.Background(), symbol, opts)
+}
+
+// GetOHLCCtx is like GetOHLC, but uses the given context.
+func (api *Api) GetOHLCCtx(ctx context.Context, symbol string, opts OHLCOpts) ([]Candle, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	values, err := opts.values()
+	if err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/ohlc/"+strings.ToLower(symbol)+"/?"+values.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return parseOHLC(body)
+}
+
+func parseOHLC(data []byte) ([]Candle, error) {
+	var raw struct {
+		Data struct {
+			OHLC []struct {
+				Timestamp interface{} `json:"timestamp"`
+				Open      interface{} `json:"open"`
+				High      interface{} `json:"high"`
+				Low       interface{} `json:"low"`
+				Close     interface{} `json:"close"`
+				Volume    interface{} `json:"volume"`
+			} `json:"ohlc"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]Candle, len(raw.Data.OHLC))
+	for i, r := range raw.Data.OHLC {
+		var (
+			c   Candle
+			err error
+		)
+		if c.Time, err = parseTimeValue(r.Timestamp); err != nil {
+			return nil, errors.Wrapf(err, "candle %d: timestamp parsing error", i)
+		}
+		fields := []struct {
+			name   string
+			value  interface{}
+			target *float64
+		}{
+			{"open", r.Open, &c.Open},
+			{"high", r.High, &c.High},
+			{"low", r.Low, &c.Low},
+			{"close", r.Close, &c.Close},
+			{"volume", r.Volume, &c.Volume},
+		}
+		for _, f := range fields {
+			if *f.target, err = parseNumber(f.name, f.value); err != nil {
+				return nil, errors.Wrapf(err, "candle %d", i)
+			}
+		}
+		result[i] = c
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// BackfillOHLC returns candles of the given symbol with the start time within [from, to],
+// in ascending time order. Long ranges are fetched with several sequential requests.
+// If ctx is canceled or a request fails, the candles fetched so far are returned with the error.
+func (api *Api) BackfillOHLC(ctx context.Context, symbol string, step time.Duration, from, to time.Time) ([]Candle, error) {
+	if err := validateOHLCStep(step); err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, errors.New("to must not be before from")
+	}
+	var result []Candle
+	for start := from; !start.After(to); {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		end := start.Add(step * (maxOHLCLimit - 1))
+		if end.After(to) {
+			end = to
+		}
+		candles, err := api.GetOHLCCtx(ctx, symbol, OHLCOpts{Step: step, Start: start, End: end})
+		if err != nil {
+			return result, errors.Wrapf(err, "candles from %v", start)
+		}
+		next := end.Add(step)
+		for _, c := range candles {
+			if c.Time.Before(from) || c.Time.After(to) {
+				continue
+			}
+			// adjacent ranges may share a boundary candle.
+			if n := len(result); n > 0 && !c.Time.After(result[n-1].Time) {
+				continue
+			}
+			result = append(result, c)
+			if after := c.Time.Add(step); after.After(next) {
+				next = after
+			}
+		}
+		start = next
+	}
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package bitstamp
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOHLC(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ohlc.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	candles, err := parseOHLC(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Candle{
+		{Time: time.Unix(1567755300, 0), Open: 10441.29, High: 10465, Low: 10440, Close: 10455.51, Volume: 5.12345678},
+		{Time: time.Unix(1567755360, 0), Open: 10455.51, High: 10464, Low: 10462, Close: 10462, Volume: 0},
+		{Time: time.Unix(1567755420, 0), Open: 10462, High: 10470, Low: 10455, Close: 10460.5, Volume: 3.21},
+	}
+	if !reflect.DeepEqual(expected, candles) {
+		t.Errorf("expected %+v, got %+v", expected, candles)
+	}
+	if _, err := parseOHLC([]byte(`{"data": {"ohlc": [{"timestamp": "1", "open": "x"}]}}`)); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestOHLCOptsValues(t *testing.T) {
+	start := time.Unix(1567755300, 0)
+	values, err := OHLCOpts{
+		Step:                 time.Minute,
+		Limit:                10,
+		Start:                start,
+		End:                  start.Add(10 * time.Minute),
+		ExcludeCurrentCandle: true,
+	}.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "end=1567755900&exclude_current_candle=true&limit=10&start=1567755300&step=60"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	if values, err = (OHLCOpts{Step: 72 * time.Hour}).values(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "limit=1000&step=259200"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	invalid := []OHLCOpts{
+		{},
+		{Step: 2 * time.Minute},
+		{Step: time.Minute, Limit: 1001},
+		{Step: time.Minute, Limit: -1},
+		{Step: time.Minute, Start: start, End: start.Add(-time.Second)},
+	}
+	for _, opts := range invalid {
+		if _, err := opts.values(); err == nil {
+			t.Errorf("%+v: error expected", opts)
+		}
+	}
+}
+
+func TestGetOHLC(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/ohlc.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/ohlc/btcusd/": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+	})
+	defer restore()
+	candles, err := New("", "").GetOHLC("BTCUSD", OHLCOpts{Step: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 3 {
+		t.Errorf("expected 3 candles, got %d", len(candles))
+	}
+	if !fake.called("/ohlc/btcusd/") {
+		t.Error("ohlc endpoint not called")
+	}
+}
+
+// syntheticOHLC serves one candle per step within the requested range,
+// including the candle at end, unless the range exceeds the limit.
+func syntheticOHLC(calls *int) fakeHandler {
+	return func(values url.Values) (int, string) {
+		*calls++
+		step, _ := strconv.ParseInt(values.Get("step"), 10, 64)
+		start, _ := strconv.ParseInt(values.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(values.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(values.Get("limit"))
+		var candles []string
+		for ts := start - start%step; ts <= end && len(candles) < limit; ts += step {
+			candles = append(candles, fmt.Sprintf(`{"timestamp": "%d", "open": "1", "high": "2", "low": "0.5", "close": "1.5", "volume": "%d"}`, ts, ts))
+		}
+		return 200, `{"data": {"pair": "BTC/USD", "ohlc": [` + strings.Join(candles, ",") + `]}}`
+	}
+}
+
+func TestBackfillOHLC(t *testing.T) {
+	var calls int
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ohlc/btcusd/": syntheticOHLC(&calls),
+	})
+	defer restore()
+	from := time.Unix(1567728000, 0)
+	to := from.Add(2500 * time.Minute)
+	candles, err := New("", "").BackfillOHLC(context.Background(), "btcusd", time.Minute, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2501 {
+		t.Fatalf("expected 2501 candles, got %d", len(candles))
+	}
+	for i, c := range candles {
+		if expected := from.Add(time.Duration(i) * time.Minute); !c.Time.Equal(expected) {
+			t.Fatalf("candle %d: expected time %v, got %v", i, expected, c.Time)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 requests, got %d", calls)
+	}
+}
+
+func TestBackfillOHLCCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	handler := syntheticOHLC(&calls)
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ohlc/btcusd/": func(values url.Values) (int, string) {
+			cancel()
+			return handler(values)
+		},
+	})
+	defer restore()
+	from := time.Unix(1567728000, 0)
+	candles, err := New("", "").BackfillOHLC(ctx, "btcusd", time.Minute, from, from.Add(5000*time.Minute))
+	if err != context.Canceled {
+		t.Errorf("context.Canceled expected, got %v", err)
+	}
+	if calls != 1 || len(candles) != maxOHLCLimit {
+		t.Errorf("unexpected result: %d calls, %d candles", calls, len(candles))
+	}
+}
@@ -0,0 +1,144 @@
+package bitstamp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	api, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Key != "" || api.HTTPClient != nil || api.BaseURL != "" {
+		t.Errorf("unexpected defaults %+v", *api)
+	}
+	if _, err := NewClient(func(api *Api) error {
+		api.Key = "key"
+		return nil
+	}); err == nil {
+		t.Error("error expected for a key without a secret")
+	}
+}
+
+func TestWithCredentials(t *testing.T) {
+	api, err := NewClient(WithCredentials("key", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.Key != "key" || api.Secret != "secret" {
+		t.Errorf("unexpected credentials %q, %q", api.Key, api.Secret)
+	}
+	for _, creds := range [][2]string{{"key", ""}, {"", "secret"}, {"", ""}} {
+		if _, err := NewClient(WithCredentials(creds[0], creds[1])); err == nil {
+			t.Errorf("%q: error expected", creds)
+		}
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	client := &http.Client{}
+	api, err := NewClient(WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.httpClient() != client {
+		t.Error("the client is not set")
+	}
+	if _, err := NewClient(WithHTTPClient(nil)); err == nil {
+		t.Error("error expected for a nil client")
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	api, err := NewClient(WithBaseURL("http://127.0.0.1:8080/api/v2/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "http://127.0.0.1:8080/api/v2"; api.baseURL() != expected {
+		t.Errorf("expected %s, got %s", expected, api.baseURL())
+	}
+	for _, rawurl := range []string{"", "/api/v2", "wss://ws.bitstamp.net", "http://%zz"} {
+		if _, err := NewClient(WithBaseURL(rawurl)); err == nil {
+			t.Errorf("%q: error expected", rawurl)
+		}
+	}
+}
+
+func TestWithWebsocketURL(t *testing.T) {
+	api, err := NewClient(WithWebsocketURL("ws://127.0.0.1:8080"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.WebsocketURL != "ws://127.0.0.1:8080" {
+		t.Errorf("unexpected url %s", api.WebsocketURL)
+	}
+	for _, rawurl := range []string{"", "ws.bitstamp.net", "https://www.bitstamp.net"} {
+		if _, err := NewClient(WithWebsocketURL(rawurl)); err == nil {
+			t.Errorf("%q: error expected", rawurl)
+		}
+	}
+}
+
+func TestWithRateLimitRetry(t *testing.T) {
+	api, err := NewClient(WithRateLimitRetry(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.RateLimitRetryBudget != time.Minute {
+		t.Errorf("unexpected budget %v", api.RateLimitRetryBudget)
+	}
+	if _, err := NewClient(WithRateLimitRetry(0)); err == nil {
+		t.Error("error expected for zero budget")
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	api, err := NewClient(WithRateLimit(8000, 10*time.Minute, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining, ok := api.RateLimitRemaining(); !ok || remaining != 10 {
+		t.Errorf("expected 10 remaining requests, got %d, %v", remaining, ok)
+	}
+	for _, params := range [][3]int{{0, 1, 1}, {1, 0, 1}, {1, 1, 0}} {
+		if _, err := NewClient(WithRateLimit(params[0], time.Duration(params[1])*time.Second, params[2])); err == nil {
+			t.Errorf("%v: error expected", params)
+		}
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	policy := &BackoffPolicy{MaxAttempts: 3, BaseDelay: time.Second}
+	api, err := NewClient(WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.RetryPolicy != policy {
+		t.Error("the policy is not set")
+	}
+	if _, err := NewClient(WithRetryPolicy(nil)); err == nil {
+		t.Error("error expected for a nil policy")
+	}
+}
+
+func TestWithHooks(t *testing.T) {
+	api, err := NewClient(
+		WithRequestHook(func(*http.Request) {}),
+		WithRequestHook(func(*http.Request) {}),
+		WithResponseHook(func(*http.Response, time.Duration, error) {}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.RequestHooks) != 2 || len(api.ResponseHooks) != 1 {
+		t.Errorf("unexpected hooks: %d request, %d response", len(api.RequestHooks), len(api.ResponseHooks))
+	}
+	if _, err := NewClient(WithRequestHook(nil)); err == nil {
+		t.Error("error expected for a nil request hook")
+	}
+	if _, err := NewClient(WithResponseHook(nil)); err == nil {
+		t.Error("error expected for a nil response hook")
+	}
+}
@@ -0,0 +1,236 @@
+package bitstamp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// OrderBookUngrouped is a group value for a book of individual orders with their ids.
+	OrderBookUngrouped = 0
+	// OrderBookByPrice is a group value for a book of orders grouped by price.
+	// It is the default grouping.
+	OrderBookByPrice = 1
+	// OrderBookWithCounts is a group value for a book of orders grouped by price
+	// with the number of orders at each level.
+	OrderBookWithCounts = 2
+)
+
+// OrderBookLevel is a level of a grouped order book.
+type OrderBookLevel struct {
+	Price  float64
+	Amount float64
+	// OrderID is an id of the order. It is set only for ungrouped books.
+	OrderID int64
+	// Count is the number of orders at the level. It is set only for books with counts.
+	Count int
+}
+
+// GroupedOrderBook is an order book requested with a group parameter.
+type GroupedOrderBook struct {
+	// Time has microsecond precision, if the api sends microtimestamp.
+	Time  time.Time
+	Group int
+	Asks  []OrderBookLevel
+	Bids  []OrderBookLevel
+}
+
+// GetOrderBookGrouped returns the order book for the given symbol.
+// group is one of 0 (individual orders with ids), 1 (grouped by price, like GetOrderBook)
+// or 2 (grouped by price with order counts).
+func (api *Api) GetOrderBookGrouped(symbol string, group int) (*GroupedOrderBook, error) {
+	return api.GetOrderBookGroupedCtx(context.Background(), symbol, group)
+}
+
+// GetOrderBookGroupedCtx is like GetOrderBookGrouped, but uses the given context.
+func (api *Api) GetOrderBookGroupedCtx(ctx context.Context, symbol string, group int) (*GroupedOrderBook, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	if group < 0 || group > 2 {
+		return nil, errors.Errorf("invalid group %d", group)
+	}
+	values := url.Values{}
+	values.Set("group", strconv.Itoa(group))
+	body, err := api.get(ctx, "/order_book/"+strings.ToLower(symbol)+"/?"+values.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return parseGroupedOrderBook(body, group)
+}
+
+func parseGroupedOrderBook(data []byte, group int) (*GroupedOrderBook, error) {
+	var raw struct {
+		Timestamp      *flexibleInt64  `json:"timestamp"`
+		Microtimestamp *flexibleInt64  `json:"microtimestamp"`
+		Bids           [][]interface{} `json:"bids"`
+		Asks           [][]interface{} `json:"asks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &GroupedOrderBook{Group: group, Time: bookTime(raw.Timestamp, raw.Microtimestamp)}
+	var err error
+	if result.Bids, err = parseOrderBookLevels(raw.Bids, group); err != nil {
+		return nil, errors.Wrap(err, "bids parsing error")
+	}
+	if result.Asks, err = parseOrderBookLevels(raw.Asks, group); err != nil {
+		return nil, errors.Wrap(err, "asks parsing error")
+	}
+	return result, nil
+}
+
+func parseOrderBookLevels(raw [][]interface{}, group int) ([]OrderBookLevel, error) {
+	result := make([]OrderBookLevel, len(raw))
+	for i, r := range raw {
+		if len(r) < 2 {
+			return nil, errors.Errorf("level %d: expected at least 2 elements, got %d", i, len(r))
+		}
+		var (
+			level OrderBookLevel
+			err   error
+		)
+		if level.Price, err = parseNumber("price", r[0]); err != nil {
+			return nil, errors.Wrapf(err, "level %d", i)
+		}
+		if level.Amount, err = parseNumber("amount", r[1]); err != nil {
+			return nil, errors.Wrapf(err, "level %d", i)
+		}
+		switch group {
+		case OrderBookUngrouped:
+			if len(r) < 3 {
+				return nil, errors.Errorf("level %d: no order id", i)
+			}
+			if level.OrderID, err = parseIntValue(r[2]); err != nil {
+				return nil, errors.Wrapf(err, "level %d: order id parsing error", i)
+			}
+		case OrderBookWithCounts:
+			if len(r) < 3 {
+				return nil, errors.Errorf("level %d: no order count", i)
+			}
+			count, err := parseIntValue(r[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "level %d: order count parsing error", i)
+			}
+			level.Count = int(count)
+		}
+		result[i] = level
+	}
+	return result, nil
+}
+
+// GetOrderBookDepth returns the order book for the given symbol with at most depth levels on each side.
+// Levels beyond depth are not decoded. If depth is not positive, the whole book is returned.
+func (api *Api) GetOrderBookDepth(symbol string, depth int) (*OrderBook, error) {
+	return api.GetOrderBookDepthCtx(context.Background(), symbol, depth)
+}
+
+// GetOrderBookDepthCtx is like GetOrderBookDepth, but uses the given context.
+func (api *Api) GetOrderBookDepthCtx(ctx context.Context, symbol string, depth int) (*OrderBook, error) {
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	body, err := api.get(ctx, "/order_book/"+symbol)
+	if err != nil {
+		return nil, err
+	}
+	book, err := api.parseOrderBookDepth(body, depth)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.checkBook(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// parseOrderBookDepth decodes a book of limited depth, keeping the raw values if RawNumbers is set.
+func (api *Api) parseOrderBookDepth(data []byte, depth int) (*OrderBook, error) {
+	return decodeOrderBookDepth(data, depth, api.RawNumbers)
+}
+
+// depthLevels decodes at most depth levels of an order book side. The levels are decoded
+// one by one from a stream, so that the levels after depth are not parsed.
+type depthLevels struct {
+	// side is the name of the side for error messages.
+	side  string
+	depth int
+	// withRaw sets the raw fields of the orders.
+	withRaw bool
+	levels  []Order
+	// found is set if the side was present, and was not null.
+	found bool
+}
+
+func (l *depthLevels) UnmarshalJSON(data []byte) error {
+	l.found = true
+	if data[0] != '[' {
+		return errors.Errorf("%s: expected an array, got %s", l.side, jsonKind(data))
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	var level [2]json.RawMessage
+	for i := 0; dec.More() && (l.depth <= 0 || i < l.depth); i++ {
+		// null levels are no-ops for Decode, so the previous values must be reset.
+		// The buffers are kept for the next level.
+		level = [2]json.RawMessage{level[0][:0], level[1][:0]}
+		if err := dec.Decode(&level); err != nil {
+			return errors.Wrapf(err, "level %d", i)
+		}
+		order, err := parseBookLevel(level, l.withRaw)
+		if err != nil {
+			return errors.Wrapf(err, "level %d", i)
+		}
+		l.levels = append(l.levels, order)
+	}
+	return nil
+}
+
+// parseOrderBookDepth parses an order book keeping at most depth levels on each side.
+func parseOrderBookDepth(data []byte, depth int) (*OrderBook, error) {
+	return decodeOrderBookDepth(data, depth, false)
+}
+
+func decodeOrderBookDepth(data []byte, depth int, withRaw bool) (*OrderBook, error) {
+	raw := struct {
+		Timestamp      *flexibleInt64 `json:"timestamp"`
+		Microtimestamp *flexibleInt64 `json:"microtimestamp"`
+		Bids           *depthLevels   `json:"bids"`
+		Asks           *depthLevels   `json:"asks"`
+	}{
+		Bids: &depthLevels{side: "bids", depth: depth, withRaw: withRaw},
+		Asks: &depthLevels{side: "asks", depth: depth, withRaw: withRaw},
+	}
+	if depth > 0 {
+		raw.Bids.levels = make([]Order, 0, depth)
+		raw.Asks.levels = make([]Order, 0, depth)
+	}
+	// null sides reset the pointers without decoding, so they are not found, as the missing ones.
+	bids, asks := raw.Bids, raw.Asks
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if !bids.found {
+		return nil, errors.New("bids are missing")
+	}
+	if !asks.found {
+		return nil, errors.New("asks are missing")
+	}
+	return &OrderBook{
+		Time: bookTime(raw.Timestamp, raw.Microtimestamp),
+		Bids: bids.levels,
+		Asks: asks.levels,
+	}, nil
+}
@@ -0,0 +1,489 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGroupedOrderBookUngrouped(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/order_book_group0.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := parseGroupedOrderBook(data, OrderBookUngrouped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &GroupedOrderBook{
+		Time:  time.Unix(1567755304, 968123000),
+		Group: OrderBookUngrouped,
+		Bids: []OrderBookLevel{
+			{Price: 10453, Amount: 0.5, OrderID: 1193624601},
+			{Price: 10452.5, Amount: 0.1, OrderID: 1193624588},
+			{Price: 10452.5, Amount: 1.2, OrderID: 1193624590},
+		},
+		Asks: []OrderBookLevel{
+			{Price: 10455.51, Amount: 0.02, OrderID: 1193624597},
+			{Price: 10456, Amount: 3, OrderID: 1193624412},
+		},
+	}
+	if !reflect.DeepEqual(expected, book) {
+		t.Errorf("expected %+v, got %+v", expected, book)
+	}
+	invalid := []string{
+		`{"timestamp": "1", "bids": [["1.0"]], "asks": []}`,
+		`{"timestamp": "1", "bids": [["1.0", "2.0"]], "asks": []}`,
+		`{"timestamp": "1", "bids": [], "asks": [["1.0", "x", "1"]]}`,
+		`{"timestamp": "x", "bids": [], "asks": []}`,
+	}
+	for _, s := range invalid {
+		if _, err := parseGroupedOrderBook([]byte(s), OrderBookUngrouped); err == nil {
+			t.Errorf("%s: error expected", s)
+		}
+	}
+}
+
+func TestGetOrderBookGrouped(t *testing.T) {
+	var group string
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/order_book/btcusd/": func(values url.Values) (int, string) {
+			group = values.Get("group")
+			return 200, `{"timestamp": "1567755304", "bids": [["10453.00", "0.5"]], "asks": [["10455.51", "0.02"]]}`
+		},
+	})
+	defer restore()
+	api := New("", "")
+	book, err := api.GetOrderBookGrouped("BTCUSD", OrderBookByPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group != "1" {
+		t.Errorf("unexpected group %q", group)
+	}
+	if len(book.Bids) != 1 || book.Bids[0] != (OrderBookLevel{Price: 10453, Amount: 0.5}) {
+		t.Errorf("unexpected bids %+v", book.Bids)
+	}
+	for _, g := range []int{-1, 3} {
+		if _, err := api.GetOrderBookGrouped("btcusd", g); err == nil {
+			t.Errorf("group %d: error expected", g)
+		}
+	}
+}
+
+func TestParseGroupedOrderBookWithCounts(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/order_book_group2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := parseGroupedOrderBook(data, OrderBookWithCounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &GroupedOrderBook{
+		Time:  time.Unix(1567755304, 968123000),
+		Group: OrderBookWithCounts,
+		Bids: []OrderBookLevel{
+			{Price: 10453, Amount: 0.5, Count: 1},
+			{Price: 10452.5, Amount: 1.3, Count: 2},
+			{Price: 10450, Amount: 12.4317611, Count: 7},
+		},
+		Asks: []OrderBookLevel{
+			{Price: 10455.51, Amount: 0.02, Count: 1},
+			{Price: 10456, Amount: 3, Count: 1},
+			{Price: 10460, Amount: 25, Count: 11},
+		},
+	}
+	if !reflect.DeepEqual(expected, book) {
+		t.Errorf("expected %+v, got %+v", expected, book)
+	}
+	// the same levels without counts are valid for the price grouping.
+	if book, err = parseGroupedOrderBook(data, OrderBookByPrice); err != nil {
+		t.Fatal(err)
+	}
+	if book.Bids[2] != (OrderBookLevel{Price: 10450, Amount: 12.4317611}) {
+		t.Errorf("unexpected level %+v", book.Bids[2])
+	}
+	if _, err := parseGroupedOrderBook([]byte(`{"timestamp": "1", "bids": [["1.0", "2.0"]], "asks": []}`), OrderBookWithCounts); err == nil {
+		t.Error("error expected for a level without count")
+	}
+}
+
+func TestParseOrderBookDepth(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := New("", "").parseOrderBook(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, depth := range []int{1, 25, 5000, 10000} {
+		book, err := parseOrderBookDepth(data, depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := depth
+		if n > len(full.Bids) {
+			n = len(full.Bids)
+		}
+		if !reflect.DeepEqual(full.Bids[:n], book.Bids) || !reflect.DeepEqual(full.Asks[:n], book.Asks) {
+			t.Errorf("depth %d: levels differ from the full book", depth)
+		}
+		if !book.Time.Equal(time.Unix(1567755304, 968123000)) {
+			t.Errorf("depth %d: unexpected time %v", depth, book.Time)
+		}
+	}
+	book, err := parseOrderBookDepth(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 5000 || len(book.Asks) != 5000 {
+		t.Errorf("expected the whole book, got %d bids and %d asks", len(book.Bids), len(book.Asks))
+	}
+	invalid := []string{
+		`{"timestamp": "1", "bids": [["x", "1"]], "asks": []}`,
+		`{"timestamp": "1", "bids": {}, "asks": []}`,
+		`{"timestamp": "x", "bids": [], "asks": []}`,
+	}
+	for _, s := range invalid {
+		if _, err := parseOrderBookDepth([]byte(s), 10); err == nil {
+			t.Errorf("%s: error expected", s)
+		}
+	}
+}
+
+func TestParseOrderBook(t *testing.T) {
+	data := []byte(`{"timestamp": "1567755304", "bids": [["10453.00", "0.5", "1193624601"], [10452.5, 0.1]], "asks": []}`)
+	for _, rawNumbers := range []bool{false, true} {
+		book, err := (&Api{RawNumbers: rawNumbers}).parseOrderBook(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := &OrderBook{
+			Time: time.Unix(1567755304, 0),
+			Bids: []Order{{Price: 10453, Amount: 0.5}, {Price: 10452.5, Amount: 0.1}},
+		}
+		if rawNumbers {
+			expected.Bids[0].PriceRaw, expected.Bids[0].AmountRaw = "10453.00", "0.5"
+			expected.Bids[1].PriceRaw, expected.Bids[1].AmountRaw = "10452.5", "0.1"
+		}
+		if !reflect.DeepEqual(expected, book) {
+			t.Errorf("raw numbers %v: expected %+v, got %+v", rawNumbers, expected, book)
+		}
+	}
+}
+
+func TestParseOrderBookTime(t *testing.T) {
+	tests := []struct {
+		fields string
+		// expected is the expected time. If zero, the local time is expected.
+		expected time.Time
+	}{
+		{fields: `"timestamp": "1567755304", "microtimestamp": "1567755304968123"`, expected: time.Unix(1567755304, 968123000)},
+		{fields: `"microtimestamp": "1567755304000001"`, expected: time.Unix(1567755304, 1000)},
+		{fields: `"timestamp": "1567755304"`, expected: time.Unix(1567755304, 0)},
+		{fields: `"timestamp": 1567755304, "microtimestamp": 1567755304968123`, expected: time.Unix(1567755304, 968123000)},
+		{fields: `"timestamp": 1567755304`, expected: time.Unix(1567755304, 0)},
+		{fields: `"timestamp": 1567755304.0`, expected: time.Unix(1567755304, 0)},
+		{},
+	}
+	api := New("", "")
+	for _, test := range tests {
+		data := `{` + test.fields + `, "bids": [], "asks": []}`
+		if test.fields == "" {
+			data = `{"bids": [], "asks": []}`
+		}
+		for name, parse := range map[string]func([]byte) (*OrderBook, error){
+			"full":  api.parseOrderBook,
+			"depth": func(data []byte) (*OrderBook, error) { return parseOrderBookDepth(data, 10) },
+			"grouped": func(data []byte) (*OrderBook, error) {
+				book, err := parseGroupedOrderBook(data, OrderBookUngrouped)
+				if err != nil {
+					return nil, err
+				}
+				return &OrderBook{Time: book.Time}, nil
+			},
+		} {
+			before := time.Now()
+			book, err := parse([]byte(data))
+			if err != nil {
+				t.Errorf("%s %s: %v", name, data, err)
+				continue
+			}
+			if test.expected.IsZero() {
+				if book.Time.Before(before) || book.Time.After(time.Now()) {
+					t.Errorf("%s %s: expected the local time, got %v", name, data, book.Time)
+				}
+			} else if !book.Time.Equal(test.expected) {
+				t.Errorf("%s %s: expected %v, got %v", name, data, test.expected, book.Time)
+			}
+		}
+	}
+}
+
+func TestParseOrderBookMalformed(t *testing.T) {
+	tests := []struct {
+		data string
+		// err is a part of the expected error message.
+		err string
+	}{
+		{data: ``},
+		{data: `[]`},
+		{data: `null`, err: "bids are missing"},
+		{data: `{"bids": []}`, err: "asks are missing"},
+		{data: `{"timestamp": true, "bids": [], "asks": []}`, err: "invalid integer true"},
+		{data: `{"timestamp": "x", "bids": [], "asks": []}`, err: `invalid integer "x"`},
+		{data: `{"timestamp": "1567755304", "microtimestamp": "x", "bids": [], "asks": []}`, err: `invalid integer "x"`},
+		{data: `{"bids": {}, "asks": []}`},
+		{data: `{"bids": "x", "asks": []}`},
+		{data: `{"bids": [["1", "2"], "x"], "asks": []}`},
+		{data: `{"bids": [["1", "2"], [{}, "1"]], "asks": []}`},
+		{data: `{"bids": [[]], "asks": []}`, err: "bids parsing error: level 0: price parsing error"},
+		{data: `{"bids": [["1"]], "asks": []}`, err: "bids parsing error: level 0: amount parsing error"},
+		{data: `{"bids": [["1", "2"], [null, "1"]], "asks": []}`, err: "bids parsing error: level 1: price parsing error"},
+		// null levels must not keep the values of the previous books.
+		{data: `{"bids": [null], "asks": []}`, err: "bids parsing error: level 0: price parsing error: missing value"},
+		{data: `{"bids": [], "asks": [["1", "2"], ["3", "1e999"]]}`, err: `asks parsing error: level 1: amount parsing error: invalid number "1e999"`},
+		{data: `{"bids": [["1", "2"]`},
+	}
+	api := New("", "")
+	for _, test := range tests {
+		book, err := api.parseOrderBook([]byte(test.data))
+		if err == nil || book != nil {
+			t.Errorf("%s: expected an error, got %+v", test.data, book)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected %q in %q", test.data, test.err, err.Error())
+		}
+	}
+}
+
+// TestParseOrderBookSideTypes is a regression test for books, which were returned
+// as nil together with a nil error, if a side was not an array.
+func TestParseOrderBookSideTypes(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{data: `{"bids": {"price": "1"}, "asks": []}`, err: "bids: expected an array, got an object"},
+		{data: `{"bids": [], "asks": {}}`, err: "asks: expected an array, got an object"},
+		{data: `{"bids": "[]", "asks": []}`, err: "bids: expected an array, got a string"},
+		{data: `{"bids": [], "asks": 1}`, err: "asks: expected an array, got a number"},
+		{data: `{"bids": [], "asks": true}`, err: "asks: expected an array, got a boolean"},
+		{data: `{"bids": null, "asks": []}`, err: "bids are missing"},
+		{data: `{"bids": []}`, err: "asks are missing"},
+	}
+	api := New("", "")
+	for _, test := range tests {
+		book, err := api.parseOrderBook([]byte(test.data))
+		if err == nil || book != nil {
+			t.Errorf("%s: expected an error, got %+v", test.data, book)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected %q in %q", test.data, test.err, err.Error())
+		}
+		if strings.Contains(test.err, "expected an array") {
+			if _, err := parseOrderBookDepth([]byte(test.data), 10); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected %q from the depth parser, got %v", test.data, test.err, err)
+			}
+		}
+	}
+}
+
+// TestParseOrderBookCorrupted checks, that corrupted books never cause panics.
+func TestParseOrderBookCorrupted(t *testing.T) {
+	data := []byte(`{"timestamp": "1567755304", "bids": [["10453.00", "0.5"], ["10452.50", "0.1"]], "asks": [["10455.51", "0.02"]]}`)
+	api := New("", "")
+	parse := func(data []byte) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("%s: panic: %v", data, r)
+			}
+		}()
+		_, err = api.parseOrderBook(data)
+		return err
+	}
+	for i := 0; i < len(data); i++ {
+		if err := parse(data[:i]); err == nil {
+			t.Errorf("%s: error expected for a truncated book", data[:i])
+		}
+		for _, c := range []byte(`"[]{},:0-.en `) {
+			corrupted := append([]byte(nil), data...)
+			corrupted[i] = c
+			parse(corrupted)
+		}
+	}
+}
+
+// TestParseOrderBookDepthDifferential checks, that the streaming parser returns the same books as parseOrderBook.
+func TestParseOrderBookDepthDifferential(t *testing.T) {
+	deep, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	books := []string{
+		string(deep),
+		`{"timestamp": "1567755304", "bids": [], "asks": []}`,
+		`{"microtimestamp": "1567755304968123", "bids": [["10453.00", "0.5", "1193624601"], [10452.5, 0.1]], "asks": [[1e4, "2"]]}`,
+		`{"timestamp": 1567755304, "bids": [["10453.00", "0.5"]], "asks": [["10455.51", "0.02"]], "unknown": [1, 2]}`,
+		`{"bids": [["1", "2"], [null, "1"]], "asks": []}`,
+		`{"bids": [], "asks": [["1", "2"], ["3", "1e999"]]}`,
+		`{"bids": [["1"]], "asks": []}`,
+		`{"bids": [["1", "2"], [{}, "1"]], "asks": []}`,
+		`{"timestamp": "1567755304.5", "bids": [], "asks": []}`,
+		`{"bids": [["1", "2"]]}`,
+		`{"asks": [["1", "2"]]}`,
+		`{"bids": null, "asks": [["1", "2"]]}`,
+		`{"bids": [["1", "2"]], "asks": null}`,
+		`{}`,
+		`null`,
+	}
+	for _, rawNumbers := range []bool{false, true} {
+		api := &Api{RawNumbers: rawNumbers}
+		for _, data := range books {
+			name := data
+			if len(name) > 100 {
+				name = name[:100]
+			}
+			expected, expectedErr := api.parseOrderBook([]byte(data))
+			book, err := api.parseOrderBookDepth([]byte(data), 0)
+			if (expectedErr == nil) != (err == nil) {
+				t.Errorf("%s: errors differ: %v and %v", name, expectedErr, err)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			if !expected.Time.Equal(book.Time) {
+				t.Errorf("%s: expected time %v, got %v", name, expected.Time, book.Time)
+			}
+			if len(expected.Bids) != len(book.Bids) || len(expected.Bids) > 0 && !reflect.DeepEqual(expected.Bids, book.Bids) {
+				t.Errorf("%s: bids differ", name)
+			}
+			if len(expected.Asks) != len(book.Asks) || len(expected.Asks) > 0 && !reflect.DeepEqual(expected.Asks, book.Asks) {
+				t.Errorf("%s: asks differ", name)
+			}
+		}
+	}
+	for _, data := range []string{`{"bids": null, "asks": [["1", "2"]]}`, `{"bids": [["1", "2"]]}`} {
+		if _, err := parseOrderBookDepth([]byte(data), 10); err == nil {
+			t.Errorf("%s: error expected", data)
+		}
+	}
+}
+
+func BenchmarkParseOrderBook(b *testing.B) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	api := New("", "")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.parseOrderBook(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// parseOrderBookMap is the former map based implementation of parseOrderBook,
+// which is kept to compare the performance.
+func parseOrderBookMap(data []byte) (*OrderBook, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	timestamp, err := strconv.ParseInt(raw["timestamp"].(string), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	parse := func(arr []interface{}) ([]Order, error) {
+		var result []Order
+		for _, elem := range arr {
+			ord := elem.([]interface{})
+			price, err := strconv.ParseFloat(ord[0].(string), 64)
+			if err != nil {
+				return nil, err
+			}
+			amount, err := strconv.ParseFloat(ord[1].(string), 64)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, Order{Price: price, Amount: amount})
+		}
+		return result, nil
+	}
+	result := &OrderBook{Time: time.Unix(timestamp, 0)}
+	if result.Bids, err = parse(raw["bids"].([]interface{})); err != nil {
+		return nil, err
+	}
+	if result.Asks, err = parse(raw["asks"].([]interface{})); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func TestParseOrderBookMap(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := parseOrderBookMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := New("", "").parseOrderBook(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected.Bids, book.Bids) || !reflect.DeepEqual(expected.Asks, book.Asks) {
+		t.Error("the book differs from the one of the map based implementation")
+	}
+}
+
+func BenchmarkParseOrderBookMap(b *testing.B) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseOrderBookMap(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseOrderBookDepth25(b *testing.B) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseOrderBookDepth(data, 25); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseOrderBookDepth0(b *testing.B) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseOrderBookDepth(data, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
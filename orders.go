@@ -0,0 +1,321 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OrderType is a type of an order.
+type OrderType int
+
+const (
+	// OrderBuy is a buy order.
+	OrderBuy OrderType = 0
+	// OrderSell is a sell order.
+	OrderSell OrderType = 1
+)
+
+func (t OrderType) String() string {
+	switch t {
+	case OrderBuy:
+		return "buy"
+	case OrderSell:
+		return "sell"
+	default:
+		return "unknown"
+	}
+}
+
+// OrderStatus is a status of an order.
+type OrderStatus string
+
+const (
+	// OrderStatusOpen is a status of an open order.
+	OrderStatusOpen OrderStatus = "Open"
+	// OrderStatusFinished is a status of a fully executed order.
+	OrderStatusFinished OrderStatus = "Finished"
+	// OrderStatusCanceled is a status of a canceled order.
+	OrderStatusCanceled OrderStatus = "Canceled"
+	// OrderStatusExpired is a status of an expired order.
+	OrderStatusExpired OrderStatus = "Expired"
+)
+
+// OpenOrder is an order, which is not yet finished.
+type OpenOrder struct {
+	ID     int64
+	Time   time.Time
+	Type   OrderType
+	Price  float64
+	Amount float64
+	// Pair is a currency pair as returned by the api, like BTC/USD.
+	Pair string
+	// Symbol is a lowercase pair symbol, like btcusd.
+	Symbol        string
+	ClientOrderID string
+}
+
+// GetOpenOrders returns open orders for all pairs.
+func (api *Api) GetOpenOrders() ([]OpenOrder, error) {
+	return api.GetOpenOrdersCtx(context.Background())
+}
+
+// GetOpenOrdersCtx is like GetOpenOrders, but uses the given context.
+func (api *Api) GetOpenOrdersCtx(ctx context.Context) ([]OpenOrder, error) {
+	body, err := api.read(ctx, "/open_orders/all/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseOpenOrders(body)
+}
+
+// GetOpenOrdersForPair returns open orders for the given symbol.
+// If there are no open orders, an empty slice is returned.
+func (api *Api) GetOpenOrdersForPair(symbol string) ([]OpenOrder, error) {
+	return api.GetOpenOrdersForPairCtx(context.Background(), symbol)
+}
+
+// GetOpenOrdersForPairCtx is like GetOpenOrdersForPair, but uses the given context.
+func (api *Api) GetOpenOrdersForPairCtx(ctx context.Context, symbol string) ([]OpenOrder, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	symbol = strings.ToLower(symbol)
+	body, err := api.read(ctx, "/open_orders/"+symbol+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := parseOpenOrders(body)
+	if err != nil {
+		return nil, err
+	}
+	// the pair is not included into the per-pair response.
+	for i := range orders {
+		if orders[i].Symbol == "" {
+			orders[i].Symbol = symbol
+		}
+	}
+	return orders, nil
+}
+
+func parseOrderType(value interface{}) (OrderType, error) {
+	typ, err := parseIntValue(value)
+	if err != nil {
+		return 0, err
+	}
+	switch t := OrderType(typ); t {
+	case OrderBuy, OrderSell:
+		return t, nil
+	default:
+		return 0, errors.Errorf("unknown order type %d", typ)
+	}
+}
+
+func parseOpenOrders(data []byte) ([]OpenOrder, error) {
+	var raw []struct {
+		ID            interface{} `json:"id"`
+		Datetime      string      `json:"datetime"`
+		Type          interface{} `json:"type"`
+		Price         interface{} `json:"price"`
+		Amount        interface{} `json:"amount"`
+		CurrencyPair  string      `json:"currency_pair"`
+		ClientOrderID interface{} `json:"client_order_id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]OpenOrder, len(raw))
+	for i, r := range raw {
+		order := OpenOrder{
+			Pair:          r.CurrencyPair,
+			Symbol:        pairSymbol(r.CurrencyPair),
+			ClientOrderID: stringValue(r.ClientOrderID),
+		}
+		var err error
+		if order.ID, err = parseIntValue(r.ID); err != nil {
+			return nil, errors.Wrapf(err, "order %d: id parsing error", i)
+		}
+		if order.Time, err = parseDatetime(r.Datetime); err != nil {
+			return nil, errors.Wrapf(err, "order %d: datetime parsing error", i)
+		}
+		if order.Type, err = parseOrderType(r.Type); err != nil {
+			return nil, errors.Wrapf(err, "order %d: type parsing error", i)
+		}
+		if order.Price, err = parseNumber("price", r.Price); err != nil {
+			return nil, errors.Wrapf(err, "order %d", i)
+		}
+		if order.Amount, err = parseNumber("amount", r.Amount); err != nil {
+			return nil, errors.Wrapf(err, "order %d", i)
+		}
+		result[i] = order
+	}
+	return result, nil
+}
+
+// OrderFill is a trade, which (partially) executed an order.
+type OrderFill struct {
+	TID   int64
+	Time  time.Time
+	Price float64
+	// Amount is the executed amount in the base currency.
+	// It is only set if the pair of the order is known.
+	Amount float64
+	// Fee is the fee in the quote currency.
+	Fee float64
+	// Type is a user transaction type, UserTransactionTrade for trades.
+	Type UserTransactionType
+	// Amounts maps lowercase currency names to the amounts of the trade, like btc or usd.
+	Amounts map[string]float64
+}
+
+// OrderStatusResult is a state of an order.
+type OrderStatusResult struct {
+	ID int64
+	// Symbol is a lowercase pair symbol, like btcusd, if returned by the api.
+	Symbol          string
+	Status          OrderStatus
+	AmountRemaining float64
+	ClientOrderID   string
+	Fills           []OrderFill
+}
+
+// OrderStatusParams are parameters of an order status request.
+// Exactly one of ID and ClientOrderID must be set.
+type OrderStatusParams struct {
+	ID            int64
+	ClientOrderID string
+}
+
+func (p OrderStatusParams) values() (url.Values, error) {
+	values := url.Values{}
+	switch {
+	case p.ID != 0 && p.ClientOrderID != "":
+		return nil, errors.New("id and client order id are mutually exclusive")
+	case p.ID != 0:
+		values.Set("id", strconv.FormatInt(p.ID, 10))
+	case p.ClientOrderID != "":
+		values.Set("client_order_id", p.ClientOrderID)
+	default:
+		return nil, errors.New("either id or client order id must be set")
+	}
+	return values, nil
+}
+
+// GetOrderStatus returns the status of an order with the given id.
+// If the order does not exist, ErrOrderNotFound is returned.
+func (api *Api) GetOrderStatus(id int64) (*OrderStatusResult, error) {
+	return api.GetOrderStatusCtx(context.Background(), id)
+}
+
+// GetOrderStatusCtx is like GetOrderStatus, but uses the given context.
+func (api *Api) GetOrderStatusCtx(ctx context.Context, id int64) (*OrderStatusResult, error) {
+	return api.GetOrderStatusParamsCtx(ctx, OrderStatusParams{ID: id})
+}
+
+// GetOrderStatusParams returns the status of an order by its id or client order id.
+// If the order does not exist, ErrOrderNotFound is returned.
+func (api *Api) GetOrderStatusParams(params OrderStatusParams) (*OrderStatusResult, error) {
+	return api.GetOrderStatusParamsCtx(context.Background(), params)
+}
+
+// GetOrderStatusParamsCtx is like GetOrderStatusParams, but uses the given context.
+func (api *Api) GetOrderStatusParamsCtx(ctx context.Context, params OrderStatusParams) (*OrderStatusResult, error) {
+	values, err := params.values()
+	if err != nil {
+		return nil, err
+	}
+	body, err := api.read(ctx, "/order_status/", values)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, withSentinel(ErrOrderNotFound, err)
+		}
+		return nil, err
+	}
+	return parseOrderStatus(body)
+}
+
+func parseOrderStatus(data []byte) (*OrderStatusResult, error) {
+	var raw struct {
+		ID              interface{}              `json:"id"`
+		Market          string                   `json:"market"`
+		Status          string                   `json:"status"`
+		AmountRemaining interface{}              `json:"amount_remaining"`
+		ClientOrderID   interface{}              `json:"client_order_id"`
+		Transactions    []map[string]interface{} `json:"transactions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := &OrderStatusResult{
+		Symbol:        pairSymbol(raw.Market),
+		Status:        OrderStatus(raw.Status),
+		ClientOrderID: stringValue(raw.ClientOrderID),
+		Fills:         make([]OrderFill, len(raw.Transactions)),
+	}
+	var err error
+	if raw.ID != nil {
+		if result.ID, err = parseIntValue(raw.ID); err != nil {
+			return nil, errors.Wrap(err, "id parsing error")
+		}
+	}
+	if raw.AmountRemaining != nil {
+		if result.AmountRemaining, err = parseNumber("amount remaining", raw.AmountRemaining); err != nil {
+			return nil, err
+		}
+	}
+	var base string
+	if idx := strings.IndexByte(raw.Market, '/'); idx > 0 {
+		base = strings.ToLower(raw.Market[:idx])
+	}
+	for i, fields := range raw.Transactions {
+		fill, err := parseOrderFill(fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "transaction %d", i)
+		}
+		fill.Amount = fill.Amounts[base]
+		result.Fills[i] = fill
+	}
+	return result, nil
+}
+
+func parseOrderFill(fields map[string]interface{}) (fill OrderFill, err error) {
+	fill.Amounts = make(map[string]float64)
+	for key, value := range fields {
+		switch key {
+		case "tid":
+			fill.TID, err = parseIntValue(value)
+		case "datetime":
+			fill.Time, err = parseTimeValue(value)
+		case "price":
+			if fill.Price, err = parseNumber(key, value); err != nil {
+				return fill, err
+			}
+		case "fee":
+			if fill.Fee, err = parseNumber(key, value); err != nil {
+				return fill, err
+			}
+		case "type":
+			var typ int64
+			typ, err = parseIntValue(value)
+			fill.Type = UserTransactionType(typ)
+		default:
+			if value == nil {
+				continue
+			}
+			var v float64
+			if v, err = parseNumber(key, value); err != nil {
+				return fill, err
+			}
+			fill.Amounts[key] = v
+		}
+		if err != nil {
+			return fill, errors.Wrapf(err, "%s parsing error", key)
+		}
+	}
+	return fill, nil
+}
@@ -0,0 +1,180 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOpenOrders(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/open_orders.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := parseOpenOrders(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OpenOrder{
+		{
+			ID:            1193624515,
+			Time:          time.Date(2020, 3, 2, 10, 15, 7, 0, time.UTC),
+			Type:          OrderBuy,
+			Price:         8500,
+			Amount:        0.01,
+			Pair:          "BTC/USD",
+			Symbol:        "btcusd",
+			ClientOrderID: "my-order-1",
+		},
+		{
+			ID:     1193624516,
+			Time:   time.Date(2020, 3, 2, 10, 16, 0, 500000000, time.UTC),
+			Type:   OrderSell,
+			Price:  250.1,
+			Amount: 2.5,
+			Pair:   "ETH/EUR",
+			Symbol: "etheur",
+		},
+		{
+			ID:     1193624517,
+			Time:   time.Date(2020, 3, 2, 11, 0, 0, 0, time.UTC),
+			Type:   OrderSell,
+			Price:  0.25,
+			Amount: 100,
+			Pair:   "XRP/USD",
+			Symbol: "xrpusd",
+		},
+	}
+	if !reflect.DeepEqual(expected, orders) {
+		t.Errorf("expected %+v, got %+v", expected, orders)
+	}
+}
+
+func TestParseOpenOrdersInvalidType(t *testing.T) {
+	for _, typ := range []string{`"2"`, `"buy"`, `null`} {
+		data := `[{"id": "1", "datetime": "2020-03-02 10:15:07", "type": ` + typ + `, "price": "1", "amount": "1", "currency_pair": "BTC/USD"}]`
+		if _, err := parseOpenOrders([]byte(data)); err == nil {
+			t.Errorf("type %s: error expected", typ)
+		}
+	}
+}
+
+func TestParseOpenOrdersEmpty(t *testing.T) {
+	for _, data := range []string{`[]`, `null`} {
+		orders, err := parseOpenOrders([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orders == nil || len(orders) != 0 {
+			t.Errorf("%s: expected empty non-nil slice, got %#v", data, orders)
+		}
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	data := []byte(`{
+		"id": 1193624515,
+		"datetime": "2020-03-02 10:15:07",
+		"type": "0",
+		"status": "Open",
+		"market": "BTC/USD",
+		"amount_remaining": "0.00500000",
+		"client_order_id": "my-order-1",
+		"transactions": [
+			{"tid": 133579151, "price": "8500.00", "fee": "0.21", "datetime": "2020-03-02 10:15:08.500000", "type": 2, "btc": "0.00500000", "usd": "42.50"}
+		]
+	}`)
+	status, err := parseOrderStatus(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &OrderStatusResult{
+		ID:              1193624515,
+		Symbol:          "btcusd",
+		Status:          OrderStatusOpen,
+		AmountRemaining: 0.005,
+		ClientOrderID:   "my-order-1",
+		Fills: []OrderFill{
+			{
+				TID:     133579151,
+				Time:    time.Date(2020, 3, 2, 10, 15, 8, 500000000, time.UTC),
+				Price:   8500,
+				Amount:  0.005,
+				Fee:     0.21,
+				Type:    UserTransactionTrade,
+				Amounts: map[string]float64{"btc": 0.005, "usd": 42.5},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, status) {
+		t.Errorf("expected %+v, got %+v", expected, status)
+	}
+}
+
+func TestOrderStatusParams(t *testing.T) {
+	values, err := OrderStatusParams{ID: 5}.values()
+	if err != nil || values.Encode() != "id=5" {
+		t.Errorf("unexpected result %v, %v", values, err)
+	}
+	values, err = OrderStatusParams{ClientOrderID: "abc"}.values()
+	if err != nil || values.Encode() != "client_order_id=abc" {
+		t.Errorf("unexpected result %v, %v", values, err)
+	}
+	if _, err := (OrderStatusParams{}).values(); err == nil {
+		t.Error("error expected for empty params")
+	}
+	if _, err := (OrderStatusParams{ID: 5, ClientOrderID: "abc"}).values(); err == nil {
+		t.Error("error expected for both ids")
+	}
+}
+
+func TestParseOrderStatusExpired(t *testing.T) {
+	status, err := parseOrderStatus([]byte(`{"id": 1, "status": "Expired", "amount_remaining": "0.5", "transactions": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != OrderStatusExpired {
+		t.Errorf("expected %s, got %s", OrderStatusExpired, status.Status)
+	}
+}
+
+func TestParseOrderStatusPartiallyFilled(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/order_status_partial.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := parseOrderStatus(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &OrderStatusResult{
+		ID:              1193624530,
+		Symbol:          "etheur",
+		Status:          OrderStatusOpen,
+		AmountRemaining: 1.25,
+		Fills: []OrderFill{
+			{
+				TID:     133580001,
+				Time:    time.Date(2020, 3, 2, 11, 0, 1, 0, time.UTC),
+				Price:   200.5,
+				Amount:  0.5,
+				Fee:     0.25,
+				Type:    UserTransactionTrade,
+				Amounts: map[string]float64{"eth": 0.5, "eur": 100.25},
+			},
+			{
+				TID:     133580002,
+				Time:    time.Date(2020, 3, 2, 11, 0, 2, 250000000, time.UTC),
+				Price:   201,
+				Amount:  0.25,
+				Fee:     0.13,
+				Type:    UserTransactionTrade,
+				Amounts: map[string]float64{"eth": 0.25, "eur": 50.25},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, status) {
+		t.Errorf("expected %+v, got %+v", expected, status)
+	}
+}
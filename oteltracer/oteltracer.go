@@ -0,0 +1,73 @@
+// Package oteltracer traces the requests of a bitstamp client with OpenTelemetry.
+// It is a separate module, so that the bitstamp package does not depend on OpenTelemetry.
+//
+//	api, err := bitstamp.NewClient(bitstamp.WithTracer(oteltracer.New(nil)))
+package oteltracer
+
+import (
+	"context"
+	"fmt"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer obtained from the provider.
+const InstrumentationName = "github.com/avdva/bitstamp-go/oteltracer"
+
+var (
+	_ bitstamp.Tracer        = (*Tracer)(nil)
+	_ bitstamp.SpanAnnotator = (*Tracer)(nil)
+)
+
+// Tracer is a bitstamp.Tracer, which starts OpenTelemetry client spans.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New creates a tracer, which starts spans with the given provider.
+// If provider is nil, the global one is used.
+func New(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+// Start implements bitstamp.Tracer. A non-nil error is recorded in the span, and sets its status.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// SetAttribute implements bitstamp.SpanAnnotator.
+func (t *Tracer) SetAttribute(ctx context.Context, key string, value interface{}) {
+	trace.SpanFromContext(ctx).SetAttributes(keyValue(key, value))
+}
+
+// keyValue converts an attribute value, formatting the types unknown to OpenTelemetry.
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,61 @@
+package oteltracer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "btcusd") {
+			w.Write([]byte(`{"last": "100.0"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status": "error", "reason": "Not found"}`))
+	}))
+	defer srv.Close()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	api, err := bitstamp.NewClient(bitstamp.WithTracer(New(provider)), bitstamp.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "caller")
+	if _, err := api.GetTickerCtx(ctx, "btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTickerCtx(ctx, "xyzusd"); err == nil {
+		t.Fatal("error expected")
+	}
+	parent.End()
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	ok, failed := spans[0], spans[1]
+	for _, span := range []sdktrace.ReadOnlySpan{ok, failed} {
+		if span.Name() != bitstamp.SpanRequest || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("unexpected span %s with parent %s", span.Name(), span.Parent().SpanID())
+		}
+	}
+	attrs := attribute.NewSet(ok.Attributes()...)
+	if v, _ := attrs.Value(bitstamp.AttrPair); v.AsString() != "btcusd" {
+		t.Errorf("unexpected pair %q", v.Emit())
+	}
+	if v, _ := attrs.Value(bitstamp.AttrStatusCode); v.AsInt64() != http.StatusOK {
+		t.Errorf("unexpected status code %q", v.Emit())
+	}
+	if ok.Status().Code != codes.Unset || failed.Status().Code != codes.Error {
+		t.Errorf("unexpected statuses %v, %v", ok.Status(), failed.Status())
+	}
+}
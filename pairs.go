@@ -0,0 +1,279 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PairInfo describes a trading pair.
+type PairInfo struct {
+	// Name is a pair name, like BTC/USD.
+	Name string
+	// URLSymbol is a symbol used in api paths, like btcusd.
+	URLSymbol string
+	// BaseDecimals is the max number of decimals of amounts.
+	BaseDecimals int
+	// CounterDecimals is the max number of decimals of prices.
+	CounterDecimals int
+	// InstantAndMarketOrdersEnabled reports whether instant and market orders are allowed.
+	InstantAndMarketOrdersEnabled bool
+	// MinimumOrder is the minimum order value as returned by the api, like "10.0 USD".
+	MinimumOrder string
+	// MinimumOrderAmount and MinimumOrderCurrency are the parsed MinimumOrder.
+	// MinimumOrderCurrency is lowercase.
+	MinimumOrderAmount   float64
+	MinimumOrderCurrency string
+	// Trading reports whether trading is enabled for the pair.
+	Trading     bool
+	Description string
+}
+
+// GetTradingPairsInfo returns descriptions of all trading pairs.
+func (api *Api) GetTradingPairsInfo() ([]PairInfo, error) {
+	return api.GetTradingPairsInfoCtx(context.Background())
+}
+
+// GetTradingPairsInfoCtx is like GetTradingPairsInfo, but uses the given context.
+func (api *Api) GetTradingPairsInfoCtx(ctx context.Context) ([]PairInfo, error) {
+	body, err := api.get(ctx, "/trading-pairs-info/")
+	if err != nil {
+		return nil, err
+	}
+	return parseTradingPairsInfo(body)
+}
+
+func parseTradingPairsInfo(data []byte) ([]PairInfo, error) {
+	var raw []struct {
+		Name                   string      `json:"name"`
+		URLSymbol              string      `json:"url_symbol"`
+		BaseDecimals           interface{} `json:"base_decimals"`
+		CounterDecimals        interface{} `json:"counter_decimals"`
+		InstantAndMarketOrders string      `json:"instant_and_market_orders"`
+		MinimumOrder           string      `json:"minimum_order"`
+		Trading                string      `json:"trading"`
+		Description            string      `json:"description"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]PairInfo, len(raw))
+	for i, r := range raw {
+		info := PairInfo{
+			Name:                          r.Name,
+			URLSymbol:                     r.URLSymbol,
+			InstantAndMarketOrdersEnabled: isEnabled(r.InstantAndMarketOrders),
+			MinimumOrder:                  r.MinimumOrder,
+			Trading:                       isEnabled(r.Trading),
+			Description:                   r.Description,
+		}
+		if info.URLSymbol == "" {
+			info.URLSymbol = pairSymbol(r.Name)
+		}
+		decimals, err := parseIntValue(r.BaseDecimals)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: base decimals parsing error", r.Name)
+		}
+		info.BaseDecimals = int(decimals)
+		if decimals, err = parseIntValue(r.CounterDecimals); err != nil {
+			return nil, errors.Wrapf(err, "%s: counter decimals parsing error", r.Name)
+		}
+		info.CounterDecimals = int(decimals)
+		if r.MinimumOrder != "" {
+			if info.MinimumOrderAmount, info.MinimumOrderCurrency, err = parseMinimumOrder(r.MinimumOrder); err != nil {
+				return nil, errors.Wrapf(err, "%s: minimum order parsing error", r.Name)
+			}
+		}
+		result[i] = info
+	}
+	return result, nil
+}
+
+// parseMinimumOrder parses a string like "10.0 USD".
+func parseMinimumOrder(s string) (float64, string, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 2 {
+		return 0, "", errors.Errorf("invalid minimum order %q", s)
+	}
+	amount, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return amount, strings.ToLower(parts[1]), nil
+}
+
+func isEnabled(s string) bool {
+	return strings.EqualFold(s, "enabled")
+}
+
+// quoteCurrencies are the known quote currencies, used to split symbols without a separator.
+// Longer codes go first, so that usdcusdt is split into usdc and usdt.
+var quoteCurrencies = []string{"usdc", "usdt", "pyusd", "pax", "usd", "eur", "gbp", "btc", "eth"}
+
+// Pair is a currency pair.
+type Pair struct {
+	base, quote string
+}
+
+// NewPair returns a pair of the given currencies.
+func NewPair(base, quote string) Pair {
+	return Pair{base: strings.ToLower(base), quote: strings.ToLower(quote)}
+}
+
+// ParsePair parses a pair name, like BTC/USD, or a symbol, like btcusd.
+// Symbols are split by a known quote currency. Use PairInfo.Pair for the pairs,
+// which quote currency is not known.
+func ParsePair(s string) (Pair, error) {
+	if idx := strings.IndexByte(s, '/'); idx >= 0 {
+		base, quote := s[:idx], s[idx+1:]
+		if base == "" || quote == "" {
+			return Pair{}, errors.Errorf("invalid pair %q", s)
+		}
+		return NewPair(base, quote), nil
+	}
+	symbol := strings.ToLower(s)
+	for _, quote := range quoteCurrencies {
+		if base := strings.TrimSuffix(symbol, quote); base != symbol && base != "" {
+			return NewPair(base, quote), nil
+		}
+	}
+	return Pair{}, errors.Errorf("unknown quote currency of %q", s)
+}
+
+// Pair returns the pair described by the info.
+func (i PairInfo) Pair() (Pair, error) {
+	return ParsePair(i.Name)
+}
+
+// Base returns the lowercase base currency.
+func (p Pair) Base() string {
+	return p.base
+}
+
+// Quote returns the lowercase quote currency.
+func (p Pair) Quote() string {
+	return p.quote
+}
+
+// String returns the pair name, like BTC/USD.
+func (p Pair) String() string {
+	return strings.ToUpper(p.base) + "/" + strings.ToUpper(p.quote)
+}
+
+// URLSymbol returns the symbol used by the api methods, like btcusd.
+func (p Pair) URLSymbol() string {
+	return p.base + p.quote
+}
+
+// pairCache is a cache of the trading pairs info. It is safe for concurrent use.
+type pairCache struct {
+	fetch func() ([]PairInfo, error)
+	now   func() time.Time
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	pairs   map[string]PairInfo
+	fetched time.Time
+}
+
+// get returns the info of the given lowercase symbol, refreshing the cache if it is expired.
+// If the refresh fails, stale data is used, if any.
+func (c *pairCache) get(symbol string) (PairInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pairs == nil || c.now().Sub(c.fetched) >= c.ttl {
+		if err := c.refreshLocked(); err != nil && c.pairs == nil {
+			return PairInfo{}, errors.Wrap(err, "trading pairs info fetching error")
+		}
+	}
+	info, found := c.pairs[symbol]
+	if !found {
+		return PairInfo{}, withSentinel(ErrUnknownSymbol, errors.Errorf("symbol %q", symbol))
+	}
+	return info, nil
+}
+
+// hasCurrency reports whether currency is the base or the quote of a cached pair.
+// cached is false, if the pairs were not fetched yet.
+func (c *pairCache) hasCurrency(currency string) (found, cached bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pairs == nil {
+		return false, false
+	}
+	for _, info := range c.pairs {
+		pair, err := info.Pair()
+		if err == nil && (pair.Base() == currency || pair.Quote() == currency) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+func (c *pairCache) refresh() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshLocked()
+}
+
+func (c *pairCache) refreshLocked() error {
+	infos, err := c.fetch()
+	if err != nil {
+		return err
+	}
+	pairs := make(map[string]PairInfo, len(infos))
+	for _, info := range infos {
+		pairs[strings.ToLower(info.URLSymbol)] = info
+	}
+	c.pairs, c.fetched = pairs, c.now()
+	return nil
+}
+
+// defaultPairsTTL is the lifetime of the trading pairs info, used if no other is set.
+const defaultPairsTTL = time.Hour
+
+// EnableSymbolValidation makes the api object check symbols against the list of trading pairs
+// before sending requests. Unknown symbols are rejected with ErrUnknownSymbol.
+// The list is fetched on the first use and refreshed after ttl.
+// It must be called before the api object is used concurrently.
+func (api *Api) EnableSymbolValidation(ttl time.Duration) {
+	api.setPairCache(ttl)
+	api.validateSymbols = true
+}
+
+// setPairCache creates the trading pairs cache, or updates its ttl.
+func (api *Api) setPairCache(ttl time.Duration) {
+	if api.pairs != nil {
+		api.pairs.mu.Lock()
+		api.pairs.ttl = ttl
+		api.pairs.mu.Unlock()
+		return
+	}
+	api.pairs = &pairCache{
+		fetch: api.GetTradingPairsInfo,
+		now:   time.Now,
+		ttl:   ttl,
+	}
+}
+
+// RefreshSymbols fetches the list of trading pairs used for symbol validation and rounding.
+func (api *Api) RefreshSymbols() error {
+	if api.pairs == nil {
+		return errors.New("neither symbol validation nor rounding is enabled")
+	}
+	return api.pairs.refresh()
+}
+
+// validateSymbol checks the symbol, if the validation is enabled.
+func (api *Api) validateSymbol(symbol string) error {
+	if !api.validateSymbols {
+		return nil
+	}
+	_, err := api.pairs.get(strings.ToLower(symbol))
+	return err
+}
@@ -0,0 +1,208 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseTradingPairsInfo(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_pairs_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs, err := parseTradingPairsInfo(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PairInfo{
+		{
+			Name:                          "BTC/USD",
+			URLSymbol:                     "btcusd",
+			BaseDecimals:                  8,
+			CounterDecimals:               0,
+			InstantAndMarketOrdersEnabled: true,
+			MinimumOrder:                  "10.0 USD",
+			MinimumOrderAmount:            10,
+			MinimumOrderCurrency:          "usd",
+			Trading:                       true,
+			Description:                   "Bitcoin / U.S. dollar",
+		},
+		{
+			Name:                 "ETH/BTC",
+			URLSymbol:            "ethbtc",
+			BaseDecimals:         8,
+			CounterDecimals:      8,
+			MinimumOrder:         "0.00020000 BTC",
+			MinimumOrderAmount:   0.0002,
+			MinimumOrderCurrency: "btc",
+			Trading:              true,
+			Description:          "Ether / Bitcoin",
+		},
+		{
+			Name:                          "EUR/USD",
+			URLSymbol:                     "eurusd",
+			BaseDecimals:                  5,
+			CounterDecimals:               5,
+			InstantAndMarketOrdersEnabled: true,
+			MinimumOrder:                  "10 EUR",
+			MinimumOrderAmount:            10,
+			MinimumOrderCurrency:          "eur",
+			Description:                   "Euro / U.S. dollar",
+		},
+	}
+	if !reflect.DeepEqual(expected, pairs) {
+		t.Errorf("expected %+v, got %+v", expected, pairs)
+	}
+}
+
+func TestParseMinimumOrder(t *testing.T) {
+	for _, s := range []string{"", "10", "USD 10", "10 USD extra"} {
+		if _, _, err := parseMinimumOrder(s); err == nil {
+			t.Errorf("%q: error expected", s)
+		}
+	}
+}
+
+func TestParsePair(t *testing.T) {
+	tests := []struct {
+		s           string
+		base, quote string
+	}{
+		{"btcusd", "btc", "usd"},
+		{"BTCEUR", "btc", "eur"},
+		{"usdcusd", "usdc", "usd"},
+		{"usdcusdt", "usdc", "usdt"},
+		{"ethbtc", "eth", "btc"},
+		{"1incheur", "1inch", "eur"},
+		{"BTC/USD", "btc", "usd"},
+		{"NEWCOIN/XYZ", "newcoin", "xyz"},
+	}
+	for _, test := range tests {
+		p, err := ParsePair(test.s)
+		if err != nil {
+			t.Errorf("%s: %v", test.s, err)
+			continue
+		}
+		if p.Base() != test.base || p.Quote() != test.quote {
+			t.Errorf("%s: expected %s/%s, got %s/%s", test.s, test.base, test.quote, p.Base(), p.Quote())
+		}
+		if p.URLSymbol() != test.base+test.quote {
+			t.Errorf("%s: unexpected symbol %s", test.s, p.URLSymbol())
+		}
+	}
+	for _, s := range []string{"", "usd", "btcxyz", "/usd", "btc/"} {
+		if _, err := ParsePair(s); err == nil {
+			t.Errorf("%q: error expected", s)
+		}
+	}
+	if s := NewPair("ETH", "btc").String(); s != "ETH/BTC" {
+		t.Errorf("unexpected name %s", s)
+	}
+	p, err := PairInfo{Name: "NEWCOIN/XYZ"}.Pair()
+	if err != nil || p != NewPair("newcoin", "xyz") {
+		t.Errorf("unexpected pair %v, %v", p, err)
+	}
+}
+
+func TestSymbolValidation(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_pairs_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infoCalls int
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/trading-pairs-info/": func(url.Values) (int, string) {
+			infoCalls++
+			return 200, string(data)
+		},
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, `{"last": "10455.51"}`
+		},
+		"/ticker/BTCUSD": func(url.Values) (int, string) {
+			return 200, `{"last": "10455.51"}`
+		},
+	})
+	defer restore()
+	api := NewWithKey("key", "secret")
+	api.EnableSymbolValidation(time.Hour)
+	now := time.Unix(1567755304, 0)
+	api.pairs.now = func() time.Time { return now }
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	calls := []func() error{
+		func() error { _, err := api.GetTicker("btcusdd"); return err },
+		func() error { _, err := api.GetOrderBook("xyzusd"); return err },
+		func() error { _, err := api.GetTrades("xyzusd"); return err },
+		func() error { _, err := api.BuyLimitOrder("xyzusd", 1, 1, LimitOrderOpts{}); return err },
+		func() error { _, err := api.SellMarketOrder("xyzusd", 1, MarketOrderOpts{}); return err },
+		func() error { _, err := api.BuyInstantOrder("xyzusd", 1, InstantOrderOpts{}); return err },
+	}
+	for i, call := range calls {
+		if err := call(); !errors.Is(err, ErrUnknownSymbol) {
+			t.Errorf("call %d: ErrUnknownSymbol expected, got %v", i, err)
+		}
+	}
+	for _, path := range []string{"/ticker/btcusdd", "/order_book/xyzusd", "/transactions/xyzusd", "/buy/xyzusd/"} {
+		if fake.called(path) {
+			t.Errorf("%s must not be called", path)
+		}
+	}
+	if infoCalls != 1 {
+		t.Errorf("expected 1 info request, got %d", infoCalls)
+	}
+	now = now.Add(time.Hour)
+	if _, err := api.GetTicker("BTCUSD"); err != nil {
+		t.Fatal(err)
+	}
+	if infoCalls != 2 {
+		t.Errorf("expected the cache to expire, got %d info requests", infoCalls)
+	}
+	if err := api.RefreshSymbols(); err != nil {
+		t.Fatal(err)
+	}
+	if infoCalls != 3 {
+		t.Errorf("expected a forced refresh, got %d info requests", infoCalls)
+	}
+}
+
+func TestSymbolValidationConcurrent(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_pairs_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/trading-pairs-info/": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+	})
+	defer restore()
+	api := New("", "")
+	api.EnableSymbolValidation(time.Nanosecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%10 == 0 {
+				if err := api.RefreshSymbols(); err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err := api.validateSymbol("ethbtc"); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := New("", "").RefreshSymbols(); err == nil {
+		t.Error("error expected if the validation is not enabled")
+	}
+}
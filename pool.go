@@ -0,0 +1,83 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxPooledMessage is the capacity of the largest message buffer kept for reuse.
+	maxPooledMessage = 1 << 20
+	// maxPooledLevels is the capacity of the largest level buffer kept for reuse.
+	maxPooledLevels = 10000
+)
+
+// messageBuffers are scratch buffers of the websocket messages.
+var messageBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// readMessage reads the next websocket message into buf.
+// The returned bytes are valid until buf is reused.
+func readMessage(ws *websocket.Conn, buf *bytes.Buffer) ([]byte, error) {
+	_, r, err := ws.NextReader()
+	if err != nil {
+		return nil, err
+	}
+	buf.Reset()
+	_, err = buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
+
+func putMessageBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledMessage {
+		messageBuffers.Put(buf)
+	}
+}
+
+// levelBuffer is a scratch buffer of the undecoded levels of an order book side.
+// The levels are parsed into new slices, so the buffers never reach the caller.
+type levelBuffer struct {
+	// side is the name of the side for error messages.
+	side   string
+	levels [][2]json.RawMessage
+	// found is set if the side was present, and was not null.
+	found bool
+}
+
+var levelBuffers = sync.Pool{
+	New: func() interface{} { return new(levelBuffer) },
+}
+
+func getLevelBuffer(side string) *levelBuffer {
+	b := levelBuffers.Get().(*levelBuffer)
+	b.side, b.levels, b.found = side, b.levels[:0], false
+	return b
+}
+
+func putLevelBuffer(b *levelBuffer) {
+	if cap(b.levels) > maxPooledLevels {
+		return
+	}
+	// null levels are no-ops for json.Unmarshal, so the values of the previous book must be reset.
+	// The buffers of the values are reused by the next book.
+	for i := range b.levels {
+		b.levels[i][0], b.levels[i][1] = b.levels[i][0][:0], b.levels[i][1][:0]
+	}
+	levelBuffers.Put(b)
+}
+
+func (b *levelBuffer) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	b.found = true
+	if data[0] != '[' {
+		return errors.Errorf("%s: expected an array, got %s", b.side, jsonKind(data))
+	}
+	return json.Unmarshal(data, &b.levels)
+}
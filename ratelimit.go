@@ -0,0 +1,175 @@
+package bitstamp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RateLimitError describes a response with the 429 status code.
+type RateLimitError struct {
+	// RetryAfter is the delay from the Retry-After header. It is zero if the header is absent.
+	RetryAfter time.Duration
+	Err        *APIError
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v, retry after %v", e.Err, e.RetryAfter)
+}
+
+// Unwrap returns the original api error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// rateLimitError converts the error of a 429 response to an error matching ErrRateLimited.
+// Other errors are returned as is.
+func rateLimitError(resp *http.Response, err error) error {
+	var apiErr *APIError
+	if resp.StatusCode != http.StatusTooManyRequests || !errors.As(err, &apiErr) {
+		return err
+	}
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return withSentinel(ErrRateLimited, &RateLimitError{RetryAfter: retryAfter, Err: apiErr})
+}
+
+// parseRetryAfter parses the Retry-After header value, which is
+// either a number of seconds, or a date. It returns zero for invalid values.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+	t, err := http.ParseTime(value)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
+
+// retryDelay returns the delay before the retry of a rate limited request,
+// and whether it fits the budget. Requests without a known delay are not retried.
+func retryDelay(err error, budget time.Duration) (time.Duration, bool) {
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || rlErr.RetryAfter <= 0 || rlErr.RetryAfter > budget {
+		return 0, false
+	}
+	return rlErr.RetryAfter, true
+}
+
+// sleep waits for d or until ctx is done. It is a variable to be replaced in tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimiter is a token bucket limiting the rate of requests. It is safe for concurrent use.
+type rateLimiter struct {
+	mu sync.Mutex
+	// rate is the number of tokens added per second.
+	rate  float64
+	burst float64
+	// tokens is the number of available tokens. It is negative, if there are waiting requests.
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(requests int, interval time.Duration, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(requests) / interval.Seconds(),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// advance adds the tokens accumulated since the last call. It must be called with mu held.
+func (l *rateLimiter) advance() {
+	now := l.now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+// wait takes a token, waiting for it if needed.
+// If ctx is done before the token is available, the token is returned back.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.advance()
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// remaining returns the number of requests, which can be sent without waiting.
+func (l *rateLimiter) remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance()
+	if l.tokens < 0 {
+		return 0
+	}
+	return int(l.tokens)
+}
+
+// EnableRateLimit limits the rate of rest requests to the given number of requests per interval,
+// allowing bursts of up to burst requests. All the parameters must be positive.
+// Bitstamp allows 8000 requests per 10 minutes.
+// Requests wait for the limiter until their context is done.
+// The limiter is shared by the copies of the api object made by WithAccount.
+func (api *Api) EnableRateLimit(requests int, interval time.Duration, burst int) {
+	api.limiter = newRateLimiter(requests, interval, burst)
+}
+
+// RateLimitRemaining returns the number of requests, which can be sent now without waiting.
+// ok is false if the rate limit is not enabled.
+func (api *Api) RateLimitRemaining() (remaining int, ok bool) {
+	if api.limiter == nil {
+		return 0, false
+	}
+	return api.limiter.remaining(), true
+}
+
+// waitRateLimit waits until a request can be sent, if the rate limit is enabled.
+func (api *Api) waitRateLimit(ctx context.Context) error {
+	if api.limiter == nil {
+		return nil
+	}
+	if err := api.limiter.wait(ctx); err != nil {
+		return errors.Wrap(err, "waiting for rate limiter")
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package bitstamp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Sun, 01 Mar 2020 12:00:30 GMT": 30 * time.Second,
+		"Sun, 01 Mar 2020 11:59:00 GMT": 0,
+	}
+	for value, expected := range tests {
+		if got := parseRetryAfter(value, now); got != expected {
+			t.Errorf("%q: expected %v, got %v", value, expected, got)
+		}
+	}
+}
+
+// withFakeSleep replaces sleep with a function recording the delays.
+func withFakeSleep(delays *[]time.Duration) func() {
+	prev := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return func() { sleep = prev }
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	var calls int
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			calls++
+			if calls == 1 {
+				return 429, `{"status": "error", "reason": "Too many requests"}`
+			}
+			return 200, `{"last": "10455.51"}`
+		},
+	})
+	defer restore()
+	fake.header = func(*http.Request, string) http.Header {
+		return http.Header{"Retry-After": {"2"}}
+	}
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api, err := NewClient(WithRateLimitRetry(5 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last != 10455.51 {
+		t.Errorf("unexpected ticker %+v", *ticker)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+	if len(delays) != 1 || delays[0] != 2*time.Second {
+		t.Errorf("expected a single 2s delay, got %v", delays)
+	}
+}
+
+func TestRateLimitNoRetry(t *testing.T) {
+	var calls int
+	handler := func(url.Values) (int, string) {
+		calls++
+		return 429, `{"status": "error", "reason": "Too many requests"}`
+	}
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": handler,
+		"/buy/btcusd/":   handler,
+	})
+	defer restore()
+	fake.header = func(*http.Request, string) http.Header {
+		return http.Header{"Retry-After": {"2"}}
+	}
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api := NewWithKey("key", "secret")
+	api.RateLimitRetryBudget = time.Minute
+	_, err := api.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected %v, got %v", ErrRateLimited, err)
+	}
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) || rlErr.RetryAfter != 2*time.Second {
+		t.Errorf("expected a 2s retry delay, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("orders must not be retried, got %d calls", calls)
+	}
+	calls = 0
+	api.RateLimitRetryBudget = time.Second
+	if _, err := api.GetTicker("btcusd"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected %v, got %v", ErrRateLimited, err)
+	}
+	if calls != 1 || len(delays) != 0 {
+		t.Errorf("the delay exceeds the budget, but got %d calls and %v delays", calls, delays)
+	}
+	calls = 0
+	api.RateLimitRetryBudget = 5 * time.Second
+	if _, err := api.GetTicker("btcusd"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected %v, got %v", ErrRateLimited, err)
+	}
+	if calls != 3 || len(delays) != 2 {
+		t.Errorf("expected retries within the budget, got %d calls and %v delays", calls, delays)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1567755304, 0)
+	l := newRateLimiter(10, time.Second, 2)
+	l.now = func() time.Time { return now }
+	l.last = now
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+	if len(delays) != 2 || delays[0] != expected[0] || delays[1] != expected[1] {
+		t.Errorf("expected delays %v, got %v", expected, delays)
+	}
+	if remaining := l.remaining(); remaining != 0 {
+		t.Errorf("expected no remaining requests, got %d", remaining)
+	}
+	now = now.Add(time.Second)
+	if remaining := l.remaining(); remaining != 2 {
+		t.Errorf("expected the burst of 2 requests, got %d", remaining)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for i := 0; i < 3; i++ {
+		l.wait(canceled)
+	}
+	if remaining := l.remaining(); remaining != 0 {
+		t.Errorf("expected no remaining requests, got %d", remaining)
+	}
+	now = now.Add(100 * time.Millisecond)
+	if remaining := l.remaining(); remaining != 1 {
+		t.Errorf("the canceled request must return the token, got %d remaining", remaining)
+	}
+}
+
+func TestRateLimitSpacing(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, `{"last": "10455.51"}`
+		},
+	})
+	defer restore()
+	api, err := NewClient(WithRateLimit(1, 20*time.Millisecond, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining, ok := api.RateLimitRemaining(); !ok || remaining != 1 {
+		t.Errorf("expected 1 remaining request, got %d, %v", remaining, ok)
+	}
+	start := time.Now()
+	errs := make(chan error)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := api.GetTicker("btcusd")
+			errs <- err
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("5 requests must take at least 80ms, took %v", elapsed)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	api.EnableRateLimit(1, time.Hour, 1)
+	api.GetTicker("btcusd")
+	if _, err := api.GetTickerCtx(ctx, "btcusd"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if _, ok := New("", "").RateLimitRemaining(); ok {
+		t.Error("the rate limit must be disabled by default")
+	}
+}
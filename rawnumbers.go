@@ -0,0 +1,131 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// rawNumber keeps the text of a json string or number, like 0.10000000 for "0.10000000".
+// null is decoded as an empty string.
+type rawNumber string
+
+func (n *rawNumber) UnmarshalJSON(data []byte) error {
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*n = ""
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*n = rawNumber(s)
+	case len(data) > 0 && (data[0] == '-' || data[0] >= '0' && data[0] <= '9'):
+		*n = rawNumber(data)
+	default:
+		return errors.Errorf("expected a number or a string, got %s", data)
+	}
+	return nil
+}
+
+// tickerRawNumbers are the original values of the numeric fields of a ticker.
+type tickerRawNumbers struct {
+	Last            rawNumber `json:"last"`
+	High            rawNumber `json:"high"`
+	Low             rawNumber `json:"low"`
+	Ask             rawNumber `json:"ask"`
+	Bid             rawNumber `json:"bid"`
+	Open            rawNumber `json:"open"`
+	Volume          rawNumber `json:"volume"`
+	VWAP            rawNumber `json:"vwap"`
+	Open24          rawNumber `json:"open_24"`
+	PercentChange24 rawNumber `json:"percent_change_24"`
+}
+
+func (r *tickerRawNumbers) set(t *Ticker) {
+	t.LastRaw, t.HighRaw, t.LowRaw = string(r.Last), string(r.High), string(r.Low)
+	t.AskRaw, t.BidRaw, t.OpenRaw = string(r.Ask), string(r.Bid), string(r.Open)
+	t.VolumeRaw, t.VWAPRaw = string(r.Volume), string(r.VWAP)
+	t.Open24Raw, t.PercentChange24Raw = string(r.Open24), string(r.PercentChange24)
+}
+
+// setTickerRaw sets the raw fields of a ticker decoded from data.
+func setTickerRaw(t *Ticker, data []byte) error {
+	var raw tickerRawNumbers
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	raw.set(t)
+	return nil
+}
+
+// setAllTickersRaw sets the raw fields of the tickers decoded from data by parseAllTickers.
+func setAllTickersRaw(tickers map[string]Ticker, data []byte) error {
+	var raw []struct {
+		Pair string `json:"pair"`
+		tickerRawNumbers
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, r := range raw {
+		symbol := pairSymbol(r.Pair)
+		ticker, found := tickers[symbol]
+		if !found {
+			continue
+		}
+		r.set(&ticker)
+		tickers[symbol] = ticker
+	}
+	return nil
+}
+
+// setTradesRaw sets the raw fields of the trades decoded from data.
+func setTradesRaw(trades []Trade, data []byte) error {
+	var raw []struct {
+		Price  rawNumber `json:"price"`
+		Amount rawNumber `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != len(trades) {
+		return errors.Errorf("expected %d trades, got %d", len(trades), len(raw))
+	}
+	for i, r := range raw {
+		trades[i].PriceRaw, trades[i].AmountRaw = string(r.Price), string(r.Amount)
+	}
+	return nil
+}
+
+// setBalancesRaw sets the raw fields of the balances decoded from data.
+func setBalancesRaw(b *Balances, data []byte) error {
+	var raw map[string]rawNumber
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	b.FeesRaw = make(map[string]string)
+	for key, value := range raw {
+		name, field, ok := splitBalanceKey(key)
+		if !ok || value == "" {
+			continue
+		}
+		balance, found := b.Currencies[name]
+		switch {
+		case field == "fee":
+			b.FeesRaw[name] = string(value)
+			continue
+		case !found:
+			continue
+		case field == "available":
+			balance.AvailableRaw = string(value)
+		case field == "balance":
+			balance.TotalRaw = string(value)
+		case field == "reserved":
+			balance.ReservedRaw = string(value)
+		}
+		b.Currencies[name] = balance
+	}
+	return nil
+}
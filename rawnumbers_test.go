@@ -0,0 +1,228 @@
+package bitstamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"testing"
+)
+
+// rawFixtures serves the fixtures of the endpoints with numeric fields.
+func rawFixtures(t *testing.T) (map[string][]byte, func()) {
+	files := map[string]string{
+		"/ticker/btcusd":       "testdata/ticker_hour.json",
+		"/ticker/":             "testdata/tickers.json",
+		"/order_book/btcusd":   "testdata/order_book_deep.json",
+		"/transactions/btcusd": "testdata/transactions.json",
+		"/balance/":            "testdata/balance.json",
+	}
+	fixtures := make(map[string][]byte)
+	handlers := make(map[string]fakeHandler)
+	for path, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures[path] = data
+		handlers[path] = func(url.Values) (int, string) {
+			return 200, string(data)
+		}
+	}
+	_, restore := withFakeExchange(handlers)
+	return fixtures, restore
+}
+
+// checkRaw checks that the raw value encoded as a json string is the original value.
+// Null values are kept as empty strings.
+func checkRaw(t *testing.T, name string, original json.RawMessage, raw string) {
+	t.Helper()
+	if string(original) == "null" {
+		if raw != "" {
+			t.Errorf("%s: expected an empty value, got %q", name, raw)
+		}
+		return
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original, encoded) {
+		t.Errorf("%s: expected %s, got %s", name, original, encoded)
+	}
+}
+
+func tickerRawFields(ticker *Ticker) map[string]string {
+	return map[string]string{
+		"last":              ticker.LastRaw,
+		"high":              ticker.HighRaw,
+		"low":               ticker.LowRaw,
+		"ask":               ticker.AskRaw,
+		"bid":               ticker.BidRaw,
+		"open":              ticker.OpenRaw,
+		"volume":            ticker.VolumeRaw,
+		"vwap":              ticker.VWAPRaw,
+		"open_24":           ticker.Open24Raw,
+		"percent_change_24": ticker.PercentChange24Raw,
+	}
+}
+
+func checkTickerRaw(t *testing.T, data []byte, ticker *Ticker) {
+	t.Helper()
+	var original map[string]json.RawMessage
+	if err := json.Unmarshal(data, &original); err != nil {
+		t.Fatal(err)
+	}
+	for key, raw := range tickerRawFields(ticker) {
+		if value, found := original[key]; found {
+			checkRaw(t, key, value, raw)
+		} else if raw != "" {
+			t.Errorf("%s: unexpected raw value %q", key, raw)
+		}
+	}
+}
+
+func TestRawNumbersRoundTrip(t *testing.T) {
+	fixtures, restore := rawFixtures(t)
+	defer restore()
+	api, err := NewClient(WithCredentials("key", "secret"), WithRawNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTickerRaw(t, fixtures["/ticker/btcusd"], ticker)
+
+	tickers, err := api.GetAllTickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var allTickers []json.RawMessage
+	if err := json.Unmarshal(fixtures["/ticker/"], &allTickers); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range allTickers {
+		var pair struct{ Pair string }
+		if err := json.Unmarshal(data, &pair); err != nil {
+			t.Fatal(err)
+		}
+		ticker := tickers[pairSymbol(pair.Pair)]
+		checkTickerRaw(t, data, &ticker)
+	}
+
+	var book struct {
+		Bids, Asks [][]json.RawMessage
+	}
+	if err := json.Unmarshal(fixtures["/order_book/btcusd"], &book); err != nil {
+		t.Fatal(err)
+	}
+	checkBook := func(name string, orders []Order, levels [][]json.RawMessage) {
+		if len(orders) > len(levels) {
+			t.Fatalf("%s: expected at most %d levels, got %d", name, len(levels), len(orders))
+		}
+		for i, o := range orders {
+			checkRaw(t, name+" price", levels[i][0], o.PriceRaw)
+			checkRaw(t, name+" amount", levels[i][1], o.AmountRaw)
+		}
+	}
+	full, err := api.GetOrderBook("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBook("bids", full.Bids, book.Bids)
+	checkBook("asks", full.Asks, book.Asks)
+	limited, err := api.GetOrderBookDepth("btcusd", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBook("limited bids", limited.Bids, book.Bids)
+	checkBook("limited asks", limited.Asks, book.Asks)
+
+	trades, err := api.GetTrades("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rawTrades []map[string]json.RawMessage
+	if err := json.Unmarshal(fixtures["/transactions/btcusd"], &rawTrades); err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != len(rawTrades) {
+		t.Fatalf("expected %d trades, got %d", len(rawTrades), len(trades))
+	}
+	for i, trade := range trades {
+		checkRaw(t, "trade price", rawTrades[i]["price"], trade.PriceRaw)
+		checkRaw(t, "trade amount", rawTrades[i]["amount"], trade.AmountRaw)
+	}
+
+	balances, err := api.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rawBalances map[string]json.RawMessage
+	if err := json.Unmarshal(fixtures["/balance/"], &rawBalances); err != nil {
+		t.Fatal(err)
+	}
+	for name, b := range balances.Currencies {
+		checkRaw(t, name+"_available", rawBalances[name+"_available"], b.AvailableRaw)
+		checkRaw(t, name+"_reserved", rawBalances[name+"_reserved"], b.ReservedRaw)
+		checkRaw(t, name+"_balance", rawBalances[name+"_balance"], b.TotalRaw)
+	}
+	for symbol := range balances.Fees {
+		checkRaw(t, symbol+"_fee", rawBalances[symbol+"_fee"], balances.FeesRaw[symbol])
+	}
+}
+
+func TestRawNumbersDisabled(t *testing.T) {
+	_, restore := rawFixtures(t)
+	defer restore()
+	api := NewWithKey("key", "secret")
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.LastRaw != "" {
+		t.Errorf("unexpected raw value %q", ticker.LastRaw)
+	}
+	book, err := api.GetOrderBook("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Bids[0].PriceRaw != "" || book.Bids[0].AmountRaw != "" {
+		t.Errorf("unexpected raw values %+v", book.Bids[0])
+	}
+	balances, err := api.GetAccountBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances.Get("btc").TotalRaw != "" || balances.FeesRaw != nil {
+		t.Errorf("unexpected raw values %+v", balances)
+	}
+}
+
+func TestRawNumber(t *testing.T) {
+	tests := map[string]string{
+		`"0.10000000"`: "0.10000000",
+		`0.10000000`:   "0.10000000",
+		`-1E-8`:        "-1E-8",
+		`null`:         "",
+	}
+	for data, expected := range tests {
+		var n rawNumber
+		if err := json.Unmarshal([]byte(data), &n); err != nil {
+			t.Errorf("%s: unexpected error %v", data, err)
+			continue
+		}
+		if string(n) != expected {
+			t.Errorf("%s: expected %q, got %q", data, expected, n)
+		}
+	}
+	for _, invalid := range []string{`true`, `[]`, `{}`} {
+		var n rawNumber
+		if err := json.Unmarshal([]byte(invalid), &n); err == nil {
+			t.Errorf("%s: error expected", invalid)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package bitstamp
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReplaceResult is a result of an order replacement.
+type ReplaceResult struct {
+	// Canceled is the canceled order.
+	Canceled *CanceledOrder
+	// Filled is the amount of the canceled order, which was executed.
+	Filled float64
+	// Order is the new order.
+	Order *OrderResult
+}
+
+// ReplaceOrder moves an order to a new price.
+// It cancels the order, and then places a new limit order of the same type at newPrice.
+// newAmount is the total amount of the order, so the amount executed before
+// the cancellation is subtracted from it. If nothing is left to place,
+// ErrAlreadyFilled is returned along with the result. If the type, the pair or the executed amount
+// of the canceled order can't be determined, no order is placed.
+func (api *Api) ReplaceOrder(ctx context.Context, orderID int64, newPrice, newAmount float64, opts LimitOrderOpts) (*ReplaceResult, error) {
+	// validate the new order before canceling the old one.
+	if _, err := limitOrderValues(newPrice, newAmount, opts); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	raw, cancelErr := api.cancelOrder(ctx, orderID)
+	if cancelErr != nil && !errors.Is(cancelErr, ErrOrderNotFound) {
+		return nil, cancelErr
+	}
+	var canceled CanceledOrder
+	if cancelErr == nil {
+		var err error
+		if canceled, err = raw.convert(); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// the status is requested after the cancellation, so all the fills are known.
+	status, err := api.GetOrderStatusCtx(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if cancelErr != nil {
+		// the order could have been filled right before the cancellation.
+		if status.Status == OrderStatusFinished {
+			return nil, errors.Wrapf(ErrAlreadyFilled, "order %d", orderID)
+		}
+		return nil, cancelErr
+	}
+	result := &ReplaceResult{Canceled: &canceled}
+	// the zero type is a buy, so a missing type must not be used to place the new order.
+	if raw.Type == nil {
+		return result, errors.Errorf("unknown type of order %d", orderID)
+	}
+	symbol := status.Symbol
+	if symbol == "" {
+		symbol = canceled.Symbol
+	}
+	if symbol == "" {
+		return result, errors.Errorf("unknown pair of order %d", orderID)
+	}
+	if result.Filled, err = filledAmount(status.Fills, symbol); err != nil {
+		return result, errors.Wrapf(err, "order %d", orderID)
+	}
+	remaining := roundAmount(newAmount - result.Filled)
+	if remaining <= 0 {
+		return result, errors.Wrapf(ErrAlreadyFilled, "order %d", orderID)
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	result.Order, err = api.limitOrder(ctx, canceled.Type.String(), symbol, newPrice, remaining, opts)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// filledAmount returns the executed amount of the fills in the base currency of symbol.
+// The base is found among the currencies of the fills, as the fill amounts are only set,
+// if the order status names the market.
+func filledAmount(fills []OrderFill, symbol string) (float64, error) {
+	var filled float64
+	for i, fill := range fills {
+		base := fillBase(fill.Amounts, symbol)
+		if base == "" {
+			return 0, errors.Errorf("unknown base currency of fill %d", i)
+		}
+		filled += fill.Amounts[base]
+	}
+	return roundAmount(filled), nil
+}
+
+// fillBase returns the currency of amounts, which is the base of symbol, or an empty string.
+func fillBase(amounts map[string]float64, symbol string) string {
+	for currency := range amounts {
+		quote := strings.TrimPrefix(symbol, currency)
+		if _, found := amounts[quote]; found && quote != symbol && quote != "" {
+			return currency
+		}
+	}
+	return ""
+}
+
+// roundAmount removes float artifacts from the result of amounts arithmetic.
+func roundAmount(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
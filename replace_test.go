@@ -0,0 +1,183 @@
+package bitstamp
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestReplaceOrder(t *testing.T) {
+	const (
+		canceled      = `{"id": 10, "amount": "0.7", "price": "8500", "type": 0}`
+		statusPartial = `{"id": 10, "status": "Canceled", "market": "BTC/USD", "amount_remaining": "0.7", "transactions": [
+			{"tid": 1, "price": "8500", "btc": "0.2", "usd": "1700", "fee": "1", "datetime": "2020-03-02 11:00:01", "type": 2},
+			{"tid": 2, "price": "8500", "btc": "0.1", "usd": "850", "fee": "0.5", "datetime": "2020-03-02 11:00:02", "type": 2}
+		]}`
+		statusFinished = `{"id": 10, "status": "Finished", "market": "BTC/USD", "amount_remaining": "0", "transactions": [
+			{"tid": 1, "price": "8500", "btc": "1.0", "usd": "8500", "fee": "5", "datetime": "2020-03-02 11:00:01", "type": 2}
+		]}`
+		// statusNoMarket is a status without the market, so the fill amounts are not set.
+		statusNoMarket = `{"id": 10, "status": "Canceled", "amount_remaining": "0.7", "transactions": [
+			{"tid": 1, "price": "8500", "btc": "0.2", "usd": "1700", "fee": "1", "datetime": "2020-03-02 11:00:01", "type": 2},
+			{"tid": 2, "price": "8500", "btc": "0.1", "usd": "850", "fee": "0.5", "datetime": "2020-03-02 11:00:02", "type": 2}
+		]}`
+		notFound = `{"status": "error", "reason": "Order not found."}`
+		newOrder = `{"id": "11", "datetime": "2020-03-02 11:01:00", "type": "0", "price": "8400", "amount": "0.7"}`
+	)
+	api := NewWithKey("key", "secret")
+
+	t.Run("partially filled", func(t *testing.T) {
+		var placed url.Values
+		_, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) { return 200, canceled },
+			"/order_status/": func(url.Values) (int, string) { return 200, statusPartial },
+			"/buy/btcusd/": func(values url.Values) (int, string) {
+				placed = values
+				return 200, newOrder
+			},
+		})
+		defer restore()
+		result, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Filled != 0.3 {
+			t.Errorf("unexpected filled amount %v", result.Filled)
+		}
+		if result.Order == nil || result.Order.ID != 11 {
+			t.Errorf("unexpected new order %+v", result.Order)
+		}
+		if placed.Get("amount") != "0.7" || placed.Get("price") != "8400" {
+			t.Errorf("unexpected new order values %q", placed.Encode())
+		}
+	})
+
+	t.Run("partially filled without market", func(t *testing.T) {
+		var placed url.Values
+		_, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) {
+				return 200, `{"id": 10, "amount": "0.7", "price": "8500", "type": 0, "currency_pair": "BTC/USD"}`
+			},
+			"/order_status/": func(url.Values) (int, string) { return 200, statusNoMarket },
+			"/buy/btcusd/": func(values url.Values) (int, string) {
+				placed = values
+				return 200, newOrder
+			},
+		})
+		defer restore()
+		result, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Filled != 0.3 {
+			t.Errorf("unexpected filled amount %v", result.Filled)
+		}
+		if placed.Get("amount") != "0.7" {
+			t.Errorf("unexpected new order values %q", placed.Encode())
+		}
+	})
+
+	t.Run("unknown pair", func(t *testing.T) {
+		f, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) { return 200, canceled },
+			"/order_status/": func(url.Values) (int, string) { return 200, statusNoMarket },
+		})
+		defer restore()
+		if _, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{}); err == nil {
+			t.Error("error expected")
+		}
+		if f.called("/buy/btcusd/") {
+			t.Error("no order must be placed")
+		}
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		f, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) {
+				return 200, `{"id": 10, "amount": "0.7", "price": "8500", "currency_pair": "BTC/USD"}`
+			},
+			"/order_status/": func(url.Values) (int, string) { return 200, statusNoMarket },
+		})
+		defer restore()
+		if _, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{}); err == nil {
+			t.Error("error expected")
+		}
+		if f.called("/buy/btcusd/") || f.called("/sell/btcusd/") {
+			t.Error("no order must be placed")
+		}
+	})
+
+	t.Run("unknown base currency", func(t *testing.T) {
+		f, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) {
+				return 200, `{"id": 10, "amount": "0.7", "price": "8500", "type": 0, "currency_pair": "ETH/USD"}`
+			},
+			"/order_status/": func(url.Values) (int, string) { return 200, statusNoMarket },
+		})
+		defer restore()
+		if _, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{}); err == nil {
+			t.Error("error expected")
+		}
+		if f.called("/buy/ethusd/") {
+			t.Error("no order must be placed")
+		}
+	})
+
+	t.Run("filled before cancel", func(t *testing.T) {
+		f, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) { return 200, notFound },
+			"/order_status/": func(url.Values) (int, string) { return 200, statusFinished },
+		})
+		defer restore()
+		_, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{})
+		if !errors.Is(err, ErrAlreadyFilled) {
+			t.Errorf("expected ErrAlreadyFilled, got %v", err)
+		}
+		if f.called("/buy/btcusd/") {
+			t.Error("no order must be placed")
+		}
+	})
+
+	t.Run("filled during cancel", func(t *testing.T) {
+		f, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) { return 200, `{"id": 10, "amount": "0", "price": "8500", "type": 0}` },
+			"/order_status/": func(url.Values) (int, string) { return 200, statusFinished },
+		})
+		defer restore()
+		result, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{})
+		if !errors.Is(err, ErrAlreadyFilled) {
+			t.Errorf("expected ErrAlreadyFilled, got %v", err)
+		}
+		if result == nil || result.Filled != 1 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if f.called("/buy/btcusd/") {
+			t.Error("no order must be placed")
+		}
+	})
+
+	t.Run("missing order", func(t *testing.T) {
+		_, restore := withFakeExchange(map[string]fakeHandler{
+			"/cancel_order/": func(url.Values) (int, string) { return 200, notFound },
+			"/order_status/": func(url.Values) (int, string) { return 200, notFound },
+		})
+		defer restore()
+		_, err := api.ReplaceOrder(context.Background(), 10, 8400, 1, LimitOrderOpts{})
+		if !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("expected ErrOrderNotFound, got %v", err)
+		}
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		f, restore := withFakeExchange(nil)
+		defer restore()
+		if _, err := api.ReplaceOrder(context.Background(), 10, 0, 1, LimitOrderOpts{}); err == nil {
+			t.Error("error expected")
+		}
+		if f.called("/cancel_order/") {
+			t.Error("the order must not be canceled")
+		}
+	})
+}
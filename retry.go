@@ -0,0 +1,116 @@
+package bitstamp
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy decides if a failed idempotent request is sent again.
+// Public requests and private read-only requests are retried. Orders, withdrawals
+// and other requests changing the account state are never retried.
+type RetryPolicy interface {
+	// Retry is called after the attempt-th failed attempt, starting from 1.
+	// It returns the delay before the next attempt, and false, if the request must not be retried.
+	Retry(attempt int, err error) (time.Duration, bool)
+}
+
+// DefaultRetryableStatus are the status codes retried by BackoffPolicy by default.
+var DefaultRetryableStatus = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// BackoffPolicy retries transport errors and responses with retryable status codes
+// with exponentially growing delays.
+type BackoffPolicy struct {
+	// MaxAttempts is the max number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the second attempt. Each next delay is doubled.
+	BaseDelay time.Duration
+	// MaxDelay, if positive, limits the delays.
+	MaxDelay time.Duration
+	// Jitter is the fraction of a delay, which is randomly subtracted from it, in [0, 1].
+	Jitter float64
+	// RetryableStatus are the retried status codes. If nil, DefaultRetryableStatus is used.
+	RetryableStatus []int
+}
+
+// Retry implements RetryPolicy.
+func (p *BackoffPolicy) Retry(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !p.retryable(err) {
+		return 0, false
+	}
+	delay := p.BaseDelay << uint(attempt-1)
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay < p.BaseDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay, true
+}
+
+func (p *BackoffPolicy) retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return isTransportError(err)
+	}
+	statuses := p.RetryableStatus
+	if statuses == nil {
+		statuses = DefaultRetryableStatus
+	}
+	for _, status := range statuses {
+		if apiErr.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransportError checks if err is a network error, or a response, which was cut off.
+func isTransportError(err error) bool {
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetries calls send until it succeeds, or the retries are over.
+// Rate limited requests are retried within Api.RateLimitRetryBudget, if rateLimitRetry is set.
+// Other errors are retried according to Api.RetryPolicy.
+func (api *Api) withRetries(ctx context.Context, rateLimitRetry bool, send func() ([]byte, error)) ([]byte, error) {
+	var budget time.Duration
+	if rateLimitRetry {
+		budget = api.RateLimitRetryBudget
+	}
+	for attempt := 1; ; attempt++ {
+		body, err := send()
+		if err == nil {
+			return body, nil
+		}
+		delay, ok := retryDelay(err, budget)
+		if ok {
+			budget -= delay
+		} else if api.RetryPolicy == nil || ctx.Err() != nil {
+			return nil, err
+		} else if delay, ok = api.RetryPolicy.Retry(attempt, err); !ok {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// the next attempt can't be made in time.
+			return nil, err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, errors.Wrap(err, "waiting for retry")
+		}
+		traceRetry(ctx)
+	}
+}
@@ -0,0 +1,119 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestBackoffPolicy(t *testing.T) {
+	p := &BackoffPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	transportErr := &url.Error{Op: "Get", URL: "https://www.bitstamp.net", Err: errors.New("connection reset by peer")}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, e := range expected {
+		delay, ok := p.Retry(i+1, transportErr)
+		if !ok || delay != e {
+			t.Errorf("attempt %d: expected %v, got %v, %v", i+1, e, delay, ok)
+		}
+	}
+	if _, ok := p.Retry(4, transportErr); ok {
+		t.Error("must give up after max attempts")
+	}
+	errs := map[error]bool{
+		&APIError{StatusCode: 503, Reason: "Service Unavailable"}: true,
+		&APIError{StatusCode: 404, Reason: "Not Found"}:           false,
+		&APIError{StatusCode: 200, Reason: "Invalid nonce"}:       false,
+		errors.New("invalid character"):                           false,
+	}
+	for err, retry := range errs {
+		if _, ok := p.Retry(1, err); ok != retry {
+			t.Errorf("%v: expected %v, got %v", err, retry, ok)
+		}
+	}
+	p.RetryableStatus = []int{404}
+	if _, ok := p.Retry(1, &APIError{StatusCode: 404}); !ok {
+		t.Error("custom status codes must be retried")
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay, _ := p.Retry(1, transportErr)
+		if delay < 50*time.Millisecond || delay > 100*time.Millisecond {
+			t.Fatalf("delay %v is out of range", delay)
+		}
+	}
+}
+
+func TestRetrySecondAttempt(t *testing.T) {
+	var calls int
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"last": "10455.51"}`)),
+			Request:    req,
+		}, nil
+	}))()
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api, err := NewClient(WithRetryPolicy(&BackoffPolicy{MaxAttempts: 3, BaseDelay: time.Second}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticker, err := api.GetTicker("btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last != 10455.51 || calls != 2 {
+		t.Errorf("unexpected result %+v after %d calls", *ticker, calls)
+	}
+	if len(delays) != 1 || delays[0] != time.Second {
+		t.Errorf("expected a single 1s delay, got %v", delays)
+	}
+}
+
+func TestRetryGiveUp(t *testing.T) {
+	var calls int
+	handler := func(url.Values) (int, string) {
+		calls++
+		return 502, `<html>Bad Gateway</html>`
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/balance/":            handler,
+		"/buy/btcusd/":         handler,
+		"/sell/market/btcusd/": handler,
+	})
+	defer restore()
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	api := NewWithKey("key", "secret")
+	api.RetryPolicy = &BackoffPolicy{MaxAttempts: 3, BaseDelay: time.Second}
+	_, err := api.GetAccountBalance()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 502 {
+		t.Errorf("expected a 502 error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if expected := []time.Duration{time.Second, 2 * time.Second}; len(delays) != 2 || delays[0] != expected[0] || delays[1] != expected[1] {
+		t.Errorf("expected delays %v, got %v", expected, delays)
+	}
+	calls = 0
+	if _, err := api.BuyLimitOrder("btcusd", 100, 1, LimitOrderOpts{}); err == nil {
+		t.Error("error expected")
+	}
+	if _, err := api.SellMarketOrder("btcusd", 1, MarketOrderOpts{}); err == nil {
+		t.Error("error expected")
+	}
+	if calls != 2 {
+		t.Errorf("orders must not be retried, got %d calls", calls)
+	}
+}
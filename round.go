@@ -0,0 +1,132 @@
+package bitstamp
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RoundingMode is a way of rounding prices and amounts.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest value, and halves away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundDown rounds towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+// RoundForPair formats a price and an amount with exactly the number of decimals allowed for the pair.
+// Float artifacts are removed before rounding, so 0.1+0.2 is rounded as 0.3 in all modes.
+func RoundForPair(pair PairInfo, price, amount float64, mode RoundingMode) (string, string) {
+	return roundDecimals(normalizeFloat(price), pair.CounterDecimals, mode),
+		roundDecimals(normalizeFloat(amount), pair.BaseDecimals, mode)
+}
+
+// roundDecimals formats v as a plain decimal string with exactly decimals digits after the point.
+func roundDecimals(v float64, decimals int, mode RoundingMode) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	var sign string
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart := s, ""
+	if idx := strings.IndexByte(s, '.'); idx >= 0 {
+		intPart, fracPart = s[:idx], s[idx+1:]
+	}
+	for len(fracPart) < decimals {
+		fracPart += "0"
+	}
+	kept, dropped := fracPart[:decimals], fracPart[decimals:]
+	var increment bool
+	switch mode {
+	case RoundHalfUp:
+		increment = dropped != "" && dropped[0] >= '5'
+	case RoundUp:
+		increment = strings.Trim(dropped, "0") != ""
+	}
+	digits := intPart + kept
+	if increment {
+		digits = incrementDigits(digits)
+	}
+	intPart, kept = digits[:len(digits)-decimals], digits[len(digits)-decimals:]
+	if strings.Trim(digits, "0") == "" {
+		sign = ""
+	}
+	if decimals == 0 {
+		return sign + intPart
+	}
+	return sign + intPart + "." + kept
+}
+
+// incrementDigits adds one to a string of decimal digits.
+func incrementDigits(digits string) string {
+	b := []byte(digits)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '9' {
+			b[i]++
+			return string(b)
+		}
+		b[i] = '0'
+	}
+	return "1" + string(b)
+}
+
+// EnableAutoRounding makes the order placement methods round prices and amounts
+// to the precision of the pair with the given mode. The pairs info is fetched on the first use
+// and cached like for EnableSymbolValidation.
+// It must be called before the api object is used concurrently.
+func (api *Api) EnableAutoRounding(mode RoundingMode) {
+	if api.pairs == nil {
+		api.setPairCache(defaultPairsTTL)
+	}
+	api.rounding = &mode
+}
+
+// roundOrderValues rounds the given price and amount fields of the order values, if auto rounding is enabled.
+// Prices are rounded to the counter decimals. Amounts are rounded to the base decimals,
+// or to the counter decimals if quoteAmount is set.
+func (api *Api) roundOrderValues(symbol string, values url.Values, quoteAmount bool) error {
+	if api.rounding == nil {
+		return nil
+	}
+	pair, err := api.pairs.get(strings.ToLower(symbol))
+	if err != nil {
+		return err
+	}
+	amountDecimals := pair.BaseDecimals
+	if quoteAmount {
+		amountDecimals = pair.CounterDecimals
+	}
+	fields := []struct {
+		name     string
+		decimals int
+	}{
+		{"price", pair.CounterDecimals},
+		{"limit_price", pair.CounterDecimals},
+		{"amount", amountDecimals},
+	}
+	for _, f := range fields {
+		s := values.Get(f.name)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return errors.Wrapf(err, "%s parsing error", f.name)
+		}
+		rounded := roundDecimals(v, f.decimals, *api.rounding)
+		if strings.Trim(rounded, "0.") == "" {
+			return errors.Errorf("%s %s rounds to zero with %d decimals", f.name, s, f.decimals)
+		}
+		values.Set(f.name, rounded)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"math"
+	"net/url"
+	"testing"
+)
+
+// artifact is 0.30000000000000004, as 0.1+0.2 constants would be evaluated exactly.
+var artifact = func(a, b float64) float64 { return a + b }(0.1, 0.2)
+
+func TestRoundDecimals(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		mode     RoundingMode
+		expected string
+	}{
+		{artifact, 8, RoundHalfUp, "0.30000000"},
+		{artifact, 8, RoundDown, "0.30000000"},
+		{artifact, 8, RoundUp, "0.30000001"},
+		{0.3, 1, RoundDown, "0.3"},
+		{0.3, 1, RoundUp, "0.3"},
+		{0.125, 2, RoundHalfUp, "0.13"},
+		{0.125, 2, RoundDown, "0.12"},
+		{0.124999, 2, RoundHalfUp, "0.12"},
+		{9.999, 2, RoundHalfUp, "10.00"},
+		{9.999, 2, RoundDown, "9.99"},
+		{99.9951, 2, RoundUp, "100.00"},
+		{10455.5, 0, RoundHalfUp, "10456"},
+		{10455.5, 0, RoundDown, "10455"},
+		{10455, 2, RoundHalfUp, "10455.00"},
+		{0, 2, RoundUp, "0.00"},
+		{1e-9, 8, RoundHalfUp, "0.00000000"},
+		{1e-9, 8, RoundUp, "0.00000001"},
+		{1e21, 2, RoundHalfUp, "1000000000000000000000.00"},
+		{-1.005, 2, RoundHalfUp, "-1.01"},
+		{-0.001, 2, RoundHalfUp, "0.00"},
+		{123.456, -1, RoundDown, "123"},
+		{math.SmallestNonzeroFloat64, 8, RoundDown, "0.00000000"},
+	}
+	for _, test := range tests {
+		if got := roundDecimals(test.v, test.decimals, test.mode); got != test.expected {
+			t.Errorf("%v with %d decimals, mode %d: expected %s, got %s", test.v, test.decimals, test.mode, test.expected, got)
+		}
+	}
+}
+
+func TestRoundForPair(t *testing.T) {
+	pair := PairInfo{Name: "BTC/USD", URLSymbol: "btcusd", BaseDecimals: 8, CounterDecimals: 0}
+	price, amount := RoundForPair(pair, 10455.51, artifact, RoundHalfUp)
+	if price != "10456" || amount != "0.30000000" {
+		t.Errorf("unexpected result %s, %s", price, amount)
+	}
+	for _, mode := range []RoundingMode{RoundUp, RoundDown} {
+		price, amount := RoundForPair(PairInfo{BaseDecimals: 8, CounterDecimals: 2}, artifact, artifact, mode)
+		if price != "0.30" || amount != "0.30000000" {
+			t.Errorf("mode %d: unexpected result %s, %s", mode, price, amount)
+		}
+	}
+	// 0.7-0.1 is 0.6000000000000001, and 0.3-0.1 is 0.19999999999999998.
+	diff := func(a, b float64) float64 { return a - b }
+	if _, amount := RoundForPair(pair, 0, diff(0.7, 0.1), RoundUp); amount != "0.60000000" {
+		t.Errorf("unexpected rounded up amount %s", amount)
+	}
+	if _, amount := RoundForPair(pair, 0, diff(0.3, 0.1), RoundDown); amount != "0.20000000" {
+		t.Errorf("unexpected rounded down amount %s", amount)
+	}
+}
+
+func TestAutoRounding(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_pairs_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := make(map[string]url.Values)
+	order := func(path string) fakeHandler {
+		return func(values url.Values) (int, string) {
+			sent[path] = values
+			return 200, `{"id": "1", "datetime": "2019-09-06 07:35:04", "type": "0", "price": "1", "amount": "1"}`
+		}
+	}
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/trading-pairs-info/": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+		"/buy/btcusd/":          order("/buy/btcusd/"),
+		"/sell/market/ethbtc/":  order("/sell/market/ethbtc/"),
+		"/buy/instant/btcusd/":  order("/buy/instant/btcusd/"),
+		"/sell/instant/btcusd/": order("/sell/instant/btcusd/"),
+	})
+	defer restore()
+	api := NewWithKey("key", "secret")
+	api.EnableAutoRounding(RoundDown)
+	if _, err := api.BuyLimitOrder("btcusd", 10455.99, artifact, LimitOrderOpts{LimitPrice: 10500.5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.SellMarketOrder("ethbtc", 1.123456789, MarketOrderOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.BuyInstantOrder("btcusd", 100.5, InstantOrderOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.SellInstantOrder("btcusd", 0.123456789, InstantOrderOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"/buy/btcusd/":          "amount=0.30000000&limit_price=10500&price=10455",
+		"/sell/market/ethbtc/":  "amount=1.12345678",
+		"/buy/instant/btcusd/":  "amount=100",
+		"/sell/instant/btcusd/": "amount=0.12345678",
+	}
+	for path, e := range expected {
+		if got := sent[path].Encode(); got != e {
+			t.Errorf("%s: expected %q, got %q", path, e, got)
+		}
+	}
+	if _, err := api.BuyLimitOrder("btcusd", 0.5, 1, LimitOrderOpts{}); err == nil {
+		t.Error("error expected for a price rounded to zero")
+	}
+}
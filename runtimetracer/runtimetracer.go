@@ -0,0 +1,38 @@
+// Package runtimetracer traces the requests of a bitstamp client with runtime/trace.
+// Each span is a trace task, so the requests are grouped under the tasks of their callers
+// in the output of go tool trace. It needs no dependencies, and is useful for local profiling.
+// For distributed tracing, see the oteltracer module.
+package runtimetracer
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+
+	bitstamp "github.com/avdva/bitstamp-go"
+)
+
+var (
+	_ bitstamp.Tracer        = Tracer{}
+	_ bitstamp.SpanAnnotator = Tracer{}
+)
+
+// Tracer is a bitstamp.Tracer, which starts runtime/trace tasks.
+// Attributes and errors are logged within the tasks.
+type Tracer struct{}
+
+// Start implements bitstamp.Tracer.
+func (Tracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, task := trace.NewTask(ctx, name)
+	return ctx, func(err error) {
+		if err != nil {
+			trace.Log(ctx, "error", err.Error())
+		}
+		task.End()
+	}
+}
+
+// SetAttribute implements bitstamp.SpanAnnotator.
+func (Tracer) SetAttribute(ctx context.Context, key string, value interface{}) {
+	trace.Log(ctx, key, fmt.Sprint(value))
+}
@@ -0,0 +1,27 @@
+package runtimetracer
+
+import (
+	"bytes"
+	"context"
+	"runtime/trace"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestTracer(t *testing.T) {
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip("tracing is not available:", err)
+	}
+	defer trace.Stop()
+	ctx, end := Tracer{}.Start(context.Background(), "bitstamp.request")
+	Tracer{}.SetAttribute(ctx, "bitstamp.endpoint", "/balance/")
+	end(errors.New("failed"))
+	trace.Stop()
+	for _, s := range []string{"bitstamp.request", "bitstamp.endpoint", "/balance/", "failed"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("%q is not in the trace", s)
+		}
+	}
+}
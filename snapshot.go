@@ -0,0 +1,68 @@
+package bitstamp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Snapshot is a state of a market.
+type Snapshot struct {
+	Ticker    *Ticker
+	OrderBook *OrderBook
+	Trades    []Trade
+	// FetchedAt is the time, when all the requests completed.
+	FetchedAt time.Time
+	// TickerLatency, OrderBookLatency and TradesLatency are the durations of the requests.
+	TickerLatency    time.Duration
+	OrderBookLatency time.Duration
+	TradesLatency    time.Duration
+}
+
+// GetMarketSnapshot fetches the ticker, the order book and the last trades of the given symbol concurrently.
+// If a request fails, the others are canceled and the first error is returned.
+func (api *Api) GetMarketSnapshot(ctx context.Context, symbol string) (*Snapshot, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		snapshot Snapshot
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	run := func(name string, latency *time.Duration, fetch func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := fetch()
+			*latency = time.Since(start)
+			if err != nil {
+				once.Do(func() {
+					firstErr = errors.Wrap(err, name)
+					cancel()
+				})
+			}
+		}()
+	}
+	run("ticker", &snapshot.TickerLatency, func() (err error) {
+		snapshot.Ticker, err = api.GetTickerCtx(ctx, symbol)
+		return err
+	})
+	run("order book", &snapshot.OrderBookLatency, func() (err error) {
+		snapshot.OrderBook, err = api.GetOrderBookCtx(ctx, symbol)
+		return err
+	})
+	run("trades", &snapshot.TradesLatency, func() (err error) {
+		snapshot.Trades, err = api.GetTradesCtx(ctx, symbol)
+		return err
+	})
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	snapshot.FetchedAt = time.Now()
+	return &snapshot, nil
+}
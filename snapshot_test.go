@@ -0,0 +1,72 @@
+package bitstamp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetMarketSnapshot(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			return 200, `{"last": "10455.51", "bid": "10453.00", "ask": "10455.51"}`
+		},
+		"/order_book/btcusd": func(url.Values) (int, string) {
+			return 200, `{"timestamp": "1567755304", "bids": [["10453.00", "0.5"]], "asks": [["10455.51", "0.02"]]}`
+		},
+		"/transactions/btcusd": func(url.Values) (int, string) {
+			return 200, `[{"date": "1567755304", "tid": "1", "amount": "0.02", "type": "0", "price": "10455.51"}]`
+		},
+	})
+	defer restore()
+	start := time.Now()
+	snapshot, err := New("", "").GetMarketSnapshot(context.Background(), "btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Ticker.Last != 10455.51 || len(snapshot.OrderBook.Bids) != 1 || len(snapshot.Trades) != 1 {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	if snapshot.FetchedAt.Before(start) {
+		t.Errorf("unexpected fetch time %v", snapshot.FetchedAt)
+	}
+	if snapshot.TickerLatency <= 0 || snapshot.OrderBookLatency <= 0 || snapshot.TradesLatency <= 0 {
+		t.Errorf("latencies must be set: %+v", snapshot)
+	}
+}
+
+func TestGetMarketSnapshotError(t *testing.T) {
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"last": "10455.51"}`
+		switch req.URL.Path {
+		case "/api/v2/order_book/btcusd":
+			// blocks until the snapshot is canceled.
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		case "/api/v2/transactions/btcusd":
+			body = `<html>not found</html>`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	}))()
+	done := make(chan error, 1)
+	go func() {
+		_, err := New("", "").GetMarketSnapshot(context.Background(), "btcusd")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.HasPrefix(err.Error(), "trades") {
+			t.Errorf("trades error expected, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the order book request was not canceled")
+	}
+}
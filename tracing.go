@@ -0,0 +1,121 @@
+package bitstamp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Attributes set on the spans of rest requests.
+const (
+	// AttrEndpoint is the api path, like /balance/.
+	AttrEndpoint = "bitstamp.endpoint"
+	// AttrPair is the symbol of the trading pair, like btcusd, if the endpoint has one.
+	AttrPair = "bitstamp.pair"
+	// AttrStatusCode is the http status code of the last response, if any.
+	AttrStatusCode = "http.status_code"
+	// AttrRetries is the number of retries of the request.
+	AttrRetries = "bitstamp.retries"
+	// AttrURL is the url of a websocket connection.
+	AttrURL = "bitstamp.url"
+)
+
+// Span names used by the client.
+const (
+	// SpanRequest is a rest request, including its retries.
+	SpanRequest = "bitstamp.request"
+	// SpanWebsocketConnect is a websocket connection and subscription.
+	SpanWebsocketConnect = "bitstamp.websocket.connect"
+)
+
+// Tracer starts spans around rest requests and websocket connections.
+// Implementations must be safe for concurrent use. See the oteltracer module for an OpenTelemetry adapter.
+type Tracer interface {
+	// Start starts a span, which is a child of the span in ctx, if any.
+	// It returns a context with the new span, and a function, which ends the span
+	// with the result of the operation.
+	Start(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// SpanAnnotator may be implemented by a Tracer to receive the attributes of the spans,
+// like AttrEndpoint and AttrStatusCode. Start only names a span, and the status code
+// and the retries are known only after the request, so the attributes need a separate method.
+// Tracers, which don't implement it, get the spans without attributes.
+type SpanAnnotator interface {
+	// SetAttribute annotates the span in ctx, which was started by Start.
+	SetAttribute(ctx context.Context, key string, value interface{})
+}
+
+// spanState collects the attributes of a request span.
+type spanState struct {
+	statusCode int
+	retries    int
+}
+
+type spanStateKey struct{}
+
+// pairPathRe matches the paths of the endpoints with a trading pair.
+var pairPathRe = regexp.MustCompile(`^/(?:balance|fees/trading|ticker|ticker_hour|order_book|transactions|ohlc|open_orders|user_transactions|cancel_all_orders|(?:buy|sell)(?:/market|/instant)?)/([a-z0-9]+)/?$`)
+
+// pathPair returns the trading pair of an api path, or an empty string.
+func pathPair(path string) string {
+	m := pairPathRe.FindStringSubmatch(path)
+	if m == nil || m[1] == "all" {
+		return ""
+	}
+	return m[1]
+}
+
+// traceRequest calls send within a request span, if Api.Tracer is set.
+func (api *Api) traceRequest(ctx context.Context, path string, send func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if api.Tracer == nil {
+		return send(ctx)
+	}
+	ctx, end := api.Tracer.Start(ctx, SpanRequest)
+	state := &spanState{}
+	body, err := send(context.WithValue(ctx, spanStateKey{}, state))
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if annotator, ok := api.Tracer.(SpanAnnotator); ok {
+		annotator.SetAttribute(ctx, AttrEndpoint, path)
+		if pair := pathPair(path); pair != "" {
+			annotator.SetAttribute(ctx, AttrPair, pair)
+		}
+		if state.statusCode != 0 {
+			annotator.SetAttribute(ctx, AttrStatusCode, state.statusCode)
+		}
+		annotator.SetAttribute(ctx, AttrRetries, state.retries)
+	}
+	end(err)
+	return body, err
+}
+
+// traceWebsocketConnect calls connect within a websocket connection span, if Api.Tracer is set.
+func (api *Api) traceWebsocketConnect(ctx context.Context, url, pair string, connect func() error) error {
+	if api.Tracer == nil {
+		return connect()
+	}
+	ctx, end := api.Tracer.Start(ctx, SpanWebsocketConnect)
+	err := connect()
+	if annotator, ok := api.Tracer.(SpanAnnotator); ok {
+		annotator.SetAttribute(ctx, AttrURL, url)
+		annotator.SetAttribute(ctx, AttrPair, pair)
+	}
+	end(err)
+	return err
+}
+
+// traceStatus records the status code of a response in the request span of ctx.
+func traceStatus(ctx context.Context, code int) {
+	if state, ok := ctx.Value(spanStateKey{}).(*spanState); ok && code != 0 {
+		state.statusCode = code
+	}
+}
+
+// traceRetry counts a retry in the request span of ctx.
+func traceRetry(ctx context.Context) {
+	if state, ok := ctx.Value(spanStateKey{}).(*spanState); ok {
+		state.retries++
+	}
+}
@@ -0,0 +1,170 @@
+package bitstamp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	err    error
+}
+
+type spanKey struct{}
+
+// recordingTracer stores the ended spans. The name of the current span is stored in the context.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (tr *recordingTracer) Start(ctx context.Context, name string) (context.Context, func(err error)) {
+	span := &recordedSpan{name: name, attrs: map[string]interface{}{}}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	return context.WithValue(ctx, spanKey{}, span), func(err error) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		span.err = err
+		tr.spans = append(tr.spans, span)
+	}
+}
+
+func (tr *recordingTracer) SetAttribute(ctx context.Context, key string, value interface{}) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	ctx.Value(spanKey{}).(*recordedSpan).attrs[key] = value
+}
+
+func TestRequestSpans(t *testing.T) {
+	var tickerCalls int
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) {
+			if tickerCalls++; tickerCalls == 1 {
+				return http.StatusServiceUnavailable, "unavailable"
+			}
+			return http.StatusOK, `{"last": "100.0"}`
+		},
+		"/balance/": func(url.Values) (int, string) {
+			return http.StatusForbidden, `{"status": "error", "reason": "Invalid signature", "code": "API0005"}`
+		},
+	})
+	defer restore()
+	var delays []time.Duration
+	defer withFakeSleep(&delays)()
+	tracer := &recordingTracer{}
+	api, err := NewClient(
+		WithCredentials("key", "secret"),
+		WithTracer(tracer),
+		WithRetryPolicy(&BackoffPolicy{MaxAttempts: 2, BaseDelay: time.Second}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, end := tracer.Start(context.Background(), "caller")
+	if _, err := api.GetTickerCtx(ctx, "btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	_, balanceErr := api.GetAccountBalanceCtx(ctx)
+	end(nil)
+	expected := []*recordedSpan{
+		{
+			name:   SpanRequest,
+			parent: "caller",
+			attrs:  map[string]interface{}{AttrEndpoint: "/ticker/btcusd", AttrPair: "btcusd", AttrStatusCode: 200, AttrRetries: 1},
+		},
+		{
+			name:   SpanRequest,
+			parent: "caller",
+			attrs:  map[string]interface{}{AttrEndpoint: "/balance/", AttrStatusCode: 403, AttrRetries: 0},
+			err:    balanceErr,
+		},
+		{name: "caller", attrs: map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(tracer.spans, expected) {
+		for _, span := range tracer.spans {
+			t.Logf("%+v", *span)
+		}
+		t.Error("unexpected spans")
+	}
+	if !errors.Is(balanceErr, ErrAuthentication) {
+		t.Errorf("expected %v, got %v", ErrAuthentication, balanceErr)
+	}
+	if _, err := NewClient(WithTracer(nil)); err == nil {
+		t.Error("error expected for a nil tracer")
+	}
+}
+
+func TestWebsocketSpan(t *testing.T) {
+	srv := orderBookServer(t)
+	defer srv.Close()
+	tracer := &recordingTracer{}
+	api := &Api{Tracer: tracer, WebsocketURL: "ws" + strings.TrimPrefix(srv.URL, "http")}
+	ctx, end := tracer.Start(context.Background(), "caller")
+	if err := api.SubscribeOrderBookCtx(ctx, "btcusd", make(chan OrderBook, 1), nil); err != nil {
+		t.Fatal(err)
+	}
+	end(nil)
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != SpanWebsocketConnect || span.parent != "caller" || span.err != nil ||
+		span.attrs[AttrURL] != api.WebsocketURL || span.attrs[AttrPair] != "btcusd" {
+		t.Errorf("unexpected span %+v", *span)
+	}
+}
+
+func TestTracerWithoutAttributes(t *testing.T) {
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) { return http.StatusOK, `{"last": "100.0"}` },
+	})
+	defer restore()
+	recorder := &recordingTracer{}
+	// the embedded interface hides SetAttribute of the recorder.
+	api := &Api{Tracer: struct{ Tracer }{recorder}}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.spans) != 1 || recorder.spans[0].name != SpanRequest || len(recorder.spans[0].attrs) != 0 {
+		t.Errorf("expected a request span without attributes, got %+v", recorder.spans)
+	}
+}
+
+func TestPathPair(t *testing.T) {
+	tests := map[string]string{
+		"/ticker/btcusd":              "btcusd",
+		"/ohlc/ethusd/":               "ethusd",
+		"/buy/market/btceur/":         "btceur",
+		"/sell/instant/xrpusd/":       "xrpusd",
+		"/fees/trading/btcusd/":       "btcusd",
+		"/open_orders/all/":           "",
+		"/balance/":                   "",
+		"/liquidation_address/info/":  "",
+		"/withdrawal/status/":         "",
+		"/cancel_all_orders/ltcusd/":  "ltcusd",
+		"/user_transactions/btcusd/":  "btcusd",
+		"/user_transactions/":         "",
+		"/transactions/btcusd/extra/": "",
+	}
+	for path, expected := range tests {
+		if pair := pathPair(path); pair != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, pair)
+		}
+	}
+}
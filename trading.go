@@ -0,0 +1,395 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OrderResult is a result of an order placement.
+type OrderResult struct {
+	ID   int64
+	Time time.Time
+	Type OrderType
+	// Price is the order price for limit orders,
+	// and the average execution price for market orders.
+	Price         float64
+	Amount        float64
+	ClientOrderID string
+}
+
+// maxClientOrderIDLength is the max length of a client order id.
+const maxClientOrderIDLength = 180
+
+// validateClientOrderID checks that id contains only letters, digits, '-', '_' and '.',
+// and is not too long.
+func validateClientOrderID(id string) error {
+	if len(id) > maxClientOrderIDLength {
+		return errors.Errorf("client order id is longer than %d characters", maxClientOrderIDLength)
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return errors.Errorf("invalid character %q in client order id", r)
+		}
+	}
+	return nil
+}
+
+func setClientOrderID(values url.Values, id string) error {
+	if id == "" {
+		return nil
+	}
+	if err := validateClientOrderID(id); err != nil {
+		return err
+	}
+	values.Set("client_order_id", id)
+	return nil
+}
+
+// maxOrderExpiration is the max duration from now to an order expiration time.
+const maxOrderExpiration = 30 * 24 * time.Hour
+
+// LimitOrderOpts are optional parameters of a limit order.
+// At most one of IOC, FOK, MOC, Daily and ExpireTime may be set.
+type LimitOrderOpts struct {
+	// LimitPrice, if set, places a sell (buy) order at this price,
+	// once the buy (sell) order is executed.
+	LimitPrice float64
+	// IOC makes an immediate-or-cancel order.
+	IOC bool
+	// FOK makes a fill-or-kill order.
+	FOK bool
+	// MOC makes a maker-or-cancel order.
+	MOC bool
+	// Daily makes an order, valid until midnight UTC.
+	Daily bool
+	// ExpireTime makes a good-til-date order, which expires at the given time.
+	// It must be in the future, but not later than 30 days from now.
+	ExpireTime time.Time
+	// ClientOrderID is an optional id of the order set by the client.
+	ClientOrderID string
+}
+
+func (o LimitOrderOpts) apply(values url.Values) error {
+	if o.LimitPrice < 0 {
+		return errors.New("limit price must not be negative")
+	}
+	flags := []struct {
+		set  bool
+		name string
+	}{
+		{o.IOC, "ioc_order"},
+		{o.FOK, "fok_order"},
+		{o.MOC, "moc_order"},
+		{o.Daily, "daily_order"},
+		{!o.ExpireTime.IsZero(), "expire_time"},
+	}
+	var flag string
+	for _, f := range flags {
+		if !f.set {
+			continue
+		}
+		if flag != "" {
+			return errors.Errorf("%s and %s are mutually exclusive", flag, f.name)
+		}
+		flag = f.name
+	}
+	if err := setClientOrderID(values, o.ClientOrderID); err != nil {
+		return err
+	}
+	if o.LimitPrice > 0 {
+		values.Set("limit_price", formatFloat(o.LimitPrice))
+	}
+	switch flag {
+	case "":
+	case "expire_time":
+		now := time.Now()
+		if !o.ExpireTime.After(now) {
+			return errors.New("expire time must be in the future")
+		}
+		if o.ExpireTime.Sub(now) > maxOrderExpiration {
+			return errors.Errorf("expire time must be within %v", maxOrderExpiration)
+		}
+		values.Set(flag, strconv.FormatInt(o.ExpireTime.UnixNano()/int64(time.Millisecond), 10))
+	default:
+		values.Set(flag, "True")
+	}
+	return nil
+}
+
+// BuyLimitOrder places a buy limit order.
+func (api *Api) BuyLimitOrder(symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error) {
+	return api.BuyLimitOrderCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// BuyLimitOrderCtx is like BuyLimitOrder, but uses the given context.
+func (api *Api) BuyLimitOrderCtx(ctx context.Context, symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error) {
+	return api.limitOrder(ctx, "buy", symbol, price, amount, opts)
+}
+
+// SellLimitOrder places a sell limit order.
+func (api *Api) SellLimitOrder(symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error) {
+	return api.SellLimitOrderCtx(context.Background(), symbol, price, amount, opts)
+}
+
+// SellLimitOrderCtx is like SellLimitOrder, but uses the given context.
+func (api *Api) SellLimitOrderCtx(ctx context.Context, symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error) {
+	return api.limitOrder(ctx, "sell", symbol, price, amount, opts)
+}
+
+func (api *Api) limitOrder(ctx context.Context, side, symbol string, price, amount float64, opts LimitOrderOpts) (*OrderResult, error) {
+	values, err := limitOrderValues(price, amount, opts)
+	if err != nil {
+		return nil, err
+	}
+	return api.sendLimitOrder(ctx, side, symbol, values, opts)
+}
+
+// sendLimitOrder checks and places a limit order with the given parameters.
+func (api *Api) sendLimitOrder(ctx context.Context, side, symbol string, values url.Values, opts LimitOrderOpts) (*OrderResult, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	if err := api.roundOrderValues(symbol, values, false); err != nil {
+		return nil, err
+	}
+	if err := api.checkMinimumOrder(symbol, values, false); err != nil {
+		return nil, err
+	}
+	result, err := api.placeOrder(ctx, "/"+side+"/"+strings.ToLower(symbol)+"/", values)
+	if err != nil && (opts.FOK || opts.IOC) && isNotFilled(err) {
+		return nil, withSentinel(ErrOrderNotFilled, err)
+	}
+	return result, err
+}
+
+// MarketOrderOpts are optional parameters of a market order.
+type MarketOrderOpts struct {
+	// ClientOrderID is an optional id of the order set by the client.
+	ClientOrderID string
+}
+
+// BuyMarketOrder places a buy market order. amount is in the base currency.
+func (api *Api) BuyMarketOrder(symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error) {
+	return api.BuyMarketOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// BuyMarketOrderCtx is like BuyMarketOrder, but uses the given context.
+func (api *Api) BuyMarketOrderCtx(ctx context.Context, symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error) {
+	return api.marketOrder(ctx, "buy", symbol, amount, opts)
+}
+
+// SellMarketOrder places a sell market order. amount is in the base currency.
+func (api *Api) SellMarketOrder(symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error) {
+	return api.SellMarketOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// SellMarketOrderCtx is like SellMarketOrder, but uses the given context.
+func (api *Api) SellMarketOrderCtx(ctx context.Context, symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error) {
+	return api.marketOrder(ctx, "sell", symbol, amount, opts)
+}
+
+func (api *Api) marketOrder(ctx context.Context, side, symbol string, amount float64, opts MarketOrderOpts) (*OrderResult, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	values, err := marketOrderValues(amount, opts.ClientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	if err := api.roundOrderValues(symbol, values, false); err != nil {
+		return nil, err
+	}
+	return api.placeOrder(ctx, "/"+side+"/market/"+strings.ToLower(symbol)+"/", values)
+}
+
+// InstantOrderOpts are optional parameters of an instant order.
+type InstantOrderOpts struct {
+	// LimitPrice, if set, prevents the order from executing beyond this price.
+	LimitPrice float64
+	// ClientOrderID is an optional id of the order set by the client.
+	ClientOrderID string
+}
+
+// BuyInstantOrder places a buy instant order. amount is in the quote currency.
+func (api *Api) BuyInstantOrder(symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error) {
+	return api.BuyInstantOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// BuyInstantOrderCtx is like BuyInstantOrder, but uses the given context.
+func (api *Api) BuyInstantOrderCtx(ctx context.Context, symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error) {
+	return api.instantOrder(ctx, "buy", symbol, amount, opts)
+}
+
+// SellInstantOrder places a sell instant order. amount is in the base currency.
+func (api *Api) SellInstantOrder(symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error) {
+	return api.SellInstantOrderCtx(context.Background(), symbol, amount, opts)
+}
+
+// SellInstantOrderCtx is like SellInstantOrder, but uses the given context.
+func (api *Api) SellInstantOrderCtx(ctx context.Context, symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error) {
+	return api.instantOrder(ctx, "sell", symbol, amount, opts)
+}
+
+func (api *Api) instantOrder(ctx context.Context, side, symbol string, amount float64, opts InstantOrderOpts) (*OrderResult, error) {
+	if symbol == "" {
+		return nil, errors.New("empty symbol")
+	}
+	values, err := instantOrderValues(amount, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.validateSymbol(symbol); err != nil {
+		return nil, err
+	}
+	if err := api.roundOrderValues(symbol, values, side == "buy"); err != nil {
+		return nil, err
+	}
+	if side == "buy" {
+		if err := api.checkMinimumOrder(symbol, values, true); err != nil {
+			return nil, err
+		}
+	}
+	return api.placeOrder(ctx, "/"+side+"/instant/"+strings.ToLower(symbol)+"/", values)
+}
+
+// checkMinimumOrder checks the order value against the minimum of the pair,
+// if the trading pairs info is cached. The value is price times amount,
+// or the amount, if quoteAmount is set. Orders without a price are not checked.
+func (api *Api) checkMinimumOrder(symbol string, values url.Values, quoteAmount bool) error {
+	if api.pairs == nil || api.SkipMinimumOrderCheck {
+		return nil
+	}
+	info, err := api.pairs.get(strings.ToLower(symbol))
+	if err != nil {
+		return err
+	}
+	pair, err := info.Pair()
+	if err != nil || info.MinimumOrderAmount <= 0 || info.MinimumOrderCurrency != pair.Quote() {
+		// the minimum is unknown, or can't be compared with the order value.
+		return nil
+	}
+	value, err := strconv.ParseFloat(values.Get("amount"), 64)
+	if err != nil {
+		return errors.Wrap(err, "amount parsing error")
+	}
+	if !quoteAmount {
+		price, err := strconv.ParseFloat(values.Get("price"), 64)
+		if err != nil {
+			return errors.Wrap(err, "price parsing error")
+		}
+		value *= price
+	}
+	// allow for float errors of the multiplication.
+	if value >= info.MinimumOrderAmount*(1-1e-9) {
+		return nil
+	}
+	return withSentinel(ErrBelowMinimumOrder, &MinimumOrderError{
+		Value:    value,
+		Minimum:  info.MinimumOrderAmount,
+		Currency: info.MinimumOrderCurrency,
+	})
+}
+
+// placeOrder sends an order placement request and parses the result.
+// Api errors are returned as *OrderError.
+func (api *Api) placeOrder(ctx context.Context, path string, values url.Values) (*OrderResult, error) {
+	body, err := api.post(ctx, path, values)
+	if err != nil {
+		return nil, newOrderError(err)
+	}
+	var result OrderResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func instantOrderValues(amount float64, opts InstantOrderOpts) (url.Values, error) {
+	values, err := marketOrderValues(amount, opts.ClientOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.LimitPrice < 0 {
+		return nil, errors.New("limit price must not be negative")
+	}
+	if opts.LimitPrice > 0 {
+		values.Set("limit_price", formatFloat(opts.LimitPrice))
+	}
+	return values, nil
+}
+
+func marketOrderValues(amount float64, clientOrderID string) (url.Values, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	values := url.Values{}
+	values.Set("amount", formatFloat(amount))
+	if err := setClientOrderID(values, clientOrderID); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func limitOrderValues(price, amount float64, opts LimitOrderOpts) (url.Values, error) {
+	if price <= 0 {
+		return nil, errors.New("price must be positive")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	values := url.Values{}
+	values.Set("price", formatFloat(price))
+	values.Set("amount", formatFloat(amount))
+	if err := opts.apply(values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// UnmarshalJSON decodes an order result, where numbers may be encoded as strings.
+func (r *OrderResult) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID            interface{} `json:"id"`
+		Datetime      interface{} `json:"datetime"`
+		Type          interface{} `json:"type"`
+		Price         interface{} `json:"price"`
+		Amount        interface{} `json:"amount"`
+		ClientOrderID interface{} `json:"client_order_id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var err error
+	result := OrderResult{ClientOrderID: stringValue(raw.ClientOrderID)}
+	if result.ID, err = parseIntValue(raw.ID); err != nil {
+		return errors.Wrap(err, "id parsing error")
+	}
+	if result.Time, err = parseTimeValue(raw.Datetime); err != nil {
+		return errors.Wrap(err, "datetime parsing error")
+	}
+	if result.Type, err = parseOrderType(raw.Type); err != nil {
+		return errors.Wrap(err, "type parsing error")
+	}
+	if result.Price, err = parseNumber("price", raw.Price); err != nil {
+		return err
+	}
+	if result.Amount, err = parseNumber("amount", raw.Amount); err != nil {
+		return err
+	}
+	*r = result
+	return nil
+}
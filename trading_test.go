@@ -0,0 +1,315 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestLimitOrderValues(t *testing.T) {
+	tests := []struct {
+		price, amount float64
+		opts          LimitOrderOpts
+		expected      string
+	}{
+		{price: 8500, amount: 0.01, expected: "amount=0.01&price=8500"},
+		{price: 0.00001234, amount: 100000000, expected: "amount=100000000&price=0.00001234"},
+		{price: 1e-8, amount: 1.5e21, expected: "amount=1500000000000000000000&price=0.00000001"},
+		{price: 8500.5, amount: 0.1, opts: LimitOrderOpts{LimitPrice: 9000}, expected: "amount=0.1&limit_price=9000&price=8500.5"},
+		{price: 1, amount: 2, opts: LimitOrderOpts{IOC: true}, expected: "amount=2&ioc_order=True&price=1"},
+		{price: 1, amount: 2, opts: LimitOrderOpts{FOK: true}, expected: "amount=2&fok_order=True&price=1"},
+		{price: 1, amount: 2, opts: LimitOrderOpts{MOC: true}, expected: "amount=2&moc_order=True&price=1"},
+		{price: 1, amount: 2, opts: LimitOrderOpts{Daily: true}, expected: "amount=2&daily_order=True&price=1"},
+		{price: 1, amount: 2, opts: LimitOrderOpts{ClientOrderID: "my-order_1.a"}, expected: "amount=2&client_order_id=my-order_1.a&price=1"},
+	}
+	for _, test := range tests {
+		values, err := limitOrderValues(test.price, test.amount, test.opts)
+		if err != nil {
+			t.Errorf("%v, %v: unexpected error %v", test.price, test.amount, err)
+			continue
+		}
+		if got := values.Encode(); got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+	}
+	for _, invalid := range [][2]float64{{0, 1}, {1, 0}, {-1, 1}, {1, -1}} {
+		if _, err := limitOrderValues(invalid[0], invalid[1], LimitOrderOpts{}); err == nil {
+			t.Errorf("%v: error expected", invalid)
+		}
+	}
+}
+
+func TestLimitOrderExclusiveFlags(t *testing.T) {
+	invalid := []LimitOrderOpts{
+		{IOC: true, FOK: true},
+		{FOK: true, MOC: true},
+		{MOC: true, Daily: true},
+		{IOC: true, FOK: true, MOC: true, Daily: true},
+		{Daily: true, ExpireTime: time.Now().Add(time.Hour)},
+	}
+	for _, opts := range invalid {
+		if _, err := limitOrderValues(1, 1, opts); err == nil {
+			t.Errorf("%+v: error expected", opts)
+		}
+	}
+}
+
+func TestLimitOrderExpireTime(t *testing.T) {
+	expire := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	values, err := limitOrderValues(1, 1, LimitOrderOpts{ExpireTime: expire})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := strconv.FormatInt(expire.UnixNano()/1e6, 10); values.Get("expire_time") != expected {
+		t.Errorf("expected %s, got %s", expected, values.Get("expire_time"))
+	}
+	for _, invalid := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(31 * 24 * time.Hour)} {
+		if _, err := limitOrderValues(1, 1, LimitOrderOpts{ExpireTime: invalid}); err == nil {
+			t.Errorf("%v: error expected", invalid)
+		}
+	}
+}
+
+func TestIsNotFilled(t *testing.T) {
+	err := parseAPIError([]byte(`{"status": "error", "reason": {"__all__": ["Fill or kill order could not be filled."]}}`))
+	if !isNotFilled(err) {
+		t.Errorf("not filled error expected for %v", err)
+	}
+	if !isNotFilled(&APIError{Reason: "Immediate or cancel order could not be filled."}) {
+		t.Error("not filled error expected for an IOC order")
+	}
+	for _, reason := range []string{"Invalid nonce", "Order already filled.", "Trading is disabled by the kill switch."} {
+		if isNotFilled(&APIError{Reason: reason}) {
+			t.Errorf("%q: unexpected not filled error", reason)
+		}
+	}
+}
+
+func TestOrderResultUnmarshal(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected OrderResult
+	}{
+		{
+			data: `{"id": "1193624515", "datetime": "2020-03-02 10:15:07.123456", "type": "1", "price": "8500.00", "amount": "0.01000000", "client_order_id": "abc"}`,
+			expected: OrderResult{
+				ID:            1193624515,
+				Time:          time.Date(2020, 3, 2, 10, 15, 7, 123456000, time.UTC),
+				Type:          OrderSell,
+				Price:         8500,
+				Amount:        0.01,
+				ClientOrderID: "abc",
+			},
+		},
+		{
+			data: `{"id": 1193624516, "datetime": "2020-03-02 10:15:07", "type": 0, "price": 8500.5, "amount": 1}`,
+			expected: OrderResult{
+				ID:     1193624516,
+				Time:   time.Date(2020, 3, 2, 10, 15, 7, 0, time.UTC),
+				Type:   OrderBuy,
+				Price:  8500.5,
+				Amount: 1,
+			},
+		},
+		{
+			data:     `{"id": "1", "datetime": "1583144107", "type": "0", "price": "1", "amount": "1"}`,
+			expected: OrderResult{ID: 1, Time: time.Unix(1583144107, 0), Type: OrderBuy, Price: 1, Amount: 1},
+		},
+		{
+			data:     `{"id": "1", "datetime": 1583144107.25, "type": "0", "price": "1", "amount": "1"}`,
+			expected: OrderResult{ID: 1, Time: time.Unix(1583144107, 250000000), Type: OrderBuy, Price: 1, Amount: 1},
+		},
+		{
+			data:     `{"id": "1", "datetime": "1583144107", "type": "0", "price": "", "amount": "1e-8"}`,
+			expected: OrderResult{ID: 1, Time: time.Unix(1583144107, 0), Type: OrderBuy, Amount: 1e-8},
+		},
+	}
+	for _, test := range tests {
+		var result OrderResult
+		if err := json.Unmarshal([]byte(test.data), &result); err != nil {
+			t.Errorf("%s: unexpected error %v", test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expected, result) {
+			t.Errorf("expected %+v, got %+v", test.expected, result)
+		}
+	}
+	invalid := []string{
+		`{"id": "x", "datetime": "2020-03-02 10:15:07", "type": "0", "price": "1", "amount": "1"}`,
+		`{"id": "1", "datetime": "yesterday", "type": "0", "price": "1", "amount": "1"}`,
+		`{"id": "1", "datetime": "2020-03-02 10:15:07", "type": "5", "price": "1", "amount": "1"}`,
+		`{"id": "1", "datetime": "2020-03-02 10:15:07", "type": "0", "price": "1.2.3", "amount": "1"}`,
+	}
+	for _, data := range invalid {
+		var result OrderResult
+		if err := json.Unmarshal([]byte(data), &result); err == nil {
+			t.Errorf("%s: error expected", data)
+		}
+	}
+}
+
+func TestOrderFieldError(t *testing.T) {
+	err := parseAPIError([]byte(`{"status": "error", "reason": {"price": ["Price is more than 20% above market price."]}}`))
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if msgs := apiErr.Fields["price"]; len(msgs) != 1 || msgs[0] != "Price is more than 20% above market price." {
+		t.Errorf("unexpected field errors %v", apiErr.Fields)
+	}
+	if expected := "api error: price: Price is more than 20% above market price."; apiErr.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, apiErr.Error())
+	}
+}
+
+func TestMarketOrderValues(t *testing.T) {
+	values, err := marketOrderValues(0.00005, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "amount=0.00005"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	for _, amount := range []float64{0, -1} {
+		if _, err := marketOrderValues(amount, ""); err == nil {
+			t.Errorf("%v: error expected", amount)
+		}
+	}
+}
+
+func TestMarketOrderFixtures(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/market_order.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parseAPIError(data); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var result OrderResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	expected := OrderResult{
+		ID:     1193624520,
+		Time:   time.Date(2020, 3, 2, 10, 20, 11, 347312000, time.UTC),
+		Type:   OrderBuy,
+		Price:  8651.37,
+		Amount: 0.05,
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("expected %+v, got %+v", expected, result)
+	}
+
+	data, err = ioutil.ReadFile("testdata/market_order_min_size.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiErr, ok := parseAPIError(data).(*APIError)
+	if !ok {
+		t.Fatal("*APIError expected")
+	}
+	if apiErr.Reason != "Minimum order size is 25.0 USD." {
+		t.Errorf("unexpected reason %q", apiErr.Reason)
+	}
+}
+
+func TestInstantOrderValues(t *testing.T) {
+	values, err := instantOrderValues(100, InstantOrderOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "amount=100"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	values, err = instantOrderValues(100, InstantOrderOpts{LimitPrice: 8700.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "amount=100&limit_price=8700.25"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	if _, err := instantOrderValues(0, InstantOrderOpts{}); err == nil {
+		t.Error("error expected for zero amount")
+	}
+	if _, err := instantOrderValues(1, InstantOrderOpts{LimitPrice: -1}); err == nil {
+		t.Error("error expected for negative limit price")
+	}
+}
+
+func TestClientOrderID(t *testing.T) {
+	values, err := marketOrderValues(1, "abc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "amount=1&client_order_id=abc-1"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	values, err = instantOrderValues(1, InstantOrderOpts{ClientOrderID: "abc-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Get("client_order_id") != "abc-2" {
+		t.Errorf("unexpected values %q", values.Encode())
+	}
+	for _, invalid := range []string{"with space", "ünicode", "a/b", strings.Repeat("a", 181)} {
+		if _, err := marketOrderValues(1, invalid); err == nil {
+			t.Errorf("%q: error expected", invalid)
+		}
+		if _, err := limitOrderValues(1, 1, LimitOrderOpts{ClientOrderID: invalid}); err == nil {
+			t.Errorf("%q: error expected", invalid)
+		}
+	}
+}
+
+func TestMinimumOrderCheck(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_pairs_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/trading-pairs-info/": func(url.Values) (int, string) {
+			return 200, string(data)
+		},
+		"/buy/btcusd/": func(url.Values) (int, string) {
+			return 200, `{"id": "1", "datetime": "2019-09-06 07:35:04", "type": "0", "price": "1", "amount": "1"}`
+		},
+		"/buy/instant/btcusd/": func(url.Values) (int, string) {
+			return 200, `{"id": "2", "datetime": "2019-09-06 07:35:04", "type": "0", "price": "1", "amount": "1"}`
+		},
+	})
+	defer restore()
+	api := NewWithKey("key", "secret")
+	api.EnableSymbolValidation(time.Hour)
+	_, err = api.BuyLimitOrder("btcusd", 10000, 0.0009, LimitOrderOpts{})
+	if !errors.Is(err, ErrBelowMinimumOrder) {
+		t.Fatalf("ErrBelowMinimumOrder expected, got %v", err)
+	}
+	var minErr *MinimumOrderError
+	if !errors.As(err, &minErr) {
+		t.Fatalf("*MinimumOrderError expected, got %T", err)
+	}
+	if minErr.Minimum != 10 || minErr.Currency != "usd" || math.Abs(minErr.Value-9) > 1e-9 {
+		t.Errorf("unexpected error %+v", *minErr)
+	}
+	if _, err := api.BuyInstantOrder("btcusd", 9.99, InstantOrderOpts{}); !errors.Is(err, ErrBelowMinimumOrder) {
+		t.Errorf("ErrBelowMinimumOrder expected, got %v", err)
+	}
+	if fake.called("/buy/btcusd/") || fake.called("/buy/instant/btcusd/") {
+		t.Error("orders below the minimum must not be sent")
+	}
+	if _, err := api.BuyLimitOrder("btcusd", 10000, 0.001, LimitOrderOpts{}); err != nil {
+		t.Errorf("an order at the minimum must pass, got %v", err)
+	}
+	api.SkipMinimumOrderCheck = true
+	if _, err := api.BuyInstantOrder("btcusd", 9.99, InstantOrderOpts{}); err != nil {
+		t.Errorf("the check must be disabled, got %v", err)
+	}
+}
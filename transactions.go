@@ -0,0 +1,323 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// UserTransactionType is a type of a user transaction.
+type UserTransactionType int
+
+const (
+	// UserTransactionDeposit is a deposit.
+	UserTransactionDeposit UserTransactionType = 0
+	// UserTransactionWithdrawal is a withdrawal.
+	UserTransactionWithdrawal UserTransactionType = 1
+	// UserTransactionTrade is a market trade.
+	UserTransactionTrade UserTransactionType = 2
+	// UserTransactionSubAccountTransfer is a sub account transfer.
+	UserTransactionSubAccountTransfer UserTransactionType = 14
+)
+
+const (
+	// SortAsc sorts results in ascending order.
+	SortAsc = "asc"
+	// SortDesc sorts results in descending order.
+	SortDesc = "desc"
+)
+
+const (
+	maxUserTransactionsLimit  = 1000
+	maxUserTransactionsOffset = 200000
+)
+
+// UserTransaction is a transaction of the account.
+type UserTransaction struct {
+	ID      int64
+	Time    time.Time
+	Type    UserTransactionType
+	OrderID int64
+	Fee     float64
+	// Amounts maps lowercase currency names to the amount change of the currency.
+	Amounts map[string]float64
+	// Rates maps exchange rate keys, like btc_usd, to the rate of a trade.
+	Rates map[string]float64
+}
+
+// UserTransactionsParams are parameters of a user transactions request.
+// Zero values are not sent.
+type UserTransactionsParams struct {
+	// Symbol limits transactions to the given pair, if set.
+	Symbol string
+	// Offset skips the given number of transactions, max 200000.
+	Offset int
+	// Limit limits the number of results, max 1000.
+	Limit int
+	// Sort is either SortAsc or SortDesc.
+	Sort string
+	// SinceTimestamp returns only transactions since the given time.
+	SinceTimestamp time.Time
+	// SinceID returns only transactions since the given transaction id.
+	// The limit is always 1000 in this case.
+	SinceID int64
+}
+
+func (p UserTransactionsParams) values() (url.Values, error) {
+	values := url.Values{}
+	if p.Offset < 0 || p.Offset > maxUserTransactionsOffset {
+		return nil, errors.Errorf("offset must be in [0, %d]", maxUserTransactionsOffset)
+	}
+	if p.Limit < 0 || p.Limit > maxUserTransactionsLimit {
+		return nil, errors.Errorf("limit must be in [0, %d]", maxUserTransactionsLimit)
+	}
+	if p.SinceID < 0 {
+		return nil, errors.New("since id must not be negative")
+	}
+	if p.SinceID > 0 && p.Limit > 0 {
+		return nil, errors.New("limit can't be used with since id")
+	}
+	if p.SinceID > 0 && !p.SinceTimestamp.IsZero() {
+		return nil, errors.New("since id and since timestamp are mutually exclusive")
+	}
+	switch p.Sort {
+	case "", SortAsc, SortDesc:
+	default:
+		return nil, errors.Errorf("invalid sort %q", p.Sort)
+	}
+	if p.Offset > 0 {
+		values.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Limit > 0 {
+		values.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Sort != "" {
+		values.Set("sort", p.Sort)
+	}
+	if !p.SinceTimestamp.IsZero() {
+		values.Set("since_timestamp", strconv.FormatInt(p.SinceTimestamp.Unix(), 10))
+	}
+	if p.SinceID > 0 {
+		values.Set("since_id", strconv.FormatInt(p.SinceID, 10))
+	}
+	return values, nil
+}
+
+// GetUserTransactions returns the transactions of the account.
+func (api *Api) GetUserTransactions(params UserTransactionsParams) ([]UserTransaction, error) {
+	return api.GetUserTransactionsCtx(context.Background(), params)
+}
+
+// GetUserTransactionsCtx is like GetUserTransactions, but uses the given context.
+func (api *Api) GetUserTransactionsCtx(ctx context.Context, params UserTransactionsParams) ([]UserTransaction, error) {
+	values, err := params.values()
+	if err != nil {
+		return nil, err
+	}
+	path := "/user_transactions/"
+	if params.Symbol != "" {
+		path += strings.ToLower(params.Symbol) + "/"
+	}
+	body, err := api.read(ctx, path, values)
+	if err != nil {
+		return nil, err
+	}
+	return parseUserTransactions(body)
+}
+
+func parseUserTransactions(data []byte) ([]UserTransaction, error) {
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]UserTransaction, len(raw))
+	for i, fields := range raw {
+		tr := UserTransaction{
+			Amounts: make(map[string]float64),
+			Rates:   make(map[string]float64),
+		}
+		for key, value := range fields {
+			if value == nil {
+				continue
+			}
+			var err error
+			switch key {
+			case "id":
+				tr.ID, err = parseIntValue(value)
+			case "order_id":
+				tr.OrderID, err = parseIntValue(value)
+			case "type":
+				var typ int64
+				typ, err = parseIntValue(value)
+				tr.Type = UserTransactionType(typ)
+			case "fee":
+				if tr.Fee, err = parseNumber(key, value); err != nil {
+					return nil, errors.Wrapf(err, "transaction %d", i)
+				}
+			case "datetime":
+				str, _ := value.(string)
+				tr.Time, err = parseDatetime(str)
+			default:
+				var v float64
+				if v, err = parseNumber(key, value); err != nil {
+					return nil, errors.Wrapf(err, "transaction %d", i)
+				}
+				if strings.Contains(key, "_") {
+					tr.Rates[key] = v
+				} else {
+					tr.Amounts[key] = v
+				}
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "transaction %d: %s parsing error", i, key)
+			}
+		}
+		result[i] = tr
+	}
+	return result, nil
+}
+
+// CryptoTransaction is a crypto deposit or withdrawal.
+type CryptoTransaction struct {
+	Currency string
+	Address  string
+	TxID     string
+	Amount   float64
+	Time     time.Time
+	// DestinationTag is a destination tag of XRP transactions, if any.
+	DestinationTag string
+	// Memo is a memo id of XLM and similar transactions, if any.
+	Memo string
+}
+
+// CryptoTransactions are crypto deposits and withdrawals of the account.
+type CryptoTransactions struct {
+	Deposits    []CryptoTransaction
+	Withdrawals []CryptoTransaction
+	// RippleIOUs are ripple IOU transactions, if requested.
+	RippleIOUs []CryptoTransaction
+}
+
+// CryptoTransactionsParams are parameters of a crypto transactions request.
+// Zero values are not sent.
+type CryptoTransactionsParams struct {
+	// Offset skips the given number of transactions, max 200000.
+	Offset int
+	// Limit limits the number of results, max 1000.
+	Limit int
+	// IncludeIOUs includes ripple IOU transactions into the result.
+	IncludeIOUs bool
+}
+
+func (p CryptoTransactionsParams) values() (url.Values, error) {
+	values := url.Values{}
+	if p.Offset < 0 || p.Offset > maxUserTransactionsOffset {
+		return nil, errors.Errorf("offset must be in [0, %d]", maxUserTransactionsOffset)
+	}
+	if p.Limit < 0 || p.Limit > maxUserTransactionsLimit {
+		return nil, errors.Errorf("limit must be in [0, %d]", maxUserTransactionsLimit)
+	}
+	if p.Offset > 0 {
+		values.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Limit > 0 {
+		values.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.IncludeIOUs {
+		values.Set("include_ious", "true")
+	}
+	return values, nil
+}
+
+// GetCryptoTransactions returns crypto deposits and withdrawals of the account.
+func (api *Api) GetCryptoTransactions(params CryptoTransactionsParams) (*CryptoTransactions, error) {
+	return api.GetCryptoTransactionsCtx(context.Background(), params)
+}
+
+// GetCryptoTransactionsCtx is like GetCryptoTransactions, but uses the given context.
+func (api *Api) GetCryptoTransactionsCtx(ctx context.Context, params CryptoTransactionsParams) (*CryptoTransactions, error) {
+	values, err := params.values()
+	if err != nil {
+		return nil, err
+	}
+	body, err := api.read(ctx, "/crypto-transactions/", values)
+	if err != nil {
+		return nil, err
+	}
+	return parseCryptoTransactions(body)
+}
+
+type rawCryptoTransaction struct {
+	Currency           string      `json:"currency"`
+	DestinationAddress string      `json:"destinationAddress"`
+	TxID               string      `json:"txid"`
+	Amount             interface{} `json:"amount"`
+	Datetime           interface{} `json:"datetime"`
+	DestinationTag     interface{} `json:"destination_tag"`
+	MemoID             interface{} `json:"memo_id"`
+}
+
+func (r rawCryptoTransaction) convert() (tr CryptoTransaction, err error) {
+	tr = CryptoTransaction{
+		Currency:       r.Currency,
+		Address:        r.DestinationAddress,
+		TxID:           r.TxID,
+		DestinationTag: stringValue(r.DestinationTag),
+		Memo:           stringValue(r.MemoID),
+	}
+	if tr.Amount, err = parseNumber("amount", r.Amount); err != nil {
+		return tr, err
+	}
+	// XRP addresses may carry the tag in the form of address?dt=tag.
+	if idx := strings.Index(tr.Address, "?dt="); idx >= 0 {
+		if tr.DestinationTag == "" {
+			tr.DestinationTag = tr.Address[idx+len("?dt="):]
+		}
+		tr.Address = tr.Address[:idx]
+	}
+	if tr.Time, err = parseTimeValue(r.Datetime); err != nil {
+		return tr, errors.Wrap(err, "datetime parsing error")
+	}
+	return tr, nil
+}
+
+func parseCryptoTransactions(data []byte) (*CryptoTransactions, error) {
+	var raw struct {
+		Deposits    []rawCryptoTransaction `json:"deposits"`
+		Withdrawals []rawCryptoTransaction `json:"withdrawals"`
+		RippleIOUs  []rawCryptoTransaction `json:"ripple_iou_transactions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	convert := func(arr []rawCryptoTransaction) ([]CryptoTransaction, error) {
+		result := make([]CryptoTransaction, len(arr))
+		for i, r := range arr {
+			tr, err := r.convert()
+			if err != nil {
+				return nil, errors.Wrapf(err, "transaction %d", i)
+			}
+			result[i] = tr
+		}
+		return result, nil
+	}
+	var (
+		result CryptoTransactions
+		err    error
+	)
+	if result.Deposits, err = convert(raw.Deposits); err != nil {
+		return nil, errors.Wrap(err, "deposits parsing error")
+	}
+	if result.Withdrawals, err = convert(raw.Withdrawals); err != nil {
+		return nil, errors.Wrap(err, "withdrawals parsing error")
+	}
+	if result.RippleIOUs, err = convert(raw.RippleIOUs); err != nil {
+		return nil, errors.Wrap(err, "ripple iou transactions parsing error")
+	}
+	return &result, nil
+}
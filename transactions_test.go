@@ -0,0 +1,136 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseUserTransactions(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/user_transactions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := parseUserTransactions(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UserTransaction{
+		{
+			ID:      133579151,
+			Time:    time.Date(2020, 3, 2, 10, 15, 7, 123456000, time.UTC),
+			Type:    UserTransactionTrade,
+			OrderID: 1193624515,
+			Fee:     0.41,
+			Amounts: map[string]float64{"usd": -82.18, "btc": 0.0095, "eur": 0},
+			Rates:   map[string]float64{"btc_usd": 8650.64},
+		},
+		{
+			ID:      133579100,
+			Time:    time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC),
+			Type:    UserTransactionDeposit,
+			Amounts: map[string]float64{"usd": 0, "btc": 1.5},
+			Rates:   map[string]float64{},
+		},
+	}
+	if !reflect.DeepEqual(expected, transactions) {
+		t.Errorf("expected %+v, got %+v", expected, transactions)
+	}
+}
+
+func TestUserTransactionsParams(t *testing.T) {
+	since := time.Unix(1583000000, 0)
+	tests := []struct {
+		params   UserTransactionsParams
+		expected string
+		valid    bool
+	}{
+		{params: UserTransactionsParams{}, expected: "", valid: true},
+		{
+			params:   UserTransactionsParams{Offset: 10, Limit: 1000, Sort: SortAsc, SinceTimestamp: since},
+			expected: "limit=1000&offset=10&since_timestamp=1583000000&sort=asc",
+			valid:    true,
+		},
+		{params: UserTransactionsParams{SinceID: 5}, expected: "since_id=5", valid: true},
+		{params: UserTransactionsParams{Limit: 1001}},
+		{params: UserTransactionsParams{Limit: -1}},
+		{params: UserTransactionsParams{Offset: 200001}},
+		{params: UserTransactionsParams{Sort: "up"}},
+		{params: UserTransactionsParams{SinceID: 5, Limit: 10}},
+		{params: UserTransactionsParams{SinceID: 5, SinceTimestamp: since}},
+	}
+	for _, test := range tests {
+		values, err := test.params.values()
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%+v: error expected", test.params)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: unexpected error %v", test.params, err)
+			continue
+		}
+		if got := values.Encode(); got != test.expected {
+			t.Errorf("%+v: expected %q, got %q", test.params, test.expected, got)
+		}
+	}
+}
+
+func TestParseCryptoTransactions(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/crypto_transactions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := parseCryptoTransactions(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &CryptoTransactions{
+		Deposits: []CryptoTransaction{
+			{
+				Currency: "BTC",
+				Address:  "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa",
+				TxID:     "4aaa02d3cd0a9342a1f98ac4ca89e7ed2c6ae9bf8c90a1ee1ccc5b69c6a7a2b9",
+				Amount:   0.5,
+				Time:     time.Unix(1583000000, 0),
+			},
+			{
+				Currency:       "XRP",
+				Address:        "rDsbeomae4FXwgQTJp9Rs64Qg9vDiTCdBv",
+				TxID:           "C2B6ED6B6CD1F5A1BAD0C7B7F4D6D2F6E1B44B9D0B1D7F6A43B0B7E0C6A0D9E1",
+				Amount:         100,
+				Time:           time.Unix(1583000100, 0),
+				DestinationTag: "123456",
+			},
+		},
+		Withdrawals: []CryptoTransaction{
+			{
+				Currency: "XLM",
+				Address:  "GAHK7EEG2WWHVKDNT4CEQFZGKF2LGDSW2IVM4S5DP42RBW3K6BTODB4A",
+				TxID:     "7d8e2b2bb4c5e8f7ab0e1ff4b12f5e0f34e5b0c4ad5b3f8e6e3f2c1b0a9d8c7b",
+				Amount:   25.5,
+				Time:     time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC),
+				Memo:     "1072345",
+			},
+		},
+		RippleIOUs: []CryptoTransaction{},
+	}
+	if !reflect.DeepEqual(expected, transactions) {
+		t.Errorf("expected %+v, got %+v", expected, transactions)
+	}
+}
+
+func TestCryptoTransactionsParams(t *testing.T) {
+	values, err := CryptoTransactionsParams{Offset: 5, Limit: 100, IncludeIOUs: true}.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "include_ious=true&limit=100&offset=5"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	if _, err := (CryptoTransactionsParams{Limit: 1001}).values(); err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,79 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TransferResult is a result of a transfer between the main account and a sub account.
+type TransferResult struct {
+	Status string
+}
+
+// TransferSubToMain transfers funds from a sub account to the main account.
+// subAccount may be empty, if the request is signed with the sub account key.
+func (api *Api) TransferSubToMain(amount float64, currency, subAccount string) (*TransferResult, error) {
+	return api.TransferSubToMainCtx(context.Background(), amount, currency, subAccount)
+}
+
+// TransferSubToMainCtx is like TransferSubToMain, but uses the given context.
+func (api *Api) TransferSubToMainCtx(ctx context.Context, amount float64, currency, subAccount string) (*TransferResult, error) {
+	values, err := transferValues(amount, currency, subAccount)
+	if err != nil {
+		return nil, err
+	}
+	return api.transfer(ctx, "/transfer-to-main/", values)
+}
+
+// TransferMainToSub transfers funds from the main account to a sub account.
+func (api *Api) TransferMainToSub(amount float64, currency, subAccount string) (*TransferResult, error) {
+	return api.TransferMainToSubCtx(context.Background(), amount, currency, subAccount)
+}
+
+// TransferMainToSubCtx is like TransferMainToSub, but uses the given context.
+func (api *Api) TransferMainToSubCtx(ctx context.Context, amount float64, currency, subAccount string) (*TransferResult, error) {
+	if subAccount == "" {
+		return nil, errors.New("empty sub account")
+	}
+	values, err := transferValues(amount, currency, subAccount)
+	if err != nil {
+		return nil, err
+	}
+	return api.transfer(ctx, "/transfer-from-main/", values)
+}
+
+func transferValues(amount float64, currency, subAccount string) (url.Values, error) {
+	currency = strings.ToLower(currency)
+	if err := validateCurrency(currency); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	values := url.Values{}
+	values.Set("amount", formatDecimals(amount, withdrawalAmountDecimals(currency)))
+	values.Set("currency", currency)
+	if subAccount != "" {
+		values.Set("subAccount", subAccount)
+	}
+	return values, nil
+}
+
+func (api *Api) transfer(ctx context.Context, path string, values url.Values) (*TransferResult, error) {
+	body, err := api.post(ctx, path, values)
+	if err != nil {
+		if apiReasonContains(err, "does not exist") {
+			return nil, withSentinel(ErrSubAccountNotFound, err)
+		}
+		return nil, err
+	}
+	var result TransferResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
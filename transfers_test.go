@@ -0,0 +1,62 @@
+package bitstamp
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestTransferValues(t *testing.T) {
+	values, err := transferValues(1.5, "BTC", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "amount=1.5&currency=btc"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	values, err = transferValues(100.123, "usdc", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "amount=100.123&currency=usdc&subAccount=123456"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	if _, err := transferValues(0, "btc", ""); err == nil {
+		t.Error("error expected for zero amount")
+	}
+	if _, err := transferValues(1, "", ""); err == nil {
+		t.Error("error expected for empty currency")
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	api := NewWithKey("key", "secret")
+	var sent url.Values
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/transfer-to-main/": func(values url.Values) (int, string) {
+			sent = values
+			return 200, `{"status": "ok"}`
+		},
+		"/transfer-from-main/": func(url.Values) (int, string) {
+			return 200, `{"status": "error", "reason": "Sub account with identifier \"42\" does not exist."}`
+		},
+	})
+	defer restore()
+	result, err := api.TransferSubToMain(1, "btc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "ok" {
+		t.Errorf("unexpected status %s", result.Status)
+	}
+	if _, found := sent["subAccount"]; found {
+		t.Error("subAccount must be omitted")
+	}
+	if _, err := api.TransferMainToSub(1, "btc", "42"); !errors.Is(err, ErrSubAccountNotFound) {
+		t.Errorf("expected ErrSubAccountNotFound, got %v", err)
+	}
+	if _, err := api.TransferMainToSub(1, "btc", ""); err == nil {
+		t.Error("error expected for empty sub account")
+	}
+}
@@ -0,0 +1,125 @@
+package bitstamp
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// connOptions are the connection settings collected by the options of NewClient.
+// They are applied to both the rest and the websocket transports, when the client is built.
+type connOptions struct {
+	// proxy is the url of a proxy. If nil, the proxy is taken from the environment.
+	proxy     *url.URL
+	tlsConfig *tls.Config
+	netDialer *net.Dialer
+}
+
+func (api *Api) connOpts() *connOptions {
+	if api.conn == nil {
+		api.conn = new(connOptions)
+	}
+	return api.conn
+}
+
+// applyConnOptions builds the http client and the websocket dialer from the connection settings.
+func (api *Api) applyConnOptions() error {
+	opts := api.conn
+	if opts == nil {
+		return nil
+	}
+	api.conn = nil
+	if api.HTTPClient != nil {
+		return errors.New("connection options can't be used with a custom http client")
+	}
+	if api.WebsocketDialer != nil {
+		return errors.New("connection options can't be used with a custom websocket dialer")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := *websocket.DefaultDialer
+	if opts.proxy != nil {
+		transport.Proxy = http.ProxyURL(opts.proxy)
+		dialer.Proxy = http.ProxyURL(opts.proxy)
+	}
+	if opts.tlsConfig != nil {
+		transport.TLSClientConfig = opts.tlsConfig.Clone()
+		dialer.TLSClientConfig = opts.tlsConfig.Clone()
+	}
+	if opts.netDialer != nil {
+		transport.DialContext = opts.netDialer.DialContext
+		dialer.NetDialContext = opts.netDialer.DialContext
+	}
+	dialer.HandshakeTimeout = api.timeout()
+	api.HTTPClient = &http.Client{Transport: transport}
+	api.WebsocketDialer = &dialer
+	return nil
+}
+
+func (api *Api) websocketDialer() *websocket.Dialer {
+	if api.WebsocketDialer != nil {
+		return api.WebsocketDialer
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = api.timeout()
+	return &dialer
+}
+
+// reservedHeaders can't be set with WithHeader, in addition to the protected ones.
+var reservedHeaders = map[string]bool{
+	"Host":                     true,
+	"Upgrade":                  true,
+	"Connection":               true,
+	"Sec-Websocket-Key":        true,
+	"Sec-Websocket-Version":    true,
+	"Sec-Websocket-Extensions": true,
+	"Sec-Websocket-Protocol":   true,
+}
+
+// setHeaders adds the user agent and the extra headers to h.
+// Protected headers are skipped, so they can't break the signature.
+func (api *Api) setHeaders(h http.Header) {
+	for k, v := range api.Headers {
+		if k = http.CanonicalHeaderKey(k); !isProtectedHeader(k) && !reservedHeaders[k] {
+			h[k] = v
+		}
+	}
+	if api.UserAgent != "" {
+		h.Set("User-Agent", api.UserAgent)
+	}
+}
+
+// handshakeHeader returns the headers of websocket handshakes.
+func (api *Api) handshakeHeader() http.Header {
+	h := http.Header{}
+	api.setHeaders(h)
+	return h
+}
+
+// readBody reads and closes the response body. A gzip compressed body is decompressed,
+// unless the transport has already done it.
+func readBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return ioutil.ReadAll(resp.Body)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "gzip error")
+	}
+	body, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, errors.Wrap(err, "gzip error")
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return body, nil
+}
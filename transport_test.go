@@ -0,0 +1,451 @@
+package bitstamp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// fakeHandler returns a status code and a body for the request with the given form values.
+type fakeHandler func(values url.Values) (int, string)
+
+// fakeExchange is a RoundTripper, which serves api requests by their paths.
+type fakeExchange struct {
+	mu       sync.Mutex
+	handlers map[string]fakeHandler
+	calls    []string
+	// header, if set, returns additional headers of a response.
+	header func(req *http.Request, body string) http.Header
+}
+
+func (f *fakeExchange) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/api/v2")
+	values := req.URL.Query()
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if values, err = url.ParseQuery(string(body)); err != nil {
+			return nil, err
+		}
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, path)
+	handler, found := f.handlers[path]
+	header := f.header
+	f.mu.Unlock()
+	code, body := http.StatusNotFound, `{"status": "error", "reason": "Not found"}`
+	if found {
+		code, body = handler(values)
+	}
+	respHeader := http.Header{"Content-Type": {"application/json"}}
+	if header != nil {
+		for k, v := range header(req, body) {
+			respHeader[k] = v
+		}
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     respHeader,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func (f *fakeExchange) called(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, call := range f.calls {
+		if call == path {
+			return true
+		}
+	}
+	return false
+}
+
+// withFakeExchange routes requests of the default client to a fake exchange.
+// The returned function restores the client.
+func withFakeExchange(handlers map[string]fakeHandler) (*fakeExchange, func()) {
+	f := &fakeExchange{handlers: handlers}
+	prev := defaultClient.Transport
+	defaultClient.Transport = f
+	return f, func() { defaultClient.Transport = prev }
+}
+
+// roundTripFunc is a RoundTripper implemented by a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// withTransport routes requests of the default client to rt.
+// The returned function restores the client.
+func withTransport(rt http.RoundTripper) func() {
+	prev := defaultClient.Transport
+	defaultClient.Transport = rt
+	return func() { defaultClient.Transport = prev }
+}
+
+// hangingTransport blocks requests until their context is done.
+// started receives a value when a request is sent.
+func hangingTransport(started chan<- struct{}) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		started <- struct{}{}
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+}
+
+// connectRequest is a CONNECT request received by a test proxy.
+type connectRequest struct {
+	host string
+	auth string
+}
+
+// newTestProxy returns a proxy, which records CONNECT requests and rejects them.
+func newTestProxy() (*httptest.Server, func() []connectRequest) {
+	var mu sync.Mutex
+	var requests []connectRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			mu.Lock()
+			requests = append(requests, connectRequest{host: r.Host, auth: r.Header.Get("Proxy-Authorization")})
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	return srv, func() []connectRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]connectRequest(nil), requests...)
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	proxy, requests := newTestProxy()
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+	api, err := NewClient(WithProxy(proxyURL.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err == nil {
+		t.Error("error expected from the proxy")
+	}
+	if _, err := newWsClient("", api.wsConfig()); err == nil {
+		t.Error("error expected from the proxy")
+	}
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	expected := []connectRequest{{host: "www.bitstamp.net:443", auth: auth}, {host: "ws.bitstamp.net:443", auth: auth}}
+	got := requests()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], got[i])
+		}
+	}
+	for _, rawurl := range []string{"", "proxy:3128", "ftp://proxy"} {
+		if _, err := NewClient(WithProxy(rawurl)); err == nil {
+			t.Errorf("%q: error expected", rawurl)
+		}
+	}
+	if _, err := NewClient(WithProxy(proxy.URL), WithHTTPClient(http.DefaultClient)); err == nil {
+		t.Error("error expected for a proxy with a custom http client")
+	}
+	if _, err := NewClient(WithProxy(proxy.URL), WithWebsocketDialer(&websocket.Dialer{})); err == nil {
+		t.Error("error expected for a proxy with a custom websocket dialer")
+	}
+}
+
+func TestWithWebsocketDialer(t *testing.T) {
+	dialer := &websocket.Dialer{}
+	api, err := NewClient(WithWebsocketDialer(dialer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.websocketDialer() != dialer {
+		t.Error("the dialer is not set")
+	}
+	if api.HTTPClient != nil {
+		t.Error("the http client must not be changed")
+	}
+	if _, err := NewClient(WithWebsocketDialer(nil)); err == nil {
+		t.Error("error expected for a nil dialer")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"last": "10455.51"}`))
+	}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	var dials int32
+	dialer := &net.Dialer{Control: func(string, string, syscall.RawConn) error {
+		atomic.AddInt32(&dials, 1)
+		return nil
+	}}
+	api, err := NewClient(
+		WithBaseURL(srv.URL+"/api/v2"),
+		WithWebsocketURL("wss"+strings.TrimPrefix(srv.URL, "https")),
+		WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}),
+		WithNetDialer(dialer),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newWsClient(api.WebsocketURL, api.wsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if n := atomic.LoadInt32(&dials); n != 2 {
+		t.Errorf("expected 2 dials, got %d", n)
+	}
+	untrusted, err := NewClient(WithBaseURL(srv.URL + "/api/v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := untrusted.GetTicker("btcusd"); err == nil {
+		t.Error("error expected for an unknown certificate authority")
+	}
+	if _, err := NewClient(WithTLSConfig(&tls.Config{}), WithHTTPClient(http.DefaultClient)); err == nil {
+		t.Error("error expected for a tls config with a custom http client")
+	}
+	if _, err := NewClient(WithNetDialer(dialer), WithHTTPClient(http.DefaultClient)); err == nil {
+		t.Error("error expected for a net dialer with a custom http client")
+	}
+	if _, err := NewClient(WithTLSConfig(nil)); err == nil {
+		t.Error("error expected for a nil tls config")
+	}
+	if _, err := NewClient(WithNetDialer(nil)); err == nil {
+		t.Error("error expected for a nil net dialer")
+	}
+}
+
+func TestExtraHeaders(t *testing.T) {
+	fake, restore := withFakeExchange(map[string]fakeHandler{
+		"/ticker/btcusd": func(url.Values) (int, string) { return 200, `{"last": "1"}` },
+		"/balance/":      func(url.Values) (int, string) { return 200, `{}` },
+	})
+	defer restore()
+	var restHeaders []http.Header
+	fake.header = func(req *http.Request, _ string) http.Header {
+		restHeaders = append(restHeaders, req.Header.Clone())
+		return nil
+	}
+	wsHeaders := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wsHeaders <- r.Header.Clone()
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	defer srv.Close()
+	api, err := NewClient(
+		WithCredentials("key", "secret"),
+		WithUserAgent("collector/1.0"),
+		WithHeader("x-trace-id", "abc"),
+		WithWebsocketURL("ws"+strings.TrimPrefix(srv.URL, "http")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newWsClient(api.WebsocketURL, api.wsConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	for i, h := range append(restHeaders, <-wsHeaders) {
+		if h.Get("User-Agent") != "collector/1.0" || h.Get("X-Trace-Id") != "abc" {
+			t.Errorf("request %d: headers are not set: %v", i, h)
+		}
+	}
+	api.Headers.Set("X-Auth-Signature", "broken")
+	restHeaders = nil
+	if _, err := api.GetAccountBalance(); err != nil {
+		t.Fatal(err)
+	}
+	if restHeaders[0].Get("X-Auth-Signature") == "broken" {
+		t.Error("the signature must not be overwritten")
+	}
+	for _, key := range []string{"", "X-Auth", "x-auth-signature", "Content-Type", "Sec-WebSocket-Key", "Upgrade"} {
+		if _, err := NewClient(WithHeader(key, "v")); err == nil {
+			t.Errorf("%q: error expected", key)
+		}
+	}
+	if _, err := NewClient(WithUserAgent("")); err == nil {
+		t.Error("error expected for an empty user agent")
+	}
+}
+
+func TestGzipResponses(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/order_book_deep.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+	var acceptEncoding []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = append(acceptEncoding, r.Header.Get("Accept-Encoding"))
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(compressed.Bytes())
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+	clients := map[string]*http.Client{
+		"default":    nil,
+		"custom":     {},
+		"no gzip":    {Transport: &http.Transport{DisableCompression: true}},
+		"round trip": {Transport: roundTripFunc(http.DefaultTransport.RoundTrip)},
+	}
+	for name, client := range clients {
+		acceptEncoding = nil
+		api := NewWithKey("key", "secret")
+		api.BaseURL = srv.URL
+		api.HTTPClient = client
+		body, err := api.get(context.Background(), "/order_book/btcusd")
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(body, data) {
+			t.Errorf("%s: the body differs from the original", name)
+		}
+		if name == "no gzip" && acceptEncoding[0] != "" {
+			t.Errorf("%s: the client settings must be respected, got %q", name, acceptEncoding[0])
+		}
+		if name == "default" && acceptEncoding[0] != "gzip" {
+			t.Errorf("%s: gzip must be requested, got %q", name, acceptEncoding[0])
+		}
+	}
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Encoding": {"gzip"}},
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("not gzip"))),
+			Request:    req,
+		}, nil
+	}))()
+	if _, err := New("", "").GetOrderBook("btcusd"); err == nil {
+		t.Error("error expected for a malformed gzip body")
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	started := make(chan struct{}, 10)
+	restore := withTransport(hangingTransport(started))
+	api, err := NewClient(WithCredentials("key", "secret"), WithTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTicker("btcusd"); !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if _, err := api.GetAccountBalance(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	restore()
+	var deadlines []bool
+	defer withTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		_, ok := req.Context().Deadline()
+		deadlines = append(deadlines, ok)
+		time.Sleep(50 * time.Millisecond)
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"last": "1"}`)),
+			Request:    req,
+		}, nil
+	}))()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := api.GetTickerCtx(ctx, "btcusd"); err != nil {
+		t.Errorf("the context deadline must override the timeout, got %v", err)
+	}
+	api.Timeout = -1
+	if _, err := api.GetTicker("btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []bool{true, false}; len(deadlines) != 2 || deadlines[0] != expected[0] || deadlines[1] != expected[1] {
+		t.Errorf("expected deadlines %v, got %v", expected, deadlines)
+	}
+	if _, err := NewClient(WithTimeout(0)); err == nil {
+		t.Error("error expected for zero timeout")
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		// accept connections, but never answer.
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	api, err := NewClient(WithTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newWsClient("ws://"+l.Addr().String(), api.wsConfig())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
@@ -1,11 +1,17 @@
 package bitstamp
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 )
 
 const bitstampWsUrl = "wss://ws.bitstamp.net"
@@ -18,22 +24,63 @@ type WsEvent struct {
 
 type WsClient struct {
 	ws       *websocket.Conn
+	debug    *debugDumper
 	done     chan bool
 	sendLock sync.Mutex
 	Stream   chan *WsEvent
 	Errors   chan error
 }
 
+// NewWsClient connects to the Bitstamp websocket api.
 func NewWsClient() (*WsClient, error) {
+	return NewWsClientURL(bitstampWsUrl)
+}
+
+// NewWsClientURL connects to the websocket api at the given url.
+// If url is empty, the default Bitstamp url is used.
+func NewWsClientURL(url string) (*WsClient, error) {
+	return newWsClient(url, wsConfig{dialer: websocket.DefaultDialer, log: nopLogger{}, metrics: nopMetrics{}})
+}
+
+// wsConfig are the settings of a websocket client.
+type wsConfig struct {
+	dialer *websocket.Dialer
+	// header is sent with the handshake.
+	header  http.Header
+	log     Logger
+	metrics Metrics
+	// debug, if set, dumps the frames.
+	debug *debugDumper
+}
+
+func (api *Api) wsConfig() wsConfig {
+	return wsConfig{
+		dialer:  api.websocketDialer(),
+		header:  api.handshakeHeader(),
+		log:     api.logger(),
+		metrics: api.metrics(),
+		debug:   api.debug,
+	}
+}
+
+func newWsClient(url string, cfg wsConfig) (*WsClient, error) {
+	if url == "" {
+		url = bitstampWsUrl
+	}
+	log, metrics := cfg.log, cfg.metrics
 	c := WsClient{
+		debug:  cfg.debug,
 		done:   make(chan bool, 1),
 		Stream: make(chan *WsEvent),
 		Errors: make(chan error, 1),
 	}
 
 	// set up websocket
-	ws, _, err := websocket.DefaultDialer.Dial(bitstampWsUrl, nil)
+	ws, _, err := cfg.dialer.Dial(url, cfg.header)
 	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			err = withSentinel(context.DeadlineExceeded, err)
+		}
 		return nil, fmt.Errorf("error dialing websocket: %w", err)
 	}
 	c.ws = ws
@@ -45,24 +92,32 @@ func NewWsClient() (*WsClient, error) {
 			case <-c.done:
 				return
 			default:
-				var message []byte
-				var err error
-				_, message, err = c.ws.ReadMessage()
+				buf := messageBuffers.Get().(*bytes.Buffer)
+				message, err := readMessage(c.ws, buf)
+				if c.debug != nil && message != nil {
+					c.debug.dumpFrame("<<<", message)
+				}
 				if err != nil {
+					putMessageBuffer(buf)
+					metrics.ObserveError(ErrorKindWebsocket)
 					select {
 					case c.Errors <- err:
 					default:
-						fmt.Printf("can't write to Errors chan read message err: %s", err)
+						log.Errorf("can't write to Errors chan read message err: %s", err)
 					}
 					continue
 				}
+				// the event keeps a copy of the data, so the buffer may be reused.
 				e := &WsEvent{}
 				err = json.Unmarshal(message, e)
+				metrics.ObserveWSMessage(e.Channel, len(message))
+				putMessageBuffer(buf)
 				if err != nil {
+					metrics.ObserveError(ErrorKindDecode)
 					select {
 					case c.Errors <- err:
 					default:
-						fmt.Printf("can't write to Errors chan unmarshal err: %s", err)
+						log.Errorf("can't write to Errors chan unmarshal err: %s", err)
 					}
 					continue
 				}
@@ -106,9 +161,95 @@ func (c *WsClient) Unsubscribe(channels ...string) error {
 	return nil
 }
 
+// SubscribePrivate subscribes to private channels, like my_orders_btcusd.
+// Channel names are extended with the private- prefix and the user id suffix.
+func (c *WsClient) SubscribePrivate(token *WebsocketToken, channels ...string) error {
+	for _, channel := range channels {
+		sub, err := privateSubscribeEvent(token, channel)
+		if err != nil {
+			return err
+		}
+		if err := c.sendEvent(sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func privateSubscribeEvent(token *WebsocketToken, channel string) (WsEvent, error) {
+	data, err := json.Marshal(struct {
+		Channel string `json:"channel"`
+		Auth    string `json:"auth"`
+	}{
+		Channel: privateChannel(channel, token.UserID),
+		Auth:    token.Token,
+	})
+	if err != nil {
+		return WsEvent{}, err
+	}
+	return WsEvent{Event: "bts:subscribe", Data: data}, nil
+}
+
+// WebsocketToken is a token for private websocket channels.
+type WebsocketToken struct {
+	Token  string
+	UserID int64
+	// Expires is the time, after which the token can't be used for subscriptions.
+	Expires time.Time
+}
+
+// GetWebsocketsToken returns a token for private websocket channels.
+func (api *Api) GetWebsocketsToken() (*WebsocketToken, error) {
+	return api.GetWebsocketsTokenCtx(context.Background())
+}
+
+// GetWebsocketsTokenCtx is like GetWebsocketsToken, but uses the given context.
+func (api *Api) GetWebsocketsTokenCtx(ctx context.Context) (*WebsocketToken, error) {
+	body, err := api.post(ctx, "/websockets_token/", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseWebsocketToken(body, time.Now())
+}
+
+func parseWebsocketToken(data []byte, now time.Time) (*WebsocketToken, error) {
+	var raw struct {
+		Token    string      `json:"token"`
+		UserID   interface{} `json:"user_id"`
+		ValidSec interface{} `json:"valid_sec"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Token == "" {
+		return nil, errors.New("empty token")
+	}
+	userID, err := parseIntValue(raw.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "user id parsing error")
+	}
+	validSec, err := parseIntValue(raw.ValidSec)
+	if err != nil {
+		return nil, errors.Wrap(err, "valid sec parsing error")
+	}
+	return &WebsocketToken{
+		Token:   raw.Token,
+		UserID:  userID,
+		Expires: now.Add(time.Duration(validSec) * time.Second),
+	}, nil
+}
+
 func (c *WsClient) sendEvent(sub WsEvent) error {
 	c.sendLock.Lock()
 	defer c.sendLock.Unlock()
 
-	return c.ws.WriteJSON(&sub)
+	data, err := json.Marshal(&sub)
+	if err != nil {
+		return err
+	}
+	if c.debug != nil {
+		c.debug.dumpFrame(">>>", data)
+	}
+	return c.ws.WriteMessage(websocket.TextMessage, data)
 }
@@ -0,0 +1,380 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestGetWebsocketsToken(t *testing.T) {
+	f, restore := withFakeExchange(map[string]fakeHandler{
+		"/websockets_token/": func(url.Values) (int, string) {
+			return 200, `{"token": "5rbCMidhbg6cPoXQC3zM3HnRfOGQGBbW", "user_id": 123456, "valid_sec": 60}`
+		},
+	})
+	defer restore()
+	before := time.Now()
+	token, err := NewWithKey("key", "secret").GetWebsocketsToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.called("/websockets_token/") {
+		t.Error("the endpoint was not called")
+	}
+	if token.Token != "5rbCMidhbg6cPoXQC3zM3HnRfOGQGBbW" || token.UserID != 123456 {
+		t.Errorf("unexpected token %+v", *token)
+	}
+	if token.Expires.Before(before.Add(time.Minute)) || token.Expires.After(time.Now().Add(time.Minute)) {
+		t.Errorf("unexpected expiration time %v", token.Expires)
+	}
+	if _, err := parseWebsocketToken([]byte(`{"user_id": 1, "valid_sec": 60}`), before); err == nil {
+		t.Error("error expected for an empty token")
+	}
+}
+
+func TestPrivateSubscribeEvent(t *testing.T) {
+	token := &WebsocketToken{Token: "abc", UserID: 42}
+	ev, err := privateSubscribeEvent(token, "my_orders_btcusd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Event != "bts:subscribe" {
+		t.Errorf("unexpected event %s", ev.Event)
+	}
+	if expected := `{"channel":"private-my_orders_btcusd-42","auth":"abc"}`; string(ev.Data) != expected {
+		t.Errorf("expected %s, got %s", expected, ev.Data)
+	}
+}
+
+func TestWsClientURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		var sub WsEvent
+		if err := conn.ReadJSON(&sub); err != nil {
+			t.Error(err)
+			return
+		}
+		conn.WriteJSON(WsEvent{Event: "bts:subscription_succeeded", Data: sub.Data})
+		conn.ReadMessage()
+	}))
+	defer srv.Close()
+	c, err := NewWsClientURL("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Subscribe("order_book_btcusd"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-c.Stream:
+		var data struct{ Channel string }
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Event != "bts:subscription_succeeded" || data.Channel != "order_book_btcusd" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case err := <-c.Errors:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestParseWebsocketOrderBook(t *testing.T) {
+	message, err := ioutil.ReadFile("testdata/ws_order_book.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev WsEvent
+	if err := json.Unmarshal(message, &ev); err != nil {
+		t.Fatal(err)
+	}
+	book, err := New("", "").parseOrderBook(ev.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 100 || len(book.Asks) != 100 {
+		t.Errorf("expected 100 levels, got %d bids and %d asks", len(book.Bids), len(book.Asks))
+	}
+	if book.Bids[0] != (Order{Price: 10453, Amount: 0.1}) || book.Asks[0] != (Order{Price: 10455.5, Amount: 0.2}) {
+		t.Errorf("unexpected top of the book %+v %+v", book.Bids[0], book.Asks[0])
+	}
+	if !book.Time.Equal(time.Unix(1567755304, 123456000)) {
+		t.Errorf("unexpected time %v", book.Time)
+	}
+}
+
+// BenchmarkOrderBookUpdate measures the processing of a live order book message,
+// which is decoded once, and the data is parsed as is.
+func BenchmarkOrderBookUpdate(b *testing.B) {
+	message, err := ioutil.ReadFile("testdata/ws_order_book.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	api := New("", "")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var ev WsEvent
+		if err := json.Unmarshal(message, &ev); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := api.parseOrderBook(ev.Data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkOrderBookUpdateRemarshal is like BenchmarkOrderBookUpdate,
+// but the data is marshalled again before parsing, for comparison.
+func BenchmarkOrderBookUpdateRemarshal(b *testing.B) {
+	message, err := ioutil.ReadFile("testdata/ws_order_book.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	api := New("", "")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var ev WsEvent
+		if err := json.Unmarshal(message, &ev); err != nil {
+			b.Fatal(err)
+		}
+		data, err := json.Marshal(ev.Data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := api.parseOrderBook(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// orderBookStreamServer is a websocket server, which sends the messages after the subscription
+// to the channel, and then a malformed message to stop the subscription.
+func orderBookStreamServer(tb testing.TB, channel string, messages [][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			tb.Error(err)
+			return
+		}
+		defer conn.Close()
+		var sub WsEvent
+		if err := conn.ReadJSON(&sub); err != nil {
+			tb.Error(err)
+			return
+		}
+		if expected := `{"channel":"` + channel + `"}`; string(sub.Data) != expected {
+			tb.Errorf("expected a subscription to %s, got %s", expected, sub.Data)
+		}
+		for _, message := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				tb.Error(err)
+				return
+			}
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event":`))
+		// wait for the unsubscription.
+		conn.ReadMessage()
+	}))
+}
+
+// subscribeOrderBookStream subscribes to the messages and returns the received books.
+func subscribeOrderBookStream(tb testing.TB, messages [][]byte) []OrderBook {
+	srv := orderBookStreamServer(tb, "order_book_btcusd", messages)
+	defer srv.Close()
+	api := New("", "")
+	api.WebsocketURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	dataChan := make(chan OrderBook, 16)
+	var books []OrderBook
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for book := range dataChan {
+			books = append(books, book)
+		}
+	}()
+	if err := api.SubscribeOrderBook("btcusd", dataChan, nil); err != nil {
+		tb.Fatal(err)
+	}
+	close(dataChan)
+	<-done
+	return books
+}
+
+// TestSubscribeOrderBookPooledBuffers checks, that the received books do not share memory
+// with the buffers reused for the following messages. It is meant to be run with -race.
+func TestSubscribeOrderBookPooledBuffers(t *testing.T) {
+	const n = 200
+	messages := make([][]byte, n)
+	for i := range messages {
+		// the sides grow, so that the buffers are both reused and reallocated.
+		var bids, asks []string
+		for j := 0; j <= i%50; j++ {
+			bids = append(bids, fmt.Sprintf(`["%d", "%d"]`, i, j))
+			asks = append(asks, fmt.Sprintf(`["%d", "%d"]`, i+1, j))
+		}
+		messages[i] = []byte(fmt.Sprintf(`{"event": "data", "channel": "order_book_btcusd", "data": {"timestamp": "%d", "bids": [%s], "asks": [%s]}}`,
+			i, strings.Join(bids, ","), strings.Join(asks, ",")))
+	}
+	books := subscribeOrderBookStream(t, messages)
+	if len(books) != n {
+		t.Fatalf("expected %d books, got %d", n, len(books))
+	}
+	for i, book := range books {
+		if book.Time.Unix() != int64(i) || len(book.Bids) != i%50+1 || len(book.Asks) != i%50+1 {
+			t.Fatalf("book %d: unexpected book %+v", i, book)
+		}
+		for j := range book.Bids {
+			if book.Bids[j] != (Order{Price: float64(i), Amount: float64(j)}) || book.Asks[j] != (Order{Price: float64(i + 1), Amount: float64(j)}) {
+				t.Fatalf("book %d: unexpected level %d: %+v %+v", i, j, book.Bids[j], book.Asks[j])
+			}
+		}
+	}
+}
+
+func TestParseOrderBookConcurrent(t *testing.T) {
+	api := &Api{RawNumbers: true}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var books []*OrderBook
+			for j := 0; j < 100; j++ {
+				data := fmt.Sprintf(`{"bids": [["%d", "%d"]], "asks": []}`, i, j)
+				book, err := api.parseOrderBook([]byte(data))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				books = append(books, book)
+			}
+			for j, book := range books {
+				if book.Bids[0].PriceRaw != strconv.Itoa(i) || book.Bids[0].AmountRaw != strconv.Itoa(j) {
+					t.Errorf("%d: unexpected level %+v", j, book.Bids[0])
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// BenchmarkSubscribeOrderBook measures the whole subscription pipeline:
+// reading of the messages, decoding of the events and parsing of the books.
+func BenchmarkSubscribeOrderBook(b *testing.B) {
+	message, err := ioutil.ReadFile("testdata/ws_order_book.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	messages := make([][]byte, b.N)
+	for i := range messages {
+		messages[i] = message
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	if books := subscribeOrderBookStream(b, messages); len(books) != b.N {
+		b.Errorf("expected %d books, got %d", b.N, len(books))
+	}
+}
+
+func TestSubscribeDetailOrderBook(t *testing.T) {
+	message, err := ioutil.ReadFile("testdata/ws_detail_order_book.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := orderBookStreamServer(t, "detail_order_book_btcusd", [][]byte{message, []byte(`{"event": "data", "data": {"bids": [["1", "2"]], "asks": []}}`)})
+	defer srv.Close()
+	api := New("", "")
+	api.WebsocketURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	dataChan := make(chan GroupedOrderBook, 2)
+	if err := api.SubscribeDetailOrderBook("btcusd", dataChan, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(dataChan) != 1 {
+		t.Fatalf("expected 1 book, the one without order ids must be skipped, got %d", len(dataChan))
+	}
+	book := <-dataChan
+	expected := GroupedOrderBook{
+		Time:  time.Unix(1567755304, 968123000),
+		Group: OrderBookUngrouped,
+		Bids: []OrderBookLevel{
+			{Price: 10453, Amount: 0.5, OrderID: 1193624601},
+			{Price: 10452.5, Amount: 0.1, OrderID: 1193624588},
+			{Price: 10452.5, Amount: 1.2, OrderID: 1193624590},
+		},
+		Asks: []OrderBookLevel{
+			{Price: 10455.51, Amount: 0.02, OrderID: 1193624597},
+			{Price: 10456, Amount: 3, OrderID: 1193624412},
+		},
+	}
+	if !reflect.DeepEqual(expected, book) {
+		t.Errorf("expected %+v, got %+v", expected, book)
+	}
+}
+
+func TestSubscribeOrderBookStop(t *testing.T) {
+	subscribed := make(chan struct{})
+	unsubscribed := make(chan WsEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		var ev WsEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Error(err)
+			return
+		}
+		close(subscribed)
+		if err := conn.ReadJSON(&ev); err == nil {
+			unsubscribed <- ev
+		}
+	}))
+	defer srv.Close()
+	api := New("", "")
+	api.WebsocketURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	stopChan := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- api.SubscribeDetailOrderBook("btcusd", make(chan GroupedOrderBook), stopChan)
+	}()
+	<-subscribed
+	close(stopChan)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription must end when stopChan is closed")
+	}
+	select {
+	case ev := <-unsubscribed:
+		if ev.Event != "bts:unsubscribe" {
+			t.Errorf("expected an unsubscription, got %q", ev.Event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("no unsubscription received")
+	}
+}
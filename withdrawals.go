@@ -0,0 +1,437 @@
+package bitstamp
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// defaultWithdrawalDecimals is the number of decimals of withdrawal amounts
+// for currencies missing in withdrawalDecimals.
+const defaultWithdrawalDecimals = 8
+
+// withdrawalDecimals maps currencies to the number of decimals of withdrawal amounts.
+var withdrawalDecimals = map[string]int{
+	"btc":  8,
+	"eth":  8,
+	"usdc": 6,
+	"usdt": 6,
+	"link": 8,
+	"pax":  8,
+	"xrp":  6,
+	"xlm":  7,
+}
+
+// withdrawalAmountDecimals returns the number of decimals of withdrawal amounts for the currency.
+func withdrawalAmountDecimals(currency string) int {
+	if decimals, found := withdrawalDecimals[currency]; found {
+		return decimals
+	}
+	return defaultWithdrawalDecimals
+}
+
+// WithdrawOpts are optional parameters of a crypto withdrawal.
+type WithdrawOpts struct {
+	// DestinationTag is a destination tag of XRP withdrawals. It is sent only if not nil.
+	DestinationTag *int64
+	// MemoID is a memo of XLM and similar withdrawals. It is sent only if not nil,
+	// so an empty memo can be sent explicitly.
+	MemoID *string
+	// Network selects the network for currencies available on several networks.
+	Network string
+	// Decimals, if positive, is the number of decimals of the amount, like Currency.Decimals.
+	// Otherwise a built-in value for the currency is used.
+	// The extra decimals are truncated, so no more than the amount is withdrawn.
+	Decimals int
+	// TravelRule is the beneficiary information required for withdrawals to other VASPs.
+	// Only the set fields are sent.
+	TravelRule TravelRuleInfo
+}
+
+// TravelRuleInfo is the beneficiary information of a withdrawal.
+type TravelRuleInfo struct {
+	// VASPName is a name of the beneficiary virtual asset service provider, like an exchange.
+	VASPName string
+	// VASPAddress is a postal address of the beneficiary VASP.
+	VASPAddress string
+	// BeneficiaryName is a full name of the address owner.
+	BeneficiaryName string
+	// BeneficiaryAddress is a postal address of the address owner.
+	BeneficiaryAddress string
+}
+
+func (i TravelRuleInfo) apply(values url.Values) {
+	fields := []struct {
+		name, value string
+	}{
+		{"vasp_name", i.VASPName},
+		{"vasp_address", i.VASPAddress},
+		{"beneficiary_name", i.BeneficiaryName},
+		{"beneficiary_address", i.BeneficiaryAddress},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			values.Set(f.name, f.value)
+		}
+	}
+}
+
+// CryptoWithdraw withdraws the given currency to the address and returns the withdrawal id.
+// If the trading pairs info was cached, a currency of none of the pairs is rejected with ErrUnknownCurrency.
+func (api *Api) CryptoWithdraw(currency, address string, amount float64, opts WithdrawOpts) (int64, error) {
+	return api.CryptoWithdrawCtx(context.Background(), currency, address, amount, opts)
+}
+
+// CryptoWithdrawCtx is like CryptoWithdraw, but uses the given context.
+func (api *Api) CryptoWithdrawCtx(ctx context.Context, currency, address string, amount float64, opts WithdrawOpts) (int64, error) {
+	currency = strings.ToLower(currency)
+	values, err := cryptoWithdrawalValues(currency, address, amount, opts)
+	if err != nil {
+		return 0, err
+	}
+	if err := api.checkCurrency(currency); err != nil {
+		return 0, err
+	}
+	return api.withdraw(ctx, "/"+currency+"_withdrawal/", values)
+}
+
+// checkCurrency checks the lowercase currency against the cached trading pairs, if they were fetched.
+// See EnableSymbolValidation and EnableAutoRounding.
+func (api *Api) checkCurrency(currency string) error {
+	if api.pairs == nil {
+		return nil
+	}
+	if found, cached := api.pairs.hasCurrency(currency); cached && !found {
+		return withSentinel(ErrUnknownCurrency, errors.Errorf("currency %q", currency))
+	}
+	return nil
+}
+
+// WithdrawBTC withdraws bitcoins to the given address and returns the withdrawal id.
+func (api *Api) WithdrawBTC(address string, amount float64) (int64, error) {
+	return api.WithdrawBTCCtx(context.Background(), address, amount)
+}
+
+// WithdrawBTCCtx is like WithdrawBTC, but uses the given context.
+func (api *Api) WithdrawBTCCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "btc", address, amount, WithdrawOpts{})
+}
+
+// WithdrawETH withdraws ether to the given address and returns the withdrawal id.
+func (api *Api) WithdrawETH(address string, amount float64) (int64, error) {
+	return api.WithdrawETHCtx(context.Background(), address, amount)
+}
+
+// WithdrawETHCtx is like WithdrawETH, but uses the given context.
+func (api *Api) WithdrawETHCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "eth", address, amount, WithdrawOpts{})
+}
+
+// WithdrawUSDC withdraws USD Coin to the given address and returns the withdrawal id.
+func (api *Api) WithdrawUSDC(address string, amount float64) (int64, error) {
+	return api.WithdrawUSDCCtx(context.Background(), address, amount)
+}
+
+// WithdrawUSDCCtx is like WithdrawUSDC, but uses the given context.
+func (api *Api) WithdrawUSDCCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "usdc", address, amount, WithdrawOpts{})
+}
+
+// WithdrawUSDT withdraws Tether to the given address and returns the withdrawal id.
+func (api *Api) WithdrawUSDT(address string, amount float64) (int64, error) {
+	return api.WithdrawUSDTCtx(context.Background(), address, amount)
+}
+
+// WithdrawUSDTCtx is like WithdrawUSDT, but uses the given context.
+func (api *Api) WithdrawUSDTCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "usdt", address, amount, WithdrawOpts{})
+}
+
+// WithdrawLINK withdraws Chainlink to the given address and returns the withdrawal id.
+func (api *Api) WithdrawLINK(address string, amount float64) (int64, error) {
+	return api.WithdrawLINKCtx(context.Background(), address, amount)
+}
+
+// WithdrawLINKCtx is like WithdrawLINK, but uses the given context.
+func (api *Api) WithdrawLINKCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "link", address, amount, WithdrawOpts{})
+}
+
+// WithdrawPAX withdraws Paxos Standard to the given address and returns the withdrawal id.
+func (api *Api) WithdrawPAX(address string, amount float64) (int64, error) {
+	return api.WithdrawPAXCtx(context.Background(), address, amount)
+}
+
+// WithdrawPAXCtx is like WithdrawPAX, but uses the given context.
+func (api *Api) WithdrawPAXCtx(ctx context.Context, address string, amount float64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "pax", address, amount, WithdrawOpts{})
+}
+
+// WithdrawXRP withdraws ripple to the given address and returns the withdrawal id.
+// destinationTag is sent only if it is not nil.
+func (api *Api) WithdrawXRP(address string, amount float64, destinationTag *int64) (int64, error) {
+	return api.WithdrawXRPCtx(context.Background(), address, amount, destinationTag)
+}
+
+// WithdrawXRPCtx is like WithdrawXRP, but uses the given context.
+func (api *Api) WithdrawXRPCtx(ctx context.Context, address string, amount float64, destinationTag *int64) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "xrp", address, amount, WithdrawOpts{DestinationTag: destinationTag})
+}
+
+// WithdrawXLM withdraws stellar lumens to the given address and returns the withdrawal id.
+// memoID is sent only if it is not nil, so an empty memo can be sent explicitly.
+func (api *Api) WithdrawXLM(address string, amount float64, memoID *string) (int64, error) {
+	return api.WithdrawXLMCtx(context.Background(), address, amount, memoID)
+}
+
+// WithdrawXLMCtx is like WithdrawXLM, but uses the given context.
+func (api *Api) WithdrawXLMCtx(ctx context.Context, address string, amount float64, memoID *string) (int64, error) {
+	return api.CryptoWithdrawCtx(ctx, "xlm", address, amount, WithdrawOpts{MemoID: memoID})
+}
+
+func cryptoWithdrawalValues(currency, address string, amount float64, opts WithdrawOpts) (url.Values, error) {
+	if err := validateCurrency(currency); err != nil {
+		return nil, err
+	}
+	decimals := opts.Decimals
+	if decimals <= 0 {
+		decimals = withdrawalAmountDecimals(currency)
+	}
+	values, err := withdrawalValues(address, amount, decimals)
+	if err != nil {
+		return nil, err
+	}
+	if tag := opts.DestinationTag; tag != nil {
+		if *tag < 0 || *tag > math.MaxUint32 {
+			return nil, errors.Errorf("destination tag %d is not a 32-bit unsigned integer", *tag)
+		}
+		values.Set("destination_tag", strconv.FormatInt(*tag, 10))
+	}
+	if opts.MemoID != nil {
+		values.Set("memo_id", *opts.MemoID)
+	}
+	if opts.Network != "" {
+		values.Set("network", opts.Network)
+	}
+	opts.TravelRule.apply(values)
+	return values, nil
+}
+
+// validateCurrency checks that currency is a non-empty lowercase alphanumeric string.
+func validateCurrency(currency string) error {
+	if currency == "" {
+		return errors.New("empty currency")
+	}
+	for _, r := range currency {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return errors.Errorf("invalid currency %q", currency)
+		}
+	}
+	return nil
+}
+
+func withdrawalValues(address string, amount float64, decimals int) (url.Values, error) {
+	if err := validateAddress(address); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	// the amount is truncated, as rounding it up would withdraw more than requested.
+	formatted := formatDecimalsMode(amount, decimals, RoundDown)
+	if formatted == "0" {
+		return nil, errors.Errorf("amount %v rounds to zero with %d decimals", amount, decimals)
+	}
+	values := url.Values{}
+	values.Set("address", address)
+	values.Set("amount", formatted)
+	return values, nil
+}
+
+// validateAddress rejects obviously invalid crypto addresses.
+func validateAddress(address string) error {
+	if address == "" {
+		return errors.New("empty address")
+	}
+	if strings.IndexFunc(address, unicode.IsSpace) >= 0 {
+		return errors.Errorf("address %q contains whitespace", address)
+	}
+	return nil
+}
+
+// withdraw sends a withdrawal request and returns the withdrawal id.
+func (api *Api) withdraw(ctx context.Context, path string, values url.Values) (int64, error) {
+	body, err := api.post(ctx, path, values)
+	if err != nil {
+		return 0, withdrawalError(err)
+	}
+	return parseWithdrawalID(body)
+}
+
+// withdrawalError maps well-known withdrawal api errors to sentinel errors.
+func withdrawalError(err error) error {
+	switch {
+	case apiReasonContains(err, "travel rule", "beneficiary", "vasp"):
+		return withSentinel(ErrTravelRuleInfoRequired, err)
+	case apiReasonContains(err, "not allowed"):
+		return withSentinel(ErrWithdrawalNotAllowed, err)
+	case apiReasonContains(err, "you have only", "insufficient", "not enough"):
+		return withSentinel(ErrInsufficientFunds, err)
+	default:
+		return err
+	}
+}
+
+func parseWithdrawalID(data []byte) (int64, error) {
+	var resp struct {
+		ID interface{} `json:"id"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return 0, err
+	}
+	id, err := parseIntValue(resp.ID)
+	if err != nil {
+		return 0, errors.Wrap(err, "id parsing error")
+	}
+	return id, nil
+}
+
+// WithdrawalStatus is a status of a withdrawal request.
+type WithdrawalStatus int
+
+const (
+	// WithdrawalOpen is a status of a new withdrawal request.
+	WithdrawalOpen WithdrawalStatus = 0
+	// WithdrawalInProcess is a status of a withdrawal being processed.
+	WithdrawalInProcess WithdrawalStatus = 1
+	// WithdrawalFinished is a status of a completed withdrawal.
+	WithdrawalFinished WithdrawalStatus = 2
+	// WithdrawalCanceled is a status of a canceled withdrawal.
+	WithdrawalCanceled WithdrawalStatus = 3
+	// WithdrawalFailed is a status of a failed withdrawal.
+	WithdrawalFailed WithdrawalStatus = 4
+)
+
+func (s WithdrawalStatus) String() string {
+	switch s {
+	case WithdrawalOpen:
+		return "open"
+	case WithdrawalInProcess:
+		return "in process"
+	case WithdrawalFinished:
+		return "finished"
+	case WithdrawalCanceled:
+		return "canceled"
+	case WithdrawalFailed:
+		return "failed"
+	default:
+		return "unknown(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// WithdrawalRequest is a withdrawal request of the account.
+type WithdrawalRequest struct {
+	ID   int64
+	Time time.Time
+	// Type is a withdrawal type as returned by the api, like 0 for SEPA or 1 for bitcoin.
+	Type     int
+	Currency string
+	Amount   float64
+	Status   WithdrawalStatus
+	// TransactionID is set for crypto withdrawals, if known.
+	TransactionID string
+	// Address is set for crypto withdrawals.
+	Address string
+}
+
+// maxWithdrawalRequestsLimit is the max number of withdrawal requests returned at once.
+const maxWithdrawalRequestsLimit = 1000
+
+// GetWithdrawalRequests returns withdrawal requests made since the given time.
+// If since is zero, the api default of one day is used. If limit is zero, it is not sent.
+func (api *Api) GetWithdrawalRequests(since time.Time, limit int) ([]WithdrawalRequest, error) {
+	return api.GetWithdrawalRequestsCtx(context.Background(), since, limit)
+}
+
+// GetWithdrawalRequestsCtx is like GetWithdrawalRequests, but uses the given context.
+func (api *Api) GetWithdrawalRequestsCtx(ctx context.Context, since time.Time, limit int) ([]WithdrawalRequest, error) {
+	values, err := withdrawalRequestsValues(since, limit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	body, err := api.read(ctx, "/withdrawal-requests/", values)
+	if err != nil {
+		return nil, err
+	}
+	return parseWithdrawalRequests(body)
+}
+
+func withdrawalRequestsValues(since time.Time, limit int, now time.Time) (url.Values, error) {
+	values := url.Values{}
+	if !since.IsZero() {
+		if since.After(now) {
+			return nil, errors.New("since must not be in the future")
+		}
+		values.Set("timedelta", strconv.FormatInt(int64(now.Sub(since)/time.Second), 10))
+	}
+	if limit < 0 || limit > maxWithdrawalRequestsLimit {
+		return nil, errors.Errorf("limit must be in [0, %d]", maxWithdrawalRequestsLimit)
+	}
+	if limit > 0 {
+		values.Set("limit", strconv.Itoa(limit))
+	}
+	return values, nil
+}
+
+func parseWithdrawalRequests(data []byte) ([]WithdrawalRequest, error) {
+	var raw []struct {
+		ID            interface{} `json:"id"`
+		Datetime      interface{} `json:"datetime"`
+		Type          interface{} `json:"type"`
+		Currency      string      `json:"currency"`
+		Amount        interface{} `json:"amount"`
+		Status        interface{} `json:"status"`
+		TransactionID interface{} `json:"transaction_id"`
+		Address       string      `json:"address"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	result := make([]WithdrawalRequest, len(raw))
+	for i, r := range raw {
+		req := WithdrawalRequest{
+			Currency:      r.Currency,
+			TransactionID: stringValue(r.TransactionID),
+			Address:       r.Address,
+		}
+		var err error
+		if req.ID, err = parseIntValue(r.ID); err != nil {
+			return nil, errors.Wrapf(err, "request %d: id parsing error", i)
+		}
+		if req.Time, err = parseTimeValue(r.Datetime); err != nil {
+			return nil, errors.Wrapf(err, "request %d: datetime parsing error", i)
+		}
+		typ, err := parseIntValue(r.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "request %d: type parsing error", i)
+		}
+		req.Type = int(typ)
+		if req.Amount, err = parseNumber("amount", r.Amount); err != nil {
+			return nil, errors.Wrapf(err, "request %d", i)
+		}
+		status, err := parseIntValue(r.Status)
+		if err != nil {
+			return nil, errors.Wrapf(err, "request %d: status parsing error", i)
+		}
+		req.Status = WithdrawalStatus(status)
+		result[i] = req
+	}
+	return result, nil
+}
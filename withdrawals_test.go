@@ -0,0 +1,322 @@
+package bitstamp
+
+import (
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestWithdrawalValues(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		expected string
+	}{
+		{amount: 0.5, expected: "address=1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa&amount=0.5"},
+		{amount: 0.00001, expected: "address=1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa&amount=0.00001"},
+		{amount: 0.123456789, expected: "address=1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa&amount=0.12345678"},
+		{amount: 0.999999999, expected: "address=1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa&amount=0.99999999"},
+		{amount: 12, expected: "address=1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa&amount=12"},
+	}
+	for _, test := range tests {
+		values, err := withdrawalValues("1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa", test.amount, withdrawalAmountDecimals("btc"))
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", test.amount, err)
+			continue
+		}
+		if got := values.Encode(); got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+	}
+	for _, address := range []string{"", " ", "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa ", "1AKj QMj5"} {
+		if _, err := withdrawalValues(address, 1, withdrawalAmountDecimals("btc")); err == nil {
+			t.Errorf("%q: error expected", address)
+		}
+	}
+	if _, err := withdrawalValues("1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa", 0, withdrawalAmountDecimals("btc")); err == nil {
+		t.Error("error expected for zero amount")
+	}
+	if _, err := withdrawalValues("1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa", 0.000000001, withdrawalAmountDecimals("btc")); err == nil {
+		t.Error("error expected for an amount truncated to zero")
+	}
+}
+
+func TestWithdrawalAmountDecimals(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		expected string
+	}{
+		{"eth", 1.123456789, "1.12345679"},
+		{"eth", 0.00000001, "0.00000001"},
+		{"usdc", 1.123456789, "1.123457"},
+		{"usdc", 0.0000001, "0"},
+		{"usdt", 100.5, "100.5"},
+		{"link", 2.000000001, "2"},
+		{"pax", 3.3, "3.3"},
+		{"unknown", 0.123456789, "0.12345679"},
+	}
+	for _, test := range tests {
+		if got := formatDecimals(test.amount, withdrawalAmountDecimals(test.currency)); got != test.expected {
+			t.Errorf("%s %v: expected %s, got %s", test.currency, test.amount, test.expected, got)
+		}
+	}
+}
+
+func TestXRPWithdrawalValues(t *testing.T) {
+	const address = "rDsbeomae4FXwgQTJp9Rs64Qg9vDiTCdBv"
+	tag := func(v int64) *int64 { return &v }
+	tests := []struct {
+		tag      *int64
+		expected string
+	}{
+		{tag: nil, expected: "address=" + address + "&amount=10.5"},
+		{tag: tag(0), expected: "address=" + address + "&amount=10.5&destination_tag=0"},
+		{tag: tag(123456), expected: "address=" + address + "&amount=10.5&destination_tag=123456"},
+		{tag: tag(4294967295), expected: "address=" + address + "&amount=10.5&destination_tag=4294967295"},
+	}
+	for _, test := range tests {
+		values, err := cryptoWithdrawalValues("xrp", address, 10.5, WithdrawOpts{DestinationTag: test.tag})
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		if got := values.Encode(); got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+	}
+	for _, invalid := range []int64{-1, 4294967296} {
+		if _, err := cryptoWithdrawalValues("xrp", address, 10.5, WithdrawOpts{DestinationTag: tag(invalid)}); err == nil {
+			t.Errorf("%d: error expected", invalid)
+		}
+	}
+}
+
+func TestMemoWithdrawalValues(t *testing.T) {
+	const address = "GAHK7EEG2WWHVKDNT4CEQFZGKF2LGDSW2IVM4S5DP42RBW3K6BTODB4A"
+	memo := func(v string) *string { return &v }
+	tests := []struct {
+		memo     *string
+		expected string
+	}{
+		{memo: nil, expected: "address=" + address + "&amount=25.1234567"},
+		{memo: memo(""), expected: "address=" + address + "&amount=25.1234567&memo_id="},
+		{memo: memo("1072345"), expected: "address=" + address + "&amount=25.1234567&memo_id=1072345"},
+	}
+	for _, test := range tests {
+		values, err := cryptoWithdrawalValues("xlm", address, 25.12345671, WithdrawOpts{MemoID: test.memo})
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			continue
+		}
+		if got := values.Encode(); got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+	}
+}
+
+func TestCryptoWithdrawalValues(t *testing.T) {
+	values, err := cryptoWithdrawalValues("usdt", "0xabc", 10.1234567, WithdrawOpts{Network: "ethereum"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "address=0xabc&amount=10.123456&network=ethereum"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	values, err = cryptoWithdrawalValues("usdt", "0xabc", 10.1234567, WithdrawOpts{Decimals: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "address=0xabc&amount=10.12"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	values, err = cryptoWithdrawalValues("btc", "bc1abc", 0.5, WithdrawOpts{
+		TravelRule: TravelRuleInfo{VASPName: "Other Exchange", BeneficiaryName: "John Doe"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "address=bc1abc&amount=0.5&beneficiary_name=John+Doe&vasp_name=Other+Exchange"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	for _, currency := range []string{"", "BTC", "b-c", "btc/usd"} {
+		if _, err := cryptoWithdrawalValues(currency, "0xabc", 1, WithdrawOpts{}); err == nil {
+			t.Errorf("%q: error expected", currency)
+		}
+	}
+}
+
+func TestWithdrawCurrencyPath(t *testing.T) {
+	paths := map[string]func(*Api) (int64, error){
+		"/eth_withdrawal/":  func(api *Api) (int64, error) { return api.WithdrawETH("0xabc", 1) },
+		"/usdc_withdrawal/": func(api *Api) (int64, error) { return api.WithdrawUSDC("0xabc", 1) },
+		"/usdt_withdrawal/": func(api *Api) (int64, error) { return api.WithdrawUSDT("0xabc", 1) },
+		"/link_withdrawal/": func(api *Api) (int64, error) { return api.WithdrawLINK("0xabc", 1) },
+		"/pax_withdrawal/":  func(api *Api) (int64, error) { return api.WithdrawPAX("0xabc", 1) },
+		"/xrp_withdrawal/":  func(api *Api) (int64, error) { return api.WithdrawXRP("rabc", 1, nil) },
+		"/xlm_withdrawal/":  func(api *Api) (int64, error) { return api.WithdrawXLM("GABC", 1, nil) },
+		"/ada_withdrawal/":  func(api *Api) (int64, error) { return api.CryptoWithdraw("ADA", "addr1", 1, WithdrawOpts{}) },
+	}
+	api := NewWithKey("key", "secret")
+	for path, withdraw := range paths {
+		_, restore := withFakeExchange(map[string]fakeHandler{
+			path: func(url.Values) (int, string) { return 200, `{"id": 7}` },
+		})
+		id, err := withdraw(api)
+		restore()
+		if err != nil || id != 7 {
+			t.Errorf("%s: unexpected result %d, %v", path, id, err)
+		}
+	}
+}
+
+func TestWithdrawUnknownCurrency(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/trading_pairs_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent int
+	_, restore := withFakeExchange(map[string]fakeHandler{
+		"/trading-pairs-info/": func(url.Values) (int, string) { return 200, string(data) },
+		"/bct_withdrawal/": func(url.Values) (int, string) {
+			sent++
+			return 200, `{"id": 7}`
+		},
+		"/btc_withdrawal/": func(url.Values) (int, string) { return 200, `{"id": 8}` },
+	})
+	defer restore()
+	api := NewWithKey("key", "secret")
+	api.EnableSymbolValidation(time.Hour)
+	// the currency is not checked, until the pairs are fetched.
+	if _, err := api.CryptoWithdraw("bct", "addr", 1, WithdrawOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.RefreshSymbols(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.CryptoWithdraw("BCT", "addr", 1, WithdrawOpts{}); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("expected ErrUnknownCurrency, got %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("the withdrawal must not be sent, got %d requests", sent)
+	}
+	if id, err := api.WithdrawBTC("addr", 1); err != nil || id != 8 {
+		t.Errorf("unexpected result %d, %v", id, err)
+	}
+}
+
+func TestWithdrawalFixtures(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/withdrawal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := parseWithdrawalID(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 11093726 {
+		t.Errorf("unexpected id %d", id)
+	}
+	errorFixtures := map[string]error{
+		"testdata/withdrawal_not_allowed.json":  ErrWithdrawalNotAllowed,
+		"testdata/withdrawal_insufficient.json": ErrInsufficientFunds,
+		"testdata/withdrawal_travel_rule.json":  ErrTravelRuleInfoRequired,
+	}
+	for name, expected := range errorFixtures {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = withdrawalError(parseAPIError(data))
+		if !errors.Is(err, expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, err)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: *APIError expected", name)
+		}
+	}
+}
+
+func TestParseWithdrawalRequests(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/withdrawal_requests.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, err := parseWithdrawalRequests(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []WithdrawalRequest{
+		{
+			ID:            11093726,
+			Time:          time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC),
+			Type:          1,
+			Currency:      "BTC",
+			Amount:        0.5,
+			Status:        WithdrawalFinished,
+			TransactionID: "4aaa02d3cd0a9342a1f98ac4ca89e7ed2c6ae9bf8c90a1ee1ccc5b69c6a7a2b9",
+			Address:       "1AKjQMj5wLxEajVqFLZFjKgYwyb9wPmHPa",
+		},
+		{
+			ID:       11093727,
+			Time:     time.Date(2020, 3, 2, 8, 30, 15, 0, time.UTC),
+			Type:     0,
+			Currency: "EUR",
+			Amount:   1000,
+			Status:   WithdrawalInProcess,
+		},
+		{
+			ID:       11093728,
+			Time:     time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC),
+			Type:     16,
+			Currency: "ETH",
+			Amount:   2,
+			Status:   WithdrawalFailed,
+			Address:  "0x5a3b5c0e2b9b5e8d0b4d7a6c5b8e3f2a1d0c9b8a",
+		},
+	}
+	if !reflect.DeepEqual(expected, requests) {
+		t.Errorf("expected %+v, got %+v", expected, requests)
+	}
+}
+
+func TestWithdrawalStatusString(t *testing.T) {
+	expected := map[WithdrawalStatus]string{
+		WithdrawalOpen:      "open",
+		WithdrawalInProcess: "in process",
+		WithdrawalFinished:  "finished",
+		WithdrawalCanceled:  "canceled",
+		WithdrawalFailed:    "failed",
+		WithdrawalStatus(9): "unknown(9)",
+	}
+	for status, str := range expected {
+		if status.String() != str {
+			t.Errorf("expected %s, got %s", str, status.String())
+		}
+	}
+}
+
+func TestWithdrawalRequestsValues(t *testing.T) {
+	now := time.Unix(1583150000, 0)
+	values, err := withdrawalRequestsValues(now.Add(-2*time.Hour), 50, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "limit=50&timedelta=7200"; values.Encode() != expected {
+		t.Errorf("expected %q, got %q", expected, values.Encode())
+	}
+	values, err = withdrawalRequestsValues(time.Time{}, 0, now)
+	if err != nil || values.Encode() != "" {
+		t.Errorf("unexpected result %q, %v", values.Encode(), err)
+	}
+	if _, err := withdrawalRequestsValues(now.Add(time.Hour), 0, now); err == nil {
+		t.Error("error expected for future since")
+	}
+	if _, err := withdrawalRequestsValues(time.Time{}, 1001, now); err == nil {
+		t.Error("error expected for too big limit")
+	}
+}
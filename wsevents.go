@@ -0,0 +1,174 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LiveTrade is a trade sent to the live_trades channels.
+type LiveTrade struct {
+	ID int64
+	// Time has microsecond precision, if the api sends microtimestamp.
+	Time   time.Time
+	Price  float64
+	Amount float64
+	Type   TradeType
+	// BuyOrderID and SellOrderID are the ids of the matched orders.
+	BuyOrderID  int64
+	SellOrderID int64
+}
+
+// LiveOrder is an order change sent to the live_orders channels.
+type LiveOrder struct {
+	// Event is order_created, order_changed or order_deleted.
+	Event string
+	ID    int64
+	Type  OrderType
+	// Time has microsecond precision, if the api sends microtimestamp.
+	Time   time.Time
+	Price  float64
+	Amount float64
+}
+
+// OrderBook decodes the data of an order_book channel event.
+// It returns an error matching ErrWrongChannelType for the other channels.
+func (e *WsEvent) OrderBook() (*OrderBook, error) {
+	if err := e.checkChannel(ChannelTypeOrderBook); err != nil {
+		return nil, err
+	}
+	if e.Event != "data" {
+		return nil, errors.Errorf("unexpected %s event", e.Event)
+	}
+	return decodeOrderBook(e.Data, false)
+}
+
+// DetailOrderBook decodes the data of a detail_order_book channel event.
+// It returns an error matching ErrWrongChannelType for the other channels.
+func (e *WsEvent) DetailOrderBook() (*GroupedOrderBook, error) {
+	if err := e.checkChannel(ChannelTypeDetailOrderBook); err != nil {
+		return nil, err
+	}
+	if e.Event != "data" {
+		return nil, errors.Errorf("unexpected %s event", e.Event)
+	}
+	return parseGroupedOrderBook(e.Data, OrderBookUngrouped)
+}
+
+// Trade decodes the data of a live_trades channel event.
+// It returns an error matching ErrWrongChannelType for the other channels.
+func (e *WsEvent) Trade() (*LiveTrade, error) {
+	if err := e.checkChannel(ChannelTypeLiveTrades); err != nil {
+		return nil, err
+	}
+	if e.Event != "trade" {
+		return nil, errors.Errorf("unexpected %s event", e.Event)
+	}
+	return parseLiveTrade(e.Data)
+}
+
+// OrderEvent decodes the data of a live_orders channel event.
+// It returns an error matching ErrWrongChannelType for the other channels.
+func (e *WsEvent) OrderEvent() (*LiveOrder, error) {
+	if err := e.checkChannel(ChannelTypeLiveOrders); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(e.Event, "order_") {
+		return nil, errors.Errorf("unexpected %s event", e.Event)
+	}
+	order, err := parseLiveOrder(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	order.Event = e.Event
+	return order, nil
+}
+
+// Pair returns the pair of the event channel, like btcusd, or an empty string,
+// if the channel name is not known. See ParseChannel.
+func (e *WsEvent) Pair() string {
+	_, pair, err := ParseChannel(e.Channel)
+	if err != nil {
+		return ""
+	}
+	return pair
+}
+
+func (e *WsEvent) checkChannel(expected ChannelType) error {
+	if t, _, err := ParseChannel(e.Channel); err != nil || t != expected {
+		return errors.Wrapf(ErrWrongChannelType, "expected %s channel, got %q", expected, e.Channel)
+	}
+	return nil
+}
+
+// liveTime returns the time of a live event, preferring microtimestamp.
+func liveTime(timestamp, microtimestamp *flexibleInt64) time.Time {
+	switch {
+	case microtimestamp != nil:
+		return unixMicro(int64(*microtimestamp))
+	case timestamp != nil:
+		return time.Unix(int64(*timestamp), 0)
+	default:
+		return time.Time{}
+	}
+}
+
+func parseLiveTrade(data []byte) (*LiveTrade, error) {
+	raw := struct {
+		ID             flexibleInt64  `json:"id"`
+		Timestamp      *flexibleInt64 `json:"timestamp"`
+		Microtimestamp *flexibleInt64 `json:"microtimestamp"`
+		Price          numberField    `json:"price"`
+		Amount         numberField    `json:"amount"`
+		Type           flexibleInt64  `json:"type"`
+		BuyOrderID     flexibleInt64  `json:"buy_order_id"`
+		SellOrderID    flexibleInt64  `json:"sell_order_id"`
+	}{
+		Price:  numberField{name: "price"},
+		Amount: numberField{name: "amount"},
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "trade decoding error")
+	}
+	if raw.Type != flexibleInt64(TradeBuy) && raw.Type != flexibleInt64(TradeSell) {
+		return nil, errors.Errorf("unknown trade type %d", raw.Type)
+	}
+	return &LiveTrade{
+		ID:          int64(raw.ID),
+		Time:        liveTime(raw.Timestamp, raw.Microtimestamp),
+		Price:       raw.Price.value,
+		Amount:      raw.Amount.value,
+		Type:        TradeType(raw.Type),
+		BuyOrderID:  int64(raw.BuyOrderID),
+		SellOrderID: int64(raw.SellOrderID),
+	}, nil
+}
+
+func parseLiveOrder(data []byte) (*LiveOrder, error) {
+	raw := struct {
+		ID             flexibleInt64  `json:"id"`
+		Datetime       *flexibleInt64 `json:"datetime"`
+		Microtimestamp *flexibleInt64 `json:"microtimestamp"`
+		Price          numberField    `json:"price"`
+		Amount         numberField    `json:"amount"`
+		OrderType      flexibleInt64  `json:"order_type"`
+	}{
+		Price:  numberField{name: "price"},
+		Amount: numberField{name: "amount"},
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "order decoding error")
+	}
+	if raw.OrderType != flexibleInt64(OrderBuy) && raw.OrderType != flexibleInt64(OrderSell) {
+		return nil, errors.Errorf("unknown order type %d", raw.OrderType)
+	}
+	return &LiveOrder{
+		ID:     int64(raw.ID),
+		Type:   OrderType(raw.OrderType),
+		Time:   liveTime(raw.Datetime, raw.Microtimestamp),
+		Price:  raw.Price.value,
+		Amount: raw.Amount.value,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func readWsEvent(t *testing.T, name string) *WsEvent {
+	t.Helper()
+	var ev WsEvent
+	if err := json.Unmarshal([]byte(readFixture(t, "testdata/"+name)), &ev); err != nil {
+		t.Fatal(err)
+	}
+	return &ev
+}
+
+func TestWsEventOrderBook(t *testing.T) {
+	ev := readWsEvent(t, "ws_order_book.json")
+	book, err := ev.OrderBook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 100 || len(book.Asks) != 100 {
+		t.Errorf("expected 100 levels on each side, got %d bids and %d asks", len(book.Bids), len(book.Asks))
+	}
+	if expected := unixMicro(1567755304123456); !book.Time.Equal(expected) {
+		t.Errorf("expected time %v, got %v", expected, book.Time)
+	}
+
+	detail := readWsEvent(t, "ws_detail_order_book.json")
+	grouped, err := detail.DetailOrderBook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grouped.Bids) == 0 || grouped.Bids[0].OrderID == 0 {
+		t.Errorf("expected bids with order ids, got %+v", grouped.Bids)
+	}
+	if _, err := detail.OrderBook(); !errors.Is(err, ErrWrongChannelType) {
+		t.Errorf("expected ErrWrongChannelType for a detail book, got %v", err)
+	}
+	if _, err := ev.DetailOrderBook(); !errors.Is(err, ErrWrongChannelType) {
+		t.Errorf("expected ErrWrongChannelType for an order book, got %v", err)
+	}
+}
+
+func TestWsEventTrade(t *testing.T) {
+	ev := readWsEvent(t, "ws_live_trade.json")
+	trade, err := ev.Trade()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &LiveTrade{
+		ID:          97654523,
+		Time:        time.Unix(1567755304, 968123000),
+		Price:       10453.28,
+		Amount:      0.02,
+		Type:        TradeBuy,
+		BuyOrderID:  1151558466924544,
+		SellOrderID: 1151558458142721,
+	}
+	if !reflect.DeepEqual(expected, trade) {
+		t.Errorf("expected %+v, got %+v", expected, trade)
+	}
+	if _, err := ev.OrderEvent(); !errors.Is(err, ErrWrongChannelType) {
+		t.Errorf("expected ErrWrongChannelType, got %v", err)
+	}
+}
+
+func TestWsEventOrderEvent(t *testing.T) {
+	ev := readWsEvent(t, "ws_live_order.json")
+	order, err := ev.OrderEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &LiveOrder{
+		Event:  "order_created",
+		ID:     1151558471090176,
+		Type:   OrderSell,
+		Time:   time.Unix(1567755304, 971254000),
+		Price:  10460.12,
+		Amount: 0.5,
+	}
+	if !reflect.DeepEqual(expected, order) {
+		t.Errorf("expected %+v, got %+v", expected, order)
+	}
+	if _, err := ev.Trade(); !errors.Is(err, ErrWrongChannelType) {
+		t.Errorf("expected ErrWrongChannelType, got %v", err)
+	}
+	if _, err := ev.OrderBook(); !errors.Is(err, ErrWrongChannelType) {
+		t.Errorf("expected ErrWrongChannelType, got %v", err)
+	}
+}
+
+func TestWsEventMalformed(t *testing.T) {
+	tests := []struct {
+		ev     WsEvent
+		decode func(*WsEvent) error
+		err    string
+	}{
+		{
+			ev:     WsEvent{Event: "bts:subscription_succeeded", Channel: "order_book_btcusd", Data: json.RawMessage(`{}`)},
+			decode: func(e *WsEvent) error { _, err := e.OrderBook(); return err },
+			err:    "unexpected bts:subscription_succeeded event",
+		},
+		{
+			ev:     WsEvent{Event: "trade", Channel: "live_trades_btcusd", Data: json.RawMessage(`{"id": 1, "price": "x", "amount": 1, "type": 0}`)},
+			decode: func(e *WsEvent) error { _, err := e.Trade(); return err },
+			err:    `price parsing error: invalid number "x"`,
+		},
+		{
+			ev:     WsEvent{Event: "trade", Channel: "live_trades_btcusd", Data: json.RawMessage(`{"id": 1, "price": 1, "amount": 1, "type": 2}`)},
+			decode: func(e *WsEvent) error { _, err := e.Trade(); return err },
+			err:    "unknown trade type 2",
+		},
+		{
+			ev:     WsEvent{Event: "order_deleted", Channel: "live_orders_btcusd", Data: json.RawMessage(`[]`)},
+			decode: func(e *WsEvent) error { _, err := e.OrderEvent(); return err },
+			err:    "order decoding error",
+		},
+		{
+			ev:     WsEvent{Event: "data", Channel: "", Data: json.RawMessage(`{}`)},
+			decode: func(e *WsEvent) error { _, err := e.OrderBook(); return err },
+			err:    `expected order_book channel, got "": wrong channel type`,
+		},
+	}
+	for _, test := range tests {
+		err := test.decode(&test.ev)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s %s: expected %q, got %v", test.ev.Channel, test.ev.Data, test.err, err)
+		}
+	}
+}
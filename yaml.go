@@ -0,0 +1,160 @@
+package bitstamp
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// yamlLine is a non-empty line of a yaml document.
+type yamlLine struct {
+	num    int
+	indent int
+	key    string
+	value  string
+}
+
+// yamlToJSON converts a yaml document to json. Only block mappings of scalars are supported,
+// which is enough for config files. Sequences, flow collections, anchors
+// and multi-line scalars are rejected.
+func yamlToJSON(data []byte) ([]byte, error) {
+	lines, err := yamlLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("empty yaml document")
+	}
+	m, rest, err := parseYAMLMapping(lines, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.Errorf("line %d: unexpected indentation", rest[0].num)
+	}
+	return json.Marshal(m)
+}
+
+func yamlLines(doc string) ([]yamlLine, error) {
+	var result []yamlLine
+	for i, text := range strings.Split(doc, "\n") {
+		num := i + 1
+		text = strings.TrimRight(stripYAMLComment(strings.TrimSuffix(text, "\r")), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || (trimmed == "---" && len(result) == 0) {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, errors.Errorf("line %d: tabs are not allowed in indentation", num)
+		}
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			return nil, errors.Errorf("line %d: sequences are not supported", num)
+		}
+		sep := strings.Index(trimmed, ": ")
+		if strings.HasSuffix(trimmed, ":") && (sep < 0 || sep == len(trimmed)-2) {
+			sep = len(trimmed) - 1
+		}
+		if sep <= 0 {
+			return nil, errors.Errorf("line %d: expected key: value", num)
+		}
+		result = append(result, yamlLine{
+			num:    num,
+			indent: len(text) - len(trimmed),
+			key:    strings.TrimSpace(trimmed[:sep]),
+			value:  strings.TrimSpace(trimmed[sep+1:]),
+		})
+	}
+	return result, nil
+}
+
+// stripYAMLComment removes a comment, which starts with # at the beginning of the line
+// or after a space, outside of quotes.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// parseYAMLMapping parses the lines of a mapping with the given indentation
+// and returns the remaining lines.
+func parseYAMLMapping(lines []yamlLine, indent int) (map[string]interface{}, []yamlLine, error) {
+	m := make(map[string]interface{})
+	for len(lines) > 0 {
+		l := lines[0]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, nil, errors.Errorf("line %d: unexpected indentation", l.num)
+		}
+		lines = lines[1:]
+		if _, found := m[l.key]; found {
+			return nil, nil, errors.Errorf("line %d: duplicate key %q", l.num, l.key)
+		}
+		if l.value != "" {
+			value, err := parseYAMLScalar(l.value)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "line %d", l.num)
+			}
+			m[l.key] = value
+			continue
+		}
+		if len(lines) == 0 || lines[0].indent <= indent {
+			m[l.key] = nil
+			continue
+		}
+		child, rest, err := parseYAMLMapping(lines, lines[0].indent)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[l.key], lines = child, rest
+	}
+	return m, lines, nil
+}
+
+// parseYAMLScalar converts a scalar to a string, an integer, a bool or nil.
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch s[0] {
+	case '"':
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid quoted string %s", s)
+		}
+		return value, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, errors.Errorf("invalid quoted string %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case '[', '{', '&', '*', '|', '>', '!':
+		return nil, errors.Errorf("unsupported value %s", s)
+	}
+	switch s {
+	case "null", "~":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,51 @@
+package bitstamp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	doc := `
+---
+# comment
+a: plain text # comment
+b: "quoted: \"#\""
+c: 'single ''quoted'''
+d: 42
+e: true
+f: ~
+g:
+  h: 1
+  i:
+    j: x:y
+k:
+`
+	data, err := yamlToJSON([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"a":"plain text","b":"quoted: \"#\"","c":"single 'quoted'","d":42,"e":true,"f":null,"g":{"h":1,"i":{"j":"x:y"}},"k":null}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestYAMLToJSONErrors(t *testing.T) {
+	tests := map[string]string{
+		"a: 1\na: 2":           "line 2: duplicate key",
+		"a:\n  - 1":            "line 2: sequences are not supported",
+		"a: [1, 2]":            "line 1: unsupported value",
+		"a: 'b":                "line 1: invalid quoted string",
+		"just text":            "line 1: expected key: value",
+		"a:\n\tb: 1":           "line 2: tabs are not allowed",
+		"  a: 1\nb: 2":         "line 2: unexpected indentation",
+		"a:\n    b: 1\n  c: 2": "line 3: unexpected indentation",
+	}
+	for doc, expected := range tests {
+		_, err := yamlToJSON([]byte(doc))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected %q, got %v", doc, expected, err)
+		}
+	}
+}